@@ -0,0 +1,88 @@
+package app
+
+import (
+	"CortexMCP/db/repository"
+	"CortexMCP/pkg/mcp"
+	"context"
+	"time"
+)
+
+// registerAuditTools registers the tools inspecting the audit log
+func registerAuditTools(s *mcp.Server, auditLog repository.AuditLogRepository) {
+	s.AddTool(mcp.Tool{
+		Name:        "audit_history",
+		Description: "List the changes made to a record, oldest first, with who made them and the changed values",
+		InputSchema: mcp.Schema{
+			Properties: map[string]mcp.Property{
+				"table":     {Type: "string", Description: "Table of the record, e.g. customer"},
+				"record_id": {Type: "string", Description: "Primary key of the record; composite keys are comma-separated"},
+			},
+			Required: []string{"table", "record_id"},
+		},
+		Annotations: readOnly,
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		table, err := args.String("table")
+		if err != nil {
+			return nil, err
+		}
+		recordID, err := args.String("record_id")
+		if err != nil {
+			return nil, err
+		}
+		return auditLog.FindByRecord(ctx, table, recordID)
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "audit_by_principal",
+		Description: "List the changes made by a principal, oldest first",
+		InputSchema: mcp.Schema{
+			Properties: map[string]mcp.Property{
+				"principal": {Type: "string", Description: "Principal as kind:id, e.g. staff:2 or client:claude-desktop"},
+			},
+			Required: []string{"principal"},
+		},
+		Annotations: readOnly,
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		p, err := args.String("principal")
+		if err != nil {
+			return nil, err
+		}
+		return auditLog.FindByPrincipal(ctx, p)
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "audit_by_date_range",
+		Description: "List the changes made between two times, oldest first",
+		InputSchema: mcp.Schema{
+			Properties: map[string]mcp.Property{
+				"start": {Type: "string", Description: "Start of the range (RFC 3339)"},
+				"end":   {Type: "string", Description: "End of the range (RFC 3339)"},
+			},
+			Required: []string{"start", "end"},
+		},
+		Annotations: readOnly,
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		start, err := timeArgument(args, "start")
+		if err != nil {
+			return nil, err
+		}
+		end, err := timeArgument(args, "end")
+		if err != nil {
+			return nil, err
+		}
+		return auditLog.FindByDateRange(ctx, start, end)
+	})
+}
+
+// timeArgument parses an RFC 3339 time argument
+func timeArgument(args mcp.Arguments, name string) (time.Time, error) {
+	value, err := args.String(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, mcp.InvalidParamsError("argument %q must be an RFC 3339 time", name)
+	}
+	return t, nil
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"CortexMCP/db/entity"
+	"CortexMCP/pkg/mcp"
+	"CortexMCP/pkg/principal"
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestServer_AuditHistory(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `audit_log` WHERE table_name = ? AND record_id = ? ORDER BY changed_at, audit_log_id")).
+		WithArgs("customer", "7").
+		WillReturnRows(sqlmock.NewRows([]string{"audit_log_id", "table_name", "record_id", "action", "before_data", "after_data", "principal", "changed_at"}).
+			AddRow(1, "customer", "7", "update", `{"active":true}`, `{"active":false}`, "staff:2", time.Now()))
+
+	var history []entity.AuditLog
+	text := toolText(t, callTool(t, s, "audit_history", map[string]any{"table": "customer", "record_id": "7"}))
+	if err := json.Unmarshal([]byte(text), &history); err != nil {
+		t.Fatalf("Failed to decode tool result: %v", err)
+	}
+	if len(history) != 1 || history[0].Principal != "staff:2" || string(history[0].After) != `{"active":false}` {
+		t.Errorf("Unexpected audit history: %s", text)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestServer_AuditByDateRange_InvalidTime(t *testing.T) {
+	repos, _ := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+
+	resp := callTool(t, s, "audit_by_date_range", map[string]any{"start": "yesterday", "end": "2026-01-01T00:00:00Z"})
+	if resp.Error == nil || resp.Error.Code != mcp.InvalidParams {
+		t.Errorf("Expected invalid params, got %+v", resp.Error)
+	}
+}
+
+func TestPrincipalMiddleware(t *testing.T) {
+	var got principal.Principal
+	handler := principalMiddleware("tool", func(ctx context.Context, args mcp.Arguments) (any, error) {
+		got, _ = principal.FromContext(ctx)
+		return nil, nil
+	})
+
+	s := mcp.NewServer("test", "1")
+	s.AddTool(mcp.Tool{Name: "whoami"}, handler)
+	s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"clientInfo":{"name":"inspector","version":"1"}}}`))
+	s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"whoami"}}`))
+	if got != principal.Client("inspector") {
+		t.Errorf("Expected the client principal, got %+v", got)
+	}
+
+	// A principal established upstream, e.g. by authentication, is kept
+	ctx := principal.NewContext(context.Background(), principal.Staff(2))
+	s.Handle(ctx, []byte(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"whoami"}}`))
+	if got != principal.Staff(2) {
+		t.Errorf("Expected the staff principal, got %+v", got)
+	}
+}
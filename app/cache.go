@@ -0,0 +1,78 @@
+package app
+
+import (
+	"CortexMCP/db/repository"
+	"CortexMCP/db/transfer"
+	"CortexMCP/pkg/mcp"
+	"CortexMCP/pkg/policy"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// cacheStatsURI is the resource listing the cache hit and miss counts
+const cacheStatsURI = "dvd://diagnostics/cache"
+
+// CacheConfig configures the cache of reference data read by the tools
+type CacheConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// Size is the number of query results kept
+	Size int `yaml:"size" mapstructure:"size" validate:"min=0"`
+
+	// TTL is how long a result is kept; it bounds how stale results get after writes made outside the
+	// server, e.g. by the import command
+	TTL time.Duration `yaml:"ttl" mapstructure:"ttl" validate:"min=0"`
+}
+
+// NewCache creates the in-memory cache of query results. Callers restricted to some stores get results of
+// their own, so that rows hidden from one store never reach another.
+func NewCache(cfg CacheConfig) *repository.Cache {
+	return repository.NewCache(repository.NewLRUCache(cfg.Size, cfg.TTL), func(ctx context.Context) string {
+		if grant, ok := policy.FromContext(ctx); ok && !grant.AllStores {
+			return fmt.Sprint(grant.StoreIDs)
+		}
+		return ""
+	})
+}
+
+// UseCache caches the results of the reference data repositories in cache. Casts edited from the actor
+// side and imports through the server invalidate the cached results too.
+func (r *Repositories) UseCache(cache *repository.Cache) {
+	r.Film = repository.NewCachedFilmRepository(r.Film, cache)
+	r.Category = repository.NewCachedCategoryRepository(r.Category, cache)
+	r.Store = repository.NewCachedStoreRepository(r.Store, cache)
+	r.Actor = repository.NewCachedActorRepository(r.Actor, cache)
+	r.Transfer = cachedTransfer{Service: r.Transfer, cache: cache}
+}
+
+// cachedTransfer is a transfer.Service invalidating every cached result of the tables it imports into, since
+// imports write through repositories of their own
+type cachedTransfer struct {
+	transfer.Service
+	cache *repository.Cache
+}
+
+// Import imports rows into a table and invalidates the table
+func (t cachedTransfer) Import(ctx context.Context, table string, format transfer.Format, r io.Reader, opts transfer.ImportOptions) (*transfer.ImportReport, error) {
+	report, err := t.Service.Import(ctx, table, format, r, opts)
+	t.cache.Invalidate(ctx, table)
+	return report, err
+}
+
+// WithCacheStats publishes the statistics of cache as the dvd://diagnostics/cache resource
+func WithCacheStats(cache *repository.Cache) Option {
+	return func(s *mcp.Server) {
+		s.AddResource(mcp.Resource{
+			URI:         cacheStatsURI,
+			Name:        "cache",
+			Description: "Cache hits, misses and invalidations by table",
+			MimeType:    "application/json",
+		}, func(ctx context.Context) (string, error) {
+			data, err := json.Marshal(cache.Stats())
+			return string(data), err
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"CortexMCP/db/entity"
+	"CortexMCP/db/repository"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestServer_Cache(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	cache := NewCache(CacheConfig{Enabled: true, Size: 10, TTL: time.Minute})
+	repos.UseCache(cache)
+	s := NewServer(testConfig(t), repos, WithCacheStats(cache))
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `film` WHERE `film`.`id` = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "film_id", "title"}).AddRow(1, 1, "The Matrix"))
+	for i := 0; i < 2; i++ {
+		toolText(t, callTool(t, s, "film_find_by_id", map[string]any{"film_id": 1}))
+	}
+
+	var stats map[string]repository.CacheStats
+	if err := json.Unmarshal([]byte(readResource(t, s, cacheStatsURI)), &stats); err != nil {
+		t.Fatalf("Failed to decode cache stats: %v", err)
+	}
+	if stats["film"].Hits != 1 || stats["film"].Misses != 1 {
+		t.Errorf("Expected one miss then one hit, got %+v", stats)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestServer_CacheSeesActorCastEdits(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	cache := NewCache(CacheConfig{Enabled: true, Size: 10, TTL: time.Minute})
+	repos.UseCache(cache)
+	s := NewServer(testConfig(t), repos, WithCacheStats(cache))
+
+	findByActor := regexp.QuoteMeta("SELECT `film`.`id`")
+	mock.ExpectQuery(findByActor).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "film_id", "title"}).AddRow(1, 1, "The Matrix"))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `actor` SET `version`=version + 1")).
+		WithArgs(sqlmock.AnyArg(), 5, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `film_actors`")).
+		WithArgs(2, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(findByActor).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "film_id", "title"}).AddRow(1, 1, "The Matrix").AddRow(2, 2, "Speed"))
+
+	toolText(t, callTool(t, s, "film_find_by_actor", map[string]any{"actor_id": 5}))
+	var films []entity.Film
+	text := toolText(t, callTool(t, s, "actor_add_films", map[string]any{"actor_id": 5, "version": 2, "film_ids": []uint{2}}))
+	if err := json.Unmarshal([]byte(text), &films); err != nil {
+		t.Fatalf("Failed to decode tool result: %v", err)
+	}
+	if len(films) != 2 {
+		t.Errorf("Expected the films of the edited cast, got %+v", films)
+	}
+
+	var stats map[string]repository.CacheStats
+	if err := json.Unmarshal([]byte(readResource(t, s, cacheStatsURI)), &stats); err != nil {
+		t.Fatalf("Failed to decode cache stats: %v", err)
+	}
+	if stats["film"].Hits != 0 || stats["film"].Invalidations != 1 {
+		t.Errorf("Expected the edit to invalidate the cached films, got %+v", stats)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestServer_CacheSeesImports(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	cache := NewCache(CacheConfig{Enabled: true, Size: 10, TTL: time.Minute})
+	repos.UseCache(cache)
+	s := NewServer(testConfig(t), repos, WithCacheStats(cache))
+
+	listCategories := regexp.QuoteMeta("SELECT * FROM `category`")
+	mock.ExpectQuery(listCategories).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "name"}).AddRow(1, 1, "Action"))
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `category`")).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(listCategories).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "name"}).AddRow(1, 1, "Action").AddRow(2, 2, "Documentary"))
+
+	for i := 0; i < 2; i++ {
+		toolText(t, callTool(t, s, "category_list", nil))
+	}
+	toolText(t, callTool(t, s, "table_import", map[string]any{"table": "category", "format": "jsonl", "content": "{\"name\":\"Documentary\"}\n"}))
+	if text := toolText(t, callTool(t, s, "category_list", nil)); !strings.Contains(text, "Documentary") {
+		t.Errorf("Expected the imported category to be listed, got %s", text)
+	}
+
+	var stats map[string]repository.CacheStats
+	if err := json.Unmarshal([]byte(readResource(t, s, cacheStatsURI)), &stats); err != nil {
+		t.Fatalf("Failed to decode cache stats: %v", err)
+	}
+	if stats["category"].Hits != 1 || stats["category"].Misses != 2 {
+		t.Errorf("Expected the import to invalidate the cached categories, got %+v", stats)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestServer_CacheStores(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	cache := NewCache(CacheConfig{Enabled: true, Size: 10, TTL: time.Minute})
+	repos.UseCache(cache)
+	s := NewServer(testConfig(t), repos)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `store`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "store_id", "store_name"}).AddRow(1, 1, "Downtown"))
+	for i := 0; i < 2; i++ {
+		toolText(t, callTool(t, s, "store_find_by_id", map[string]any{"store_id": 1}))
+	}
+	if stats := cache.Stats()["store"]; stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected one miss then one hit, got %+v", stats)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
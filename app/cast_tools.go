@@ -0,0 +1,96 @@
+package app
+
+import (
+	"CortexMCP/db/repository"
+	"CortexMCP/pkg/mcp"
+	"context"
+)
+
+// castEdit is an edit of the film_actors table made by a cast tool, based on the given version of the owner
+type castEdit func(ctx context.Context, ownerID, version uint, linkedIDs ...uint) error
+
+// Annotations of the cast tools. Cast edits are not idempotent: each one increments the version of the
+// edited record, so repeating an edit with the same version fails as stale.
+var (
+	additiveEdit    = &mcp.ToolAnnotations{}
+	destructiveEdit = &mcp.ToolAnnotations{DestructiveHint: true}
+)
+
+// castSchema is the input schema of a cast tool editing the links of one record
+func castSchema(owner, ownerDescription, linked, linkedDescription string) mcp.Schema {
+	return mcp.Schema{
+		Properties: map[string]mcp.Property{
+			owner:     {Type: "integer", Description: ownerDescription},
+			"version": {Type: "integer", Description: "Version of the edited record as last read; the edit fails if the record has changed since"},
+			linked:    {Type: "array", Description: linkedDescription, Items: &mcp.Property{Type: "integer"}},
+		},
+		Required: []string{owner, "version", linked},
+	}
+}
+
+// castHandler applies edit and returns the records linked to the owner afterwards
+func castHandler[T any](owner, linked string, edit castEdit, result func(ctx context.Context, id uint, opts ...repository.QueryOption) ([]T, error)) mcp.ToolHandler {
+	return func(ctx context.Context, args mcp.Arguments) (any, error) {
+		ownerID, err := args.Uint(owner)
+		if err != nil {
+			return nil, err
+		}
+		version, err := args.Uint("version")
+		if err != nil {
+			return nil, err
+		}
+		linkedIDs, err := args.Uints(linked)
+		if err != nil {
+			return nil, err
+		}
+		if err := edit(ctx, ownerID, version, linkedIDs...); err != nil {
+			return nil, err
+		}
+		return result(ctx, ownerID)
+	}
+}
+
+// registerCastTools registers the catalog editing tools for film casts
+func registerCastTools(s *mcp.Server, repos *Repositories) {
+	s.AddTool(mcp.Tool{
+		Name:        "film_add_actors",
+		Description: "Add actors to the cast of a film and return the resulting cast. Actors already in the cast are skipped.",
+		InputSchema: castSchema("film_id", "Film to edit", "actor_ids", "Actors to add"),
+		Annotations: additiveEdit,
+	}, castHandler("film_id", "actor_ids", repos.Film.AddActors, repos.Actor.FindByFilm))
+
+	s.AddTool(mcp.Tool{
+		Name:        "film_remove_actors",
+		Description: "Remove actors from the cast of a film and return the resulting cast",
+		InputSchema: castSchema("film_id", "Film to edit", "actor_ids", "Actors to remove"),
+		Annotations: destructiveEdit,
+	}, castHandler("film_id", "actor_ids", repos.Film.RemoveActors, repos.Actor.FindByFilm))
+
+	s.AddTool(mcp.Tool{
+		Name:        "film_replace_cast",
+		Description: "Set the cast of a film to exactly the given actors and return it",
+		InputSchema: castSchema("film_id", "Film to edit", "actor_ids", "Complete cast of the film"),
+		Annotations: destructiveEdit,
+	}, castHandler("film_id", "actor_ids", repos.Film.ReplaceCast, repos.Actor.FindByFilm))
+
+	s.AddTool(mcp.Tool{
+		Name:        "actor_add_films",
+		Description: "Add an actor to the cast of films and return the actor's films. Films the actor already appears in are skipped.",
+		InputSchema: castSchema("actor_id", "Actor to edit", "film_ids", "Films to add the actor to"),
+		Annotations: additiveEdit,
+	}, castHandler("actor_id", "film_ids", repos.Actor.AddFilms, repos.Film.FindByActor))
+
+	s.AddTool(mcp.Tool{
+		Name:        "actor_remove_films",
+		Description: "Remove an actor from the cast of films and return the actor's films",
+		InputSchema: castSchema("actor_id", "Actor to edit", "film_ids", "Films to remove the actor from"),
+		Annotations: destructiveEdit,
+	}, castHandler("actor_id", "film_ids", repos.Actor.RemoveFilms, repos.Film.FindByActor))
+
+	s.AddTool(mcp.Tool{
+		Name:        "actor_replace_films",
+		Description: "Set the films an actor appears in to exactly the given films and return them",
+		InputSchema: castSchema("actor_id", "Actor to edit", "film_ids", "Complete list of the actor's films"),
+		Annotations: destructiveEdit,
+	}, castHandler("actor_id", "film_ids", repos.Actor.ReplaceFilms, repos.Film.FindByActor))
+}
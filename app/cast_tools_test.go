@@ -0,0 +1,78 @@
+package app
+
+import (
+	"CortexMCP/db/entity"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestServer_FilmAddActors(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `film` SET `version`=version + 1")).
+		WithArgs(sqlmock.AnyArg(), 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `film_actors`")).
+		WithArgs(1, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `actor`.`id`")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "actor_id", "first_name", "last_name"}).AddRow(5, 5, "Penelope", "Guiness"))
+
+	var cast []entity.Actor
+	text := toolText(t, callTool(t, s, "film_add_actors", map[string]any{"film_id": 1, "version": 2, "actor_ids": []uint{5}}))
+	if err := json.Unmarshal([]byte(text), &cast); err != nil {
+		t.Fatalf("Failed to decode tool result: %v", err)
+	}
+	if len(cast) != 1 || cast[0].ActorID != 5 {
+		t.Errorf("Expected the resulting cast, got %+v", cast)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestServer_CastToolsAreAnnotated(t *testing.T) {
+	repos, _ := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+
+	for _, tool := range s.Tools() {
+		switch tool.Name {
+		case "film_add_actors", "film_remove_actors", "film_replace_cast", "actor_add_films", "actor_remove_films", "actor_replace_films":
+			if tool.Annotations == nil || tool.Annotations.ReadOnlyHint {
+				t.Errorf("Expected %s to be annotated as a write tool", tool.Name)
+			}
+		}
+	}
+}
+
+func TestServer_CastToolRejectsStaleVersion(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+
+	// The film is at version 3 by now
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `film` SET `version`=version + 1")).
+		WithArgs(sqlmock.AnyArg(), 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `film`")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	resp := callTool(t, s, "film_replace_cast", map[string]any{"film_id": 1, "version": 2, "actor_ids": []uint{5}})
+	if resp.Error == nil || resp.Error.Code != CodeStale {
+		t.Errorf("Expected a stale version error, got %+v", resp.Error)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"CortexMCP/db/changes"
+	"CortexMCP/pkg/mcp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// URIs of the resources that change with rentals, reservations, inventory, payments and waitlists
+const (
+	availabilityURI     = "dvd://stores/%d/availability"
+	inventoryURI        = "dvd://inventory/%d"
+	rentalURI           = "dvd://rentals/%d"
+	customerWaitlistURI = "dvd://customers/%d/waitlist"
+	waitlistQueueURI    = "dvd://stores/%d/films/%d/waitlist"
+)
+
+// registerChangeResources registers the resources clients subscribe to for updates
+func registerChangeResources(s *mcp.Server, repos *Repositories) {
+	s.AddResourceTemplate(mcp.ResourceTemplate{
+		URITemplate: "dvd://stores/{store_id}/availability",
+		Name:        "store-availability",
+		Description: "Copies available for rent at a store, updated when rentals, reservations or inventory of the store change",
+		MimeType:    "application/json",
+	}, resourceByID("store_id", func(ctx context.Context, id uint) (any, error) {
+		return repos.Inventory.FindAvailableByStore(ctx, id)
+	}))
+	s.AddResourceTemplate(mcp.ResourceTemplate{
+		URITemplate: "dvd://inventory/{inventory_id}",
+		Name:        "inventory",
+		Description: "A copy of a film, updated when it changes or is rented or returned",
+		MimeType:    "application/json",
+	}, resourceByID("inventory_id", func(ctx context.Context, id uint) (any, error) {
+		return repos.Inventory.FindByID(ctx, id)
+	}))
+	s.AddResourceTemplate(mcp.ResourceTemplate{
+		URITemplate: "dvd://rentals/{rental_id}",
+		Name:        "rental",
+		Description: "A rental and its payments, updated when any of them changes",
+		MimeType:    "application/json",
+	}, resourceByID("rental_id", func(ctx context.Context, id uint) (any, error) {
+		return repos.Rental.FindByID(ctx, id)
+	}))
+	s.AddResourceTemplate(mcp.ResourceTemplate{
+		URITemplate: "dvd://customers/{customer_id}/waitlist",
+		Name:        "customer-waitlist",
+		Description: "Waitlist entries of a customer, updated when the customer joins or leaves a waitlist or a returned copy is held for them",
+		MimeType:    "application/json",
+	}, resourceByID("customer_id", func(ctx context.Context, id uint) (any, error) {
+		return repos.Waitlist.FindByCustomer(ctx, id)
+	}))
+	s.AddResourceTemplate(mcp.ResourceTemplate{
+		URITemplate: "dvd://stores/{store_id}/films/{film_id}/waitlist",
+		Name:        "film-waitlist",
+		Description: "Customers waiting for a film at a store, first come first, updated when the queue changes",
+		MimeType:    "application/json",
+	}, func(ctx context.Context, uri string, params map[string]string) (string, error) {
+		storeID, err := strconv.ParseUint(params["store_id"], 10, 0)
+		if err != nil {
+			return "", mcp.NewError(mcp.ResourceNotFound, "resource not found: "+uri)
+		}
+		return resourceByID("film_id", func(ctx context.Context, id uint) (any, error) {
+			return repos.Waitlist.FindQueue(ctx, id, uint(storeID))
+		})(ctx, uri, params)
+	})
+}
+
+// resourceByID returns a template handler serving as JSON what find returns for the ID in the named variable
+func resourceByID(param string, find func(ctx context.Context, id uint) (any, error)) mcp.ResourceTemplateHandler {
+	return func(ctx context.Context, uri string, params map[string]string) (string, error) {
+		id, err := strconv.ParseUint(params[param], 10, 0)
+		if err != nil {
+			return "", mcp.NewError(mcp.ResourceNotFound, "resource not found: "+uri)
+		}
+		value, err := find(ctx, uint(id))
+		if err != nil {
+			return "", err
+		}
+		data, err := json.Marshal(value)
+		return string(data), err
+	}
+}
+
+// PublishChanges notifies the clients of s subscribed to the resources affected by the changes from source,
+// until ctx is cancelled
+func PublishChanges(ctx context.Context, s *mcp.Server, source changes.Source) error {
+	return source.Run(ctx, func(change changes.Change) {
+		if !s.HasSubscriptions() {
+			return
+		}
+		for _, uri := range changedURIs(change) {
+			if err := s.ResourceUpdated(uri); err != nil {
+				log.Printf("failed to notify update of %s: %v", uri, err)
+			}
+		}
+	})
+}
+
+// changedURIs returns the URIs of the resources a change affects
+func changedURIs(change changes.Change) []string {
+	var uris []string
+	add := func(format string, ids ...uint) {
+		for _, id := range ids {
+			if id != 0 {
+				uris = append(uris, fmt.Sprintf(format, id))
+			}
+		}
+	}
+	switch change.Table {
+	case "rental":
+		add(rentalURI, change.RentalID)
+		add(inventoryURI, change.InventoryID, change.OldInventoryID)
+		add(availabilityURI, change.StoreID)
+	case "inventory":
+		add(inventoryURI, change.InventoryID)
+		add(availabilityURI, change.StoreID, change.OldStoreID)
+	case "reservation":
+		add(inventoryURI, change.InventoryID, change.OldInventoryID)
+		add(availabilityURI, change.StoreID)
+	case "payment":
+		add(rentalURI, change.RentalID)
+	case "waitlist":
+		add(customerWaitlistURI, change.CustomerID)
+		if change.StoreID != 0 && change.FilmID != 0 {
+			uris = append(uris, fmt.Sprintf(waitlistQueueURI, change.StoreID, change.FilmID))
+		}
+	}
+	return uris
+}
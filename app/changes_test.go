@@ -0,0 +1,99 @@
+package app
+
+import (
+	"CortexMCP/db/changes"
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// fakeSource delivers fixed changes once the server has subscriptions
+type fakeSource struct {
+	changes []changes.Change
+	ready   func() bool
+}
+
+func (f *fakeSource) Run(ctx context.Context, handle func(changes.Change)) error {
+	for !f.ready() {
+		time.Sleep(time.Millisecond)
+	}
+	for _, change := range f.changes {
+		handle(change)
+	}
+	return nil
+}
+
+func TestChangedURIs(t *testing.T) {
+	tests := []struct {
+		change changes.Change
+		want   []string
+	}{
+		{changes.Change{Table: "rental", RentalID: 5, InventoryID: 10, StoreID: 1},
+			[]string{"dvd://rentals/5", "dvd://inventory/10", "dvd://stores/1/availability"}},
+		{changes.Change{Table: "inventory", InventoryID: 10, StoreID: 2, OldStoreID: 1},
+			[]string{"dvd://inventory/10", "dvd://stores/2/availability", "dvd://stores/1/availability"}},
+		{changes.Change{Table: "payment", PaymentID: 3, RentalID: 5}, []string{"dvd://rentals/5"}},
+		{changes.Change{Table: "reservation", InventoryID: 10, StoreID: 1},
+			[]string{"dvd://inventory/10", "dvd://stores/1/availability"}},
+		{changes.Change{Table: "waitlist", WaitlistID: 4, CustomerID: 9, FilmID: 3, StoreID: 1},
+			[]string{"dvd://customers/9/waitlist", "dvd://stores/1/films/3/waitlist"}},
+	}
+	for _, tt := range tests {
+		if got := changedURIs(tt.change); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("changedURIs(%+v) = %v, want %v", tt.change, got, tt.want)
+		}
+	}
+}
+
+func TestServer_AvailabilityResource(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `inventory`.`id`")).
+		WithArgs("reserved", "out", "lost", 1, "active", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"inventory_id", "film_id", "store_id"}).AddRow(10, 2, 1))
+
+	if text := readResource(t, s, "dvd://stores/1/availability"); !strings.Contains(text, `"InventoryID":10`) {
+		t.Errorf("Expected the available copy, got %s", text)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPublishChanges(t *testing.T) {
+	repos, _ := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+	in, input := io.Pipe()
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() { done <- s.ServeStdio(context.Background(), in, &out) }()
+
+	if _, err := input.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"dvd://stores/1/availability"}}` + "\n")); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	source := &fakeSource{
+		changes: []changes.Change{
+			{Table: "rental", Op: "INSERT", RentalID: 5, InventoryID: 10, StoreID: 1},
+			{Table: "payment", Op: "INSERT", PaymentID: 3, RentalID: 5},
+		},
+		ready: s.HasSubscriptions,
+	}
+	if err := PublishChanges(context.Background(), s, source); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	input.Close()
+	<-done
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"params":{"uri":"dvd://stores/1/availability"}`) {
+		t.Errorf("Expected one update of the subscribed availability, got %q", lines)
+	}
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"CortexMCP/db/changes"
+	"CortexMCP/pkg/auth"
+	"CortexMCP/pkg/db"
+	"CortexMCP/pkg/policy"
+	"CortexMCP/pkg/ratelimit"
+	"CortexMCP/pkg/redact"
+	"CortexMCP/pkg/telemetry"
+	_ "embed"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed default_config.yaml
+var defaultConfig []byte
+
+// Config is the application configuration
+type Config struct {
+	Database  db.ConnectionConfig `yaml:"database" validate:"required" mapstructure:"database"`
+	Transfer  TransferConfig      `yaml:"transfer" mapstructure:"transfer"`
+	HTTP      HTTPConfig          `yaml:"http" mapstructure:"http"`
+	Auth      auth.Config         `yaml:"auth" mapstructure:"auth"`
+	Policy    policy.Config       `yaml:"policy" mapstructure:"policy"`
+	Redaction redact.Config       `yaml:"redaction" mapstructure:"redaction"`
+	Limits    ratelimit.Config    `yaml:"limits" mapstructure:"limits"`
+	Telemetry telemetry.Config    `yaml:"telemetry" mapstructure:"telemetry"`
+	Cache     CacheConfig         `yaml:"cache" mapstructure:"cache"`
+	Changes   changes.Config      `yaml:"changes" mapstructure:"changes"`
+	LostItems LostItemsConfig     `yaml:"lostItems" mapstructure:"lostItems"`
+}
+
+// HTTPConfig configures the HTTP transport started by the serve-http command
+type HTTPConfig struct {
+	// Addr is the address to listen on, e.g. ":8080"
+	Addr string `yaml:"addr" mapstructure:"addr" validate:"required"`
+
+	// Path is the path the MCP endpoint is served at
+	Path string `yaml:"path" mapstructure:"path" validate:"required,startswith=/"`
+}
+
+// TransferConfig configures imports and exports
+type TransferConfig struct {
+	// Dir is the directory export files and import reports are written to. Defaults to a directory in the
+	// system temporary directory.
+	Dir string `yaml:"dir" mapstructure:"dir"`
+
+	// TTL is how long a file and its resource are kept; zero keeps them until they are deleted by hand
+	TTL time.Duration `yaml:"ttl" mapstructure:"ttl" validate:"min=0"`
+}
+
+// LoadConfig reads and validates the configuration at path.
+// The embedded default configuration is used when path is empty.
+func LoadConfig(path string) (*Config, error) {
+	data := defaultConfig
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	if err := validator.New().Struct(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if err := cfg.LostItems.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: lostItems: %w", err)
+	}
+	if cfg.Policy.Enabled {
+		if _, err := policy.New(cfg.Policy); err != nil {
+			return nil, fmt.Errorf("invalid policy: %w", err)
+		}
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"CortexMCP/pkg/db"
+	"CortexMCP/pkg/mcp"
+	"context"
+	"encoding/json"
+)
+
+// slowQueriesURI is the resource listing recent slow queries
+const slowQueriesURI = "dvd://diagnostics/slow-queries"
+
+// registerDiagnosticResources registers the resources describing the server's database activity
+func registerDiagnosticResources(s *mcp.Server, queries *db.SlowQueries) {
+	s.AddResource(mcp.Resource{
+		URI:  slowQueriesURI,
+		Name: "slow-queries",
+		Description: "Recent SQL statements slower than database.log.slowThreshold, most recent first, with the " +
+			"correlation ID and principal of the request that ran them. Sensitive values are redacted. Only " +
+			"readable with a grant for all stores.",
+		MimeType: "application/json",
+	}, func(ctx context.Context) (string, error) {
+		// The statements of every store are listed, with values of any column but the redacted ones
+		if err := requireAllStores(ctx, slowQueriesURI); err != nil {
+			return "", err
+		}
+		data, err := json.Marshal(queries.List())
+		return string(data), err
+	})
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"CortexMCP/pkg/correlation"
+	"CortexMCP/pkg/db"
+	"CortexMCP/pkg/mcp"
+	"CortexMCP/pkg/principal"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestServer_SlowQueries(t *testing.T) {
+	repos, _ := newTestRepositories(t)
+	queries := db.NewSlowQueries(10)
+	queries.Add(db.SlowQuery{SQL: "SELECT * FROM rental", DurationMs: 1500, CorrelationID: "abc123"})
+	s := NewServer(testConfig(t), repos, WithSlowQueries(queries))
+
+	var listed []db.SlowQuery
+	if err := json.Unmarshal([]byte(readResource(t, s, slowQueriesURI)), &listed); err != nil {
+		t.Fatalf("Failed to decode slow queries: %v", err)
+	}
+	if len(listed) != 1 || listed[0].SQL != "SELECT * FROM rental" || listed[0].CorrelationID != "abc123" {
+		t.Errorf("Unexpected slow queries: %+v", listed)
+	}
+}
+
+func TestServer_SlowQueriesNeedAllStores(t *testing.T) {
+	repos, _ := newTestRepositories(t)
+	queries := db.NewSlowQueries(10)
+	queries.Add(db.SlowQuery{SQL: "SELECT * FROM rental WHERE customer_id = 7", DurationMs: 1500})
+	s := NewServer(policyConfig(t), repos, WithSlowQueries(queries))
+
+	if resp := readResourceAs(t, s, principal.Staff(1), slowQueriesURI); resp.Error == nil || resp.Error.Code != CodeForbidden {
+		t.Errorf("Expected a clerk of one store to be denied the slow queries, got %+v", resp.Error)
+	}
+	if resp := readResourceAs(t, s, principal.Staff(3), slowQueriesURI); resp.Error != nil {
+		t.Errorf("Expected an admin to read the slow queries, got %v", resp.Error)
+	}
+}
+
+func TestServer_CorrelationID(t *testing.T) {
+	repos, _ := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+	var ids []string
+	s.AddTool(mcp.Tool{Name: "probe", Annotations: readOnly}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		id, _ := correlation.FromContext(ctx)
+		ids = append(ids, id)
+		return nil, nil
+	})
+
+	toolText(t, callTool(t, s, "probe", map[string]any{}))
+	toolText(t, callTool(t, s, "probe", map[string]any{}))
+	if len(ids) != 2 || len(ids[0]) != 32 || ids[0] == ids[1] {
+		t.Errorf("Expected a distinct correlation ID per request, got %v", ids)
+	}
+}
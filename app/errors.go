@@ -0,0 +1,120 @@
+package app
+
+import (
+	"CortexMCP/db/repository"
+	"CortexMCP/pkg/mcp"
+	"CortexMCP/pkg/policy"
+	"CortexMCP/pkg/ratelimit"
+	"context"
+	"errors"
+	"log"
+	"math"
+)
+
+// JSON-RPC error codes for repository errors, taken from the implementation-defined server error range
+const (
+	CodeForbidden   = -32003
+	CodeNotFound    = -32004
+	CodeConflict    = -32009
+	CodeReferenced  = -32010
+	CodeStale       = -32011
+	CodeUnavailable = -32012
+	CodeValidation  = mcp.InvalidParams
+	CodeLimited     = -32029
+)
+
+// domainErrors maps repository domain errors to JSON-RPC errors with user-safe messages, and names them in metrics
+var domainErrors = []struct {
+	kind    error
+	name    string
+	code    int
+	message string
+}{
+	{policy.ErrForbidden, "forbidden", CodeForbidden, "permission denied"},
+	{repository.ErrNotFound, "not_found", CodeNotFound, "record not found"},
+	{repository.ErrConflict, "conflict", CodeConflict, "a record with the same unique value already exists"},
+	{repository.ErrReferenced, "referenced", CodeReferenced, "the record references a missing record or is still referenced by other records"},
+	{repository.ErrValidation, "validation", CodeValidation, "the record is invalid"},
+	{repository.ErrStaleEntity, "stale", CodeStale, "the record was changed since it was read; read it again and retry"},
+	{repository.ErrUnavailable, "unavailable", CodeUnavailable, "the copy is already rented or held, or the hold on it has expired"},
+}
+
+// toolError converts a repository error into a JSON-RPC error. Driver details never reach the client;
+// validation errors carry the failing fields and rate limit errors the seconds to wait before retrying;
+// unclassified errors are logged and reported as internal errors.
+func toolError(name string, err error) error {
+	var rpcErr *mcp.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+
+	var validationErr *repository.ValidationError
+	if errors.As(err, &validationErr) {
+		rpcErr = mcp.NewError(CodeValidation, "the record is invalid")
+		rpcErr.Data = map[string]any{"fields": validationErr.Fields}
+		return rpcErr
+	}
+
+	var limitErr *ratelimit.Error
+	if errors.As(err, &limitErr) {
+		rpcErr = mcp.NewError(CodeLimited, limitErr.Reason)
+		rpcErr.Data = map[string]any{"retryAfter": math.Ceil(limitErr.RetryAfter.Seconds())}
+		return rpcErr
+	}
+
+	for _, domain := range domainErrors {
+		if errors.Is(err, domain.kind) {
+			return mcp.NewError(domain.code, domain.message)
+		}
+	}
+
+	log.Printf("tool %s failed: %v", name, err)
+	return mcp.NewError(mcp.InternalError, "internal error")
+}
+
+// errorKind names the kind of a tool error for metrics: a domain error, rate_limited, invalid_params for
+// arguments rejected by the tool, rpc_error for other JSON-RPC errors, or internal
+func errorKind(err error) string {
+	var validationErr *repository.ValidationError
+	if errors.As(err, &validationErr) {
+		return "validation"
+	}
+	if errors.Is(err, ratelimit.ErrLimited) {
+		return "rate_limited"
+	}
+	for _, domain := range domainErrors {
+		if errors.Is(err, domain.kind) {
+			return domain.name
+		}
+	}
+	var rpcErr *mcp.Error
+	if errors.As(err, &rpcErr) {
+		if rpcErr.Code == mcp.InvalidParams {
+			return "invalid_params"
+		}
+		return "rpc_error"
+	}
+	return "internal"
+}
+
+// errorMiddleware maps the errors of every tool with toolError
+func errorMiddleware(name string, next mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, args mcp.Arguments) (any, error) {
+		result, err := next(ctx, args)
+		if err != nil {
+			return nil, toolError(name, err)
+		}
+		return result, nil
+	}
+}
+
+// errorResourceMiddleware maps the errors of every resource with toolError
+func errorResourceMiddleware(resource mcp.Resource, next mcp.ResourceHandler) mcp.ResourceHandler {
+	return func(ctx context.Context) (string, error) {
+		text, err := next(ctx)
+		if err != nil {
+			return "", toolError(resource.URI, err)
+		}
+		return text, nil
+	}
+}
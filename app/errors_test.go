@@ -0,0 +1,63 @@
+package app
+
+import (
+	"CortexMCP/db/repository"
+	"CortexMCP/pkg/mcp"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestToolError(t *testing.T) {
+	driverErr := errors.New(`pq: duplicate key value violates unique constraint "customer_email_key"`)
+
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{name: "not found", err: &repository.Error{Kind: repository.ErrNotFound, Err: errors.New("record not found")}, code: CodeNotFound},
+		{name: "conflict", err: &repository.Error{Kind: repository.ErrConflict, Err: driverErr}, code: CodeConflict},
+		{name: "referenced", err: fmt.Errorf("delete: %w", &repository.Error{Kind: repository.ErrReferenced, Err: driverErr}), code: CodeReferenced},
+		{name: "validation", err: &repository.Error{Kind: repository.ErrValidation, Err: driverErr}, code: CodeValidation},
+		{name: "stale", err: repository.ErrStaleEntity, code: CodeStale},
+		{name: "unavailable", err: repository.ErrUnavailable, code: CodeUnavailable},
+		{name: "rpc error", err: mcp.InvalidParamsError("bad"), code: mcp.InvalidParams},
+		{name: "unclassified", err: driverErr, code: mcp.InternalError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rpcErr *mcp.Error
+			if !errors.As(toolError("test", tt.err), &rpcErr) {
+				t.Fatalf("Expected an *mcp.Error")
+			}
+			if rpcErr.Code != tt.code {
+				t.Errorf("Expected code %d, got %d", tt.code, rpcErr.Code)
+			}
+			if strings.Contains(rpcErr.Message, "customer_email_key") {
+				t.Errorf("Expected driver details to be hidden, got %q", rpcErr.Message)
+			}
+		})
+	}
+}
+
+func TestToolError_ValidationFields(t *testing.T) {
+	err := &repository.ValidationError{Fields: []repository.FieldError{{Field: "email", Rule: "email"}}}
+
+	var rpcErr *mcp.Error
+	if !errors.As(toolError("test", err), &rpcErr) {
+		t.Fatalf("Expected an *mcp.Error")
+	}
+	if rpcErr.Code != CodeValidation {
+		t.Errorf("Expected code %d, got %d", CodeValidation, rpcErr.Code)
+	}
+	data, ok := rpcErr.Data.(map[string]any)
+	if !ok {
+		t.Fatalf("Expected field errors in data, got %v", rpcErr.Data)
+	}
+	if fields := data["fields"].([]repository.FieldError); len(fields) != 1 || fields[0].Field != "email" {
+		t.Errorf("Unexpected field errors: %v", fields)
+	}
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"CortexMCP/db/repository"
+	"CortexMCP/pkg/mcp"
+	"context"
+)
+
+// includeProperty describes the include argument of a finder tool preloading the given associations
+func includeProperty(associations ...string) mcp.Property {
+	return mcp.Property{
+		Type:        "array",
+		Description: "Related records to load with each result",
+		Items:       &mcp.Property{Type: "string", Enum: associations},
+	}
+}
+
+// idSchema is the input schema of a finder tool taking a single ID argument and an include argument
+func idSchema(name, description string, associations ...string) mcp.Schema {
+	return mcp.Schema{
+		Properties: map[string]mcp.Property{
+			name:      {Type: "integer", Description: description},
+			"include": includeProperty(associations...),
+		},
+		Required: []string{name},
+	}
+}
+
+// include converts the include argument into a query option
+func include(args mcp.Arguments) ([]repository.QueryOption, error) {
+	associations, err := args.OptionalStrings("include")
+	if err != nil || len(associations) == 0 {
+		return nil, err
+	}
+	return []repository.QueryOption{repository.With(associations...)}, nil
+}
+
+// idHandler adapts a finder taking a single ID to a tool handler
+func idHandler[T any](name string, find func(ctx context.Context, id uint, opts ...repository.QueryOption) (T, error)) mcp.ToolHandler {
+	return func(ctx context.Context, args mcp.Arguments) (any, error) {
+		id, err := args.Uint(name)
+		if err != nil {
+			return nil, err
+		}
+		opts, err := include(args)
+		if err != nil {
+			return nil, err
+		}
+		return find(ctx, id, opts...)
+	}
+}
+
+// registerFinderTools registers the tools looking up records by ID or relationship
+func registerFinderTools(s *mcp.Server, repos *Repositories) {
+	s.AddTool(mcp.Tool{
+		Name:        "film_find_by_id",
+		Description: "Find a film by ID",
+		InputSchema: idSchema("film_id", "Film to find", "Category", "Actors"),
+		Annotations: readOnly,
+	}, idHandler("film_id", repos.Film.FindByID))
+
+	s.AddTool(mcp.Tool{
+		Name:        "film_find_by_category",
+		Description: "Find the films in a category",
+		InputSchema: idSchema("category_id", "Category of the films", "Category", "Actors"),
+		Annotations: readOnly,
+	}, idHandler("category_id", repos.Film.FindByCategory))
+
+	s.AddTool(mcp.Tool{
+		Name:        "film_find_by_actor",
+		Description: "Find the films an actor appears in",
+		InputSchema: idSchema("actor_id", "Actor appearing in the films", "Category", "Actors"),
+		Annotations: readOnly,
+	}, idHandler("actor_id", repos.Film.FindByActor))
+
+	s.AddTool(mcp.Tool{
+		Name:        "customer_find_by_id",
+		Description: "Find a customer by ID",
+		InputSchema: idSchema("customer_id", "Customer to find", "Store"),
+		Annotations: readOnly,
+	}, idHandler("customer_id", repos.Customer.FindByID))
+
+	s.AddTool(mcp.Tool{
+		Name:        "inventory_find_available_by_store",
+		Description: "Find the inventory items of a store that are not currently held, rented or lost",
+		InputSchema: idSchema("store_id", "Store holding the items", "Film", "Store"),
+		Annotations: readOnly,
+	}, idHandler("store_id", repos.Inventory.FindAvailableByStore))
+
+	s.AddTool(mcp.Tool{
+		Name:        "rental_find_by_customer",
+		Description: "Find the rentals of a customer",
+		InputSchema: idSchema("customer_id", "Customer who rented", rentalAssociations...),
+		Annotations: readOnly,
+	}, idHandler("customer_id", repos.Rental.FindByCustomer))
+
+	s.AddTool(mcp.Tool{
+		Name:        "rental_find_overdue",
+		Description: "Find rentals that are still out and were rented more than the given number of days ago",
+		InputSchema: mcp.Schema{
+			Properties: map[string]mcp.Property{
+				"days":    {Type: "integer", Description: "Days after which an open rental is overdue (default 7)"},
+				"include": includeProperty(rentalAssociations...),
+			},
+		},
+		Annotations: readOnly,
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		days, err := args.OptionalInt("days", defaultOverdueDays)
+		if err != nil {
+			return nil, err
+		}
+		opts, err := include(args)
+		if err != nil {
+			return nil, err
+		}
+		return repos.Rental.FindOverdue(ctx, days, opts...)
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "category_list",
+		Description: "List the film categories",
+		Annotations: readOnly,
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		return repos.Category.FindAll(ctx)
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "store_find_by_id",
+		Description: "Find a store by its ID",
+		InputSchema: mcp.Schema{
+			Properties: map[string]mcp.Property{"store_id": {Type: "integer", Description: "Store to find"}},
+			Required:   []string{"store_id"},
+		},
+		Annotations: readOnly,
+	}, idHandler("store_id", repos.Store.FindByID))
+
+	s.AddTool(mcp.Tool{
+		Name:        "payment_find_by_customer",
+		Description: "Find the payments of a customer",
+		InputSchema: idSchema("customer_id", "Customer who paid", "Customer", "Staff", "Rental"),
+		Annotations: readOnly,
+	}, idHandler("customer_id", repos.Payment.FindByCustomer))
+}
+
+// defaultOverdueDays is the rental period after which rental_find_overdue reports an open rental
+const defaultOverdueDays = 7
+
+var rentalAssociations = []string{"Inventory", "Inventory.Film", "Customer", "Staff", "Payments", "Transitions"}
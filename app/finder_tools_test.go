@@ -0,0 +1,50 @@
+package app
+
+import (
+	"CortexMCP/db/entity"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestServer_FilmFindByID_Include(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `film` WHERE `film`.`id` = ? AND `film`.`deleted_at` IS NULL ORDER BY `film`.`id` LIMIT ?")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "film_id", "title", "category_id"}).AddRow(1, 1, "Academy Dinosaur", 6))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `category` WHERE `category`.`category_id` = ? AND `category`.`deleted_at` IS NULL")).
+		WithArgs(6).
+		WillReturnRows(sqlmock.NewRows([]string{"category_id", "name"}).AddRow(6, "Documentary"))
+
+	var film entity.Film
+	text := toolText(t, callTool(t, s, "film_find_by_id", map[string]any{"film_id": 1, "include": []string{"category"}}))
+	if err := json.Unmarshal([]byte(text), &film); err != nil {
+		t.Fatalf("Failed to decode tool result: %v", err)
+	}
+	if film.Category.Name != "Documentary" {
+		t.Errorf("Expected category to be preloaded, got %+v", film.Category)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestServer_FilmFindByID_UnknownInclude(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+
+	resp := callTool(t, s, "film_find_by_id", map[string]any{"film_id": 1, "include": []string{"Inventory"}})
+	if resp.Error == nil || resp.Error.Code != CodeValidation {
+		t.Fatalf("Expected validation error, got %+v", resp.Error)
+	}
+
+	// The query is rejected before it reaches the database
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"CortexMCP/pkg/mcp"
+	"CortexMCP/pkg/principal"
+	"CortexMCP/pkg/ratelimit"
+	"context"
+)
+
+// limitMiddleware refuses calls beyond the caller's rate limits and caps the tool calls running at once.
+// Each call holds one slot for its whole duration.
+func limitMiddleware(limiter *ratelimit.Limiter) mcp.ToolMiddleware {
+	return func(name string, next mcp.ToolHandler) mcp.ToolHandler {
+		return func(ctx context.Context, args mcp.Arguments) (any, error) {
+			if err := limiter.Allow(caller(ctx), name); err != nil {
+				return nil, err
+			}
+			release, err := limiter.Acquire(ctx)
+			if err != nil {
+				return nil, err
+			}
+			defer release()
+			return next(ctx, args)
+		}
+	}
+}
+
+// caller identifies the caller for rate limiting: its principal, or anonymous when it has none
+func caller(ctx context.Context) string {
+	if p, ok := principal.FromContext(ctx); ok {
+		return p.String()
+	}
+	return "anonymous"
+}
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"CortexMCP/pkg/ratelimit"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestServer_RateLimit(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	cfg := testConfig(t)
+	cfg.Limits = ratelimit.Config{
+		Enabled: true,
+		Tools:   map[string]ratelimit.Rate{"rental_find_overdue": {PerSecond: 0.1, Burst: 1}},
+	}
+	s := NewServer(cfg, repos)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental`")).WillReturnRows(sqlmock.NewRows([]string{"rental_id"}))
+	if resp := callTool(t, s, "rental_find_overdue", map[string]any{}); resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+
+	resp := callTool(t, s, "rental_find_overdue", map[string]any{})
+	if resp.Error == nil || resp.Error.Code != CodeLimited {
+		t.Fatalf("Expected the second call to be rate limited, got %+v", resp.Error)
+	}
+	if data, ok := resp.Error.Data.(map[string]any); !ok || data["retryAfter"] != float64(10) {
+		t.Errorf("Expected a retry-after hint of 10 seconds, got %+v", resp.Error.Data)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
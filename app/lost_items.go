@@ -0,0 +1,168 @@
+package app
+
+import (
+	"CortexMCP/db/entity"
+	"CortexMCP/db/repository"
+	"CortexMCP/pkg/mcp"
+	"CortexMCP/pkg/principal"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// lostItemsURI is the resource listing the outcomes of the recent runs of the lost-item job
+const lostItemsURI = "dvd://jobs/lost-items"
+
+// lostItemRuns is the number of runs the lost-item job keeps for its resource
+const lostItemRuns = 20
+
+// LostItemsConfig configures the job declaring the copies of long-overdue rentals lost
+type LostItemsConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// Interval is how often the job runs. Defaults to 24h.
+	Interval time.Duration `yaml:"interval" mapstructure:"interval" validate:"min=0"`
+
+	// OverdueDays is how many days a rental stays out before its copy is declared lost
+	OverdueDays int `yaml:"overdueDays" mapstructure:"overdueDays" validate:"required_if=Enabled true,min=0"`
+
+	// ReplacementFee is charged to the customer for a lost copy, e.g. 19.99 or "19.99 USD". It must be in
+	// entity.DefaultCurrency and, when the job is enabled, positive.
+	ReplacementFee entity.Money `yaml:"replacementFee" mapstructure:"replacementFee"`
+}
+
+// validate checks the replacement fee, which the struct tags cannot express
+func (c *LostItemsConfig) validate() error {
+	if c.ReplacementFee.Currency() != entity.DefaultCurrency {
+		return fmt.Errorf("replacementFee must be in %s, got %s", entity.DefaultCurrency, c.ReplacementFee.Currency())
+	}
+	if c.Enabled && c.ReplacementFee.Minor() <= 0 {
+		return fmt.Errorf("replacementFee must be positive, got %s", c.ReplacementFee)
+	}
+	return nil
+}
+
+// LostItemOutcome is what the lost-item job did with one overdue rental
+type LostItemOutcome struct {
+	RentalID    uint         `json:"rentalId"`
+	InventoryID uint         `json:"inventoryId"`
+	CustomerID  uint         `json:"customerId"`
+	PaymentID   uint         `json:"paymentId,omitempty"`
+	Fee         entity.Money `json:"fee"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// LostItemRun is a run of the lost-item job
+type LostItemRun struct {
+	StartedAt  time.Time         `json:"startedAt"`
+	FinishedAt time.Time         `json:"finishedAt"`
+	Outcomes   []LostItemOutcome `json:"outcomes"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// LostItems is the scheduled job declaring the copies of rentals out for longer than the configured number of
+// days lost, taking them out of circulation and charging their customers the replacement fee
+type LostItems struct {
+	rentals repository.RentalRepository
+	cfg     LostItemsConfig
+
+	mu   sync.Mutex
+	runs []LostItemRun
+}
+
+// NewLostItems creates the lost-item job working on rentals
+func NewLostItems(rentals repository.RentalRepository, cfg LostItemsConfig) *LostItems {
+	return &LostItems{rentals: rentals, cfg: cfg}
+}
+
+// Run runs the job right away and then every configured interval until ctx is cancelled
+func (j *LostItems) Run(ctx context.Context) {
+	interval := j.cfg.Interval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if run := j.RunOnce(ctx); run.Error != "" {
+			log.Printf("lost-item job failed: %s", run.Error)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce declares the copies of the rentals overdue right now lost and records the outcome. A rental that
+// cannot be declared lost, e.g. because it was returned meanwhile, fails on its own without affecting the others.
+func (j *LostItems) RunOnce(ctx context.Context) LostItemRun {
+	ctx = principal.NewContext(ctx, principal.Job("lost-items"))
+	run := LostItemRun{StartedAt: time.Now()}
+	overdue, err := j.rentals.FindOverdue(ctx, j.cfg.OverdueDays)
+	if err != nil {
+		run.Error = err.Error()
+	}
+	note := fmt.Sprintf("out for more than %d days", j.cfg.OverdueDays)
+	for _, rental := range overdue {
+		outcome := LostItemOutcome{
+			RentalID:    rental.RentalID,
+			InventoryID: rental.InventoryID,
+			CustomerID:  rental.CustomerID,
+			Fee:         j.cfg.ReplacementFee,
+		}
+		lost, err := j.rentals.MarkLost(ctx, rental.RentalID, j.cfg.ReplacementFee, note)
+		if err != nil {
+			outcome.Error = err.Error()
+		} else {
+			for _, payment := range lost.Payments {
+				if payment.Kind == entity.PaymentReplacement {
+					outcome.PaymentID = payment.PaymentID
+				}
+			}
+		}
+		run.Outcomes = append(run.Outcomes, outcome)
+	}
+	run.FinishedAt = time.Now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.runs = append([]LostItemRun{run}, j.runs...)
+	if len(j.runs) > lostItemRuns {
+		j.runs = j.runs[:lostItemRuns]
+	}
+	return run
+}
+
+// Runs returns the recent runs of the job, most recent first
+func (j *LostItems) Runs() []LostItemRun {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]LostItemRun(nil), j.runs...)
+}
+
+// WithLostItems publishes the outcomes of the recent runs of job as the dvd://jobs/lost-items resource, to
+// callers whose grant covers all stores
+func WithLostItems(job *LostItems) Option {
+	return func(s *mcp.Server) {
+		s.AddResource(mcp.Resource{
+			URI:  lostItemsURI,
+			Name: "lost-items",
+			Description: "Recent runs of the job declaring the copies of long-overdue rentals lost, most recent first, " +
+				"with the rentals marked lost, the replacement fees charged and the rentals that failed. Only readable " +
+				"with a grant for all stores.",
+			MimeType: "application/json",
+		}, func(ctx context.Context) (string, error) {
+			// The job runs for every store
+			if err := requireAllStores(ctx, lostItemsURI); err != nil {
+				return "", err
+			}
+			data, err := json.Marshal(job.Runs())
+			return string(data), err
+		})
+	}
+}
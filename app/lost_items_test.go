@@ -0,0 +1,119 @@
+package app
+
+import (
+	"CortexMCP/db/entity"
+	"CortexMCP/pkg/principal"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestLostItems_RunOnce(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	job := NewLostItems(repos.Rental, LostItemsConfig{Enabled: true, OverdueDays: 90, ReplacementFee: entity.Cents(2000)})
+	s := NewServer(testConfig(t), repos, WithLostItems(job))
+
+	rentalDate := time.Now().AddDate(0, 0, -120)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE (status = ? AND rental_date < ?)")).
+		WithArgs("out", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"rental_id", "inventory_id", "customer_id", "status"}).
+			AddRow(1, 7, 3, "out").
+			AddRow(2, 8, 4, "out"))
+
+	// Rental 1 is declared lost and charged
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE rental_id = ?")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "rental_id", "rental_date", "inventory_id", "customer_id", "staff_id", "status"}).
+			AddRow(1, 1, 1, rentalDate, 7, 3, 1, "out"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `rental` SET ")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `rental_transition`")).
+		WithArgs(1, "out", "lost", "job:lost-items", "out for more than 90 days", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `inventory` SET `deleted_at`=?")).
+		WithArgs(sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `payment`")).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectCommit()
+
+	// Rental 2 was returned meanwhile
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE rental_id = ?")).
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "rental_id", "rental_date", "inventory_id", "customer_id", "return_date", "staff_id", "status"}).
+			AddRow(2, 2, 2, rentalDate, 8, 4, time.Now(), 1, "returned"))
+	mock.ExpectRollback()
+
+	run := job.RunOnce(context.Background())
+	if run.Error != "" || len(run.Outcomes) != 2 {
+		t.Fatalf("Expected outcomes for both rentals, got %+v", run)
+	}
+	if outcome := run.Outcomes[0]; outcome.Error != "" || outcome.InventoryID != 7 || outcome.Fee != entity.Cents(2000) {
+		t.Errorf("Expected rental 1 to be declared lost, got %+v", outcome)
+	}
+	if outcome := run.Outcomes[1]; outcome.Error == "" {
+		t.Errorf("Expected the returned rental 2 to fail, got %+v", outcome)
+	}
+
+	var runs []LostItemRun
+	if err := json.Unmarshal([]byte(readResource(t, s, lostItemsURI)), &runs); err != nil {
+		t.Fatalf("Failed to decode resource: %v", err)
+	}
+	if len(runs) != 1 || len(runs[0].Outcomes) != 2 {
+		t.Errorf("Expected the run in the resource, got %+v", runs)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestLostItems_ResourceNeedsAllStores(t *testing.T) {
+	repos, _ := newTestRepositories(t)
+	job := NewLostItems(repos.Rental, LostItemsConfig{Enabled: true, OverdueDays: 90, ReplacementFee: entity.Cents(2000)})
+	s := NewServer(policyConfig(t), repos, WithLostItems(job))
+
+	if resp := readResourceAs(t, s, principal.Staff(2), lostItemsURI); resp.Error == nil || resp.Error.Code != CodeForbidden {
+		t.Errorf("Expected a manager of one store to be denied the job runs, got %+v", resp.Error)
+	}
+	if resp := readResourceAs(t, s, principal.Staff(3), lostItemsURI); resp.Error != nil {
+		t.Errorf("Expected an admin to read the job runs, got %v", resp.Error)
+	}
+}
+
+func TestLoadConfig_ReplacementFee(t *testing.T) {
+	tests := []struct {
+		fee   string
+		valid bool
+	}{
+		{"19.99", true},
+		{"\"19.99 USD\"", true},
+		{"\"19.99 EUR\"", false},
+		{"0", false},
+		{"-5", false},
+	}
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		config := bytes.Replace(defaultConfig, []byte("replacementFee: 19.99"), []byte("replacementFee: "+test.fee), 1)
+		config = bytes.Replace(config, []byte("lostItems:\n  enabled: false"), []byte("lostItems:\n  enabled: true"), 1)
+		if err := os.WriteFile(path, config, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadConfig(path)
+		if test.valid && err != nil {
+			t.Errorf("Expected fee %s to be valid, got %v", test.fee, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("Expected fee %s to be invalid", test.fee)
+		}
+	}
+}
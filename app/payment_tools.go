@@ -0,0 +1,144 @@
+package app
+
+import (
+	"CortexMCP/db/entity"
+	"CortexMCP/pkg/mcp"
+	"context"
+	"strconv"
+	"time"
+)
+
+// paymentKinds are the values of the kind argument of payment_record; refunds go through payment_refund
+var paymentKinds = []string{string(entity.PaymentCharge), string(entity.PaymentLateFee), string(entity.PaymentReplacement)}
+
+// paymentLineSchema is the input schema of a tool adding a payment line to a rental
+func paymentLineSchema(properties map[string]mcp.Property) mcp.Schema {
+	properties["rental_id"] = mcp.Property{Type: "integer", Description: "Rental the payment is for"}
+	properties["staff_id"] = mcp.Property{Type: "integer", Description: "Staff member taking the payment"}
+	properties["amount"] = mcp.Property{Type: "number", Description: "Amount of the payment line"}
+	required := []string{"rental_id", "staff_id", "amount"}
+	if _, ok := properties["kind"]; ok {
+		required = append(required, "kind")
+	}
+	return mcp.Schema{Properties: properties, Required: required}
+}
+
+// paymentLine reads the arguments of a tool adding a payment line to a rental into a payment of its customer
+func paymentLine(ctx context.Context, repos *Repositories, args mcp.Arguments) (*entity.Payment, error) {
+	rentalID, err := args.Uint("rental_id")
+	if err != nil {
+		return nil, err
+	}
+	staffID, err := args.Uint("staff_id")
+	if err != nil {
+		return nil, err
+	}
+	number, err := args.Number("amount")
+	if err != nil {
+		return nil, err
+	}
+	// Parsing the shortest decimal form of the number keeps e.g. 4.99 exact
+	amount, err := entity.ParseMoney(strconv.FormatFloat(number, 'f', -1, 64))
+	if err != nil {
+		return nil, mcp.InvalidParamsError("argument %q must be an amount of money", "amount")
+	}
+	rental, err := repos.Rental.FindByID(ctx, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.Payment{
+		CustomerID:  rental.CustomerID,
+		StaffID:     staffID,
+		RentalID:    rentalID,
+		Amount:      amount,
+		PaymentDate: time.Now(),
+	}, nil
+}
+
+// registerPaymentTools registers the tools recording the payment lines of rentals and reporting their balances
+func registerPaymentTools(s *mcp.Server, repos *Repositories) {
+	s.AddTool(mcp.Tool{
+		Name:        "payment_record",
+		Description: "Record a charge, late fee or replacement fee paid by the customer of a rental and return it",
+		InputSchema: paymentLineSchema(map[string]mcp.Property{
+			"kind": {Type: "string", Description: "What the payment is for", Enum: paymentKinds},
+		}),
+		Annotations: &mcp.ToolAnnotations{},
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		kind, err := args.String("kind")
+		if err != nil {
+			return nil, err
+		}
+		if kind == string(entity.PaymentRefund) {
+			return nil, mcp.InvalidParamsError("refunds are recorded with payment_refund")
+		}
+		payment, err := paymentLine(ctx, repos, args)
+		if err != nil {
+			return nil, err
+		}
+		payment.Kind = entity.PaymentKind(kind)
+		if err := repos.Payment.Create(ctx, payment); err != nil {
+			return nil, err
+		}
+		return payment, nil
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "payment_refund",
+		Description: "Refund the customer of a rental and return the refund. The refund may not exceed the net amount paid for the rental.",
+		InputSchema: paymentLineSchema(map[string]mcp.Property{}),
+		Annotations: &mcp.ToolAnnotations{},
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		refund, err := paymentLine(ctx, repos, args)
+		if err != nil {
+			return nil, err
+		}
+		if err := repos.Payment.Refund(ctx, refund); err != nil {
+			return nil, err
+		}
+		return refund, nil
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "payment_find_by_rental",
+		Description: "Find the payment lines of a rental, including refunds, in the order they were made",
+		InputSchema: idSchema("rental_id", "Rental paid for", "Customer", "Staff", "Rental"),
+		Annotations: readOnly,
+	}, idHandler("rental_id", repos.Payment.FindByRental))
+
+	s.AddTool(mcp.Tool{
+		Name:        "payment_rental_balance",
+		Description: "Get what was paid for a rental: the charges, late fees and replacements, the refunds and the net amount",
+		InputSchema: mcp.Schema{
+			Properties: map[string]mcp.Property{
+				"rental_id": {Type: "integer", Description: "Rental paid for"},
+			},
+			Required: []string{"rental_id"},
+		},
+		Annotations: readOnly,
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		rentalID, err := args.Uint("rental_id")
+		if err != nil {
+			return nil, err
+		}
+		return repos.Payment.GetRentalBalance(ctx, rentalID)
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "payment_balances_by_customer",
+		Description: "Get what a customer paid for each of their rentals with payments, net of refunds",
+		InputSchema: mcp.Schema{
+			Properties: map[string]mcp.Property{
+				"customer_id": {Type: "integer", Description: "Customer who paid"},
+			},
+			Required: []string{"customer_id"},
+		},
+		Annotations: readOnly,
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		customerID, err := args.Uint("customer_id")
+		if err != nil {
+			return nil, err
+		}
+		return repos.Payment.GetRentalBalancesByCustomer(ctx, customerID)
+	})
+}
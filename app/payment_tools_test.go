@@ -0,0 +1,33 @@
+package app
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestServer_PaymentRefundRejectsOvercharge(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rental_id", "inventory_id", "customer_id", "staff_id", "status"}).
+			AddRow(1, 1, 7, 3, 2, "returned"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `rental_id` FROM `rental` WHERE rental_id = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"rental_id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT payment.rental_id")).
+		WithArgs("refund", "refund", "refund", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"rental_id", "paid", "refunded", "net"}).AddRow(1, 4.99, 0, 4.99))
+	mock.ExpectRollback()
+
+	resp := callTool(t, s, "payment_refund", map[string]any{"rental_id": 1, "staff_id": 2, "amount": 5})
+	if resp.Error == nil || resp.Error.Code != CodeValidation {
+		t.Errorf("Expected refunding more than was paid to fail validation, got %+v", resp.Error)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
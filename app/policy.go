@@ -0,0 +1,74 @@
+package app
+
+import (
+	"CortexMCP/pkg/mcp"
+	"CortexMCP/pkg/policy"
+	"CortexMCP/pkg/principal"
+	"context"
+	"fmt"
+)
+
+// policyMiddleware denies tools the calling principal's role may not call, judged by the tool's annotations,
+// and scopes the queries of the tools it allows to the principal's stores. Tools without annotations are
+// treated as destructive.
+func policyMiddleware(s *mcp.Server, cfg policy.Config) mcp.ToolMiddleware {
+	grantOf := granter(cfg)
+	return func(name string, next mcp.ToolHandler) mcp.ToolHandler {
+		return func(ctx context.Context, args mcp.Arguments) (any, error) {
+			grant, err := grantOf(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			readOnly, destructive := false, true
+			if tool, ok := s.Tool(name); ok && tool.Annotations != nil {
+				readOnly, destructive = tool.Annotations.ReadOnlyHint, tool.Annotations.DestructiveHint
+			}
+			if !grant.AllowsTool(readOnly, destructive) {
+				return nil, fmt.Errorf("%w: role %s may not call %s", policy.ErrForbidden, grant.Role, name)
+			}
+			return next(policy.NewContext(ctx, grant), args)
+		}
+	}
+}
+
+// policyResourceMiddleware scopes the queries of the resources read by a principal to the principal's stores,
+// like policyMiddleware does for read-only tools
+func policyResourceMiddleware(cfg policy.Config) mcp.ResourceMiddleware {
+	grantOf := granter(cfg)
+	return func(resource mcp.Resource, next mcp.ResourceHandler) mcp.ResourceHandler {
+		return func(ctx context.Context) (string, error) {
+			grant, err := grantOf(ctx)
+			if err != nil {
+				return "", err
+			}
+			return next(policy.NewContext(ctx, grant))
+		}
+	}
+}
+
+// granter returns a function returning the grant of the principal in a context. It fails with
+// policy.ErrForbidden for unauthenticated requests.
+func granter(cfg policy.Config) func(ctx context.Context) (policy.Grant, error) {
+	p, policyErr := policy.New(cfg)
+	return func(ctx context.Context) (policy.Grant, error) {
+		if policyErr != nil {
+			// LoadConfig rejects invalid policies; fail closed if one gets here anyway
+			return policy.Grant{}, fmt.Errorf("%w: %v", policy.ErrForbidden, policyErr)
+		}
+		caller, ok := principal.FromContext(ctx)
+		if !ok {
+			return policy.Grant{}, fmt.Errorf("%w: unauthenticated", policy.ErrForbidden)
+		}
+		return p.Grant(caller)
+	}
+}
+
+// requireAllStores rejects callers whose grant is restricted to some stores from reading data that cannot be
+// scoped to stores, like the audit log is by the policy callbacks
+func requireAllStores(ctx context.Context, what string) error {
+	if grant, ok := policy.FromContext(ctx); ok && !grant.AllStores {
+		return fmt.Errorf("%w: %s needs a grant for all stores", policy.ErrForbidden, what)
+	}
+	return nil
+}
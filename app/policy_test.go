@@ -0,0 +1,133 @@
+package app
+
+import (
+	"CortexMCP/pkg/mcp"
+	"CortexMCP/pkg/policy"
+	"CortexMCP/pkg/principal"
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// callToolAs calls a tool with p as the authenticated principal
+func callToolAs(t *testing.T, s *mcp.Server, p principal.Principal, name string, args map[string]any) mcp.Response {
+	t.Helper()
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]any{"name": name, "arguments": args},
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode request: %v", err)
+	}
+
+	var resp mcp.Response
+	if err := json.Unmarshal(s.Handle(principal.NewContext(context.Background(), p), message), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func policyConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg := testConfig(t)
+	cfg.Policy = policy.Config{
+		Enabled: true,
+		Principals: []policy.Assignment{
+			{Principal: "staff:1", Role: policy.RoleClerk, Stores: []uint{1}},
+			{Principal: "staff:2", Role: policy.RoleManager, Stores: []uint{2}},
+			{Principal: "staff:3", Role: policy.RoleAdmin},
+		},
+	}
+	return cfg
+}
+
+func TestServer_EveryToolIsAnnotated(t *testing.T) {
+	repos, _ := newTestRepositories(t)
+	for _, tool := range NewServer(testConfig(t), repos).Tools() {
+		if tool.Annotations == nil {
+			t.Errorf("Tool %s has no annotations; the policy treats it as destructive", tool.Name)
+		}
+	}
+}
+
+func TestServer_Policy(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(policyConfig(t), repos)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `film`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "film_id", "title"}).AddRow(1, 1, "Academy Dinosaur"))
+	if resp := callToolAs(t, s, principal.Staff(1), "film_find_by_id", map[string]any{"film_id": 1}); resp.Error != nil {
+		t.Errorf("Expected a clerk to call read-only tools, got %v", resp.Error)
+	}
+
+	denied := []struct {
+		principal principal.Principal
+		tool      string
+	}{
+		{principal.Staff(1), "film_add_actors"},
+		{principal.Staff(2), "film_replace_cast"},
+		{principal.Staff(2), "table_import"},
+		{principal.Staff(9), "film_find_by_id"},
+	}
+	for _, d := range denied {
+		resp := callToolAs(t, s, d.principal, d.tool, map[string]any{"film_id": 1, "actor_ids": []uint{5}})
+		if resp.Error == nil || resp.Error.Code != CodeForbidden {
+			t.Errorf("Expected %s to be denied %s, got %+v", d.principal, d.tool, resp.Error)
+		}
+	}
+
+	// Without authentication or a client name there is no principal to authorize
+	if resp := callTool(t, s, "film_find_by_id", map[string]any{"film_id": 1}); resp.Error == nil || resp.Error.Code != CodeForbidden {
+		t.Errorf("Expected an anonymous call to be denied, got %+v", resp.Error)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+// readResourceAs reads a resource with p as the authenticated principal
+func readResourceAs(t *testing.T, s *mcp.Server, p principal.Principal, uri string) mcp.Response {
+	t.Helper()
+	message, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "resources/read", "params": map[string]any{"uri": uri}})
+
+	var resp mcp.Response
+	if err := json.Unmarshal(s.Handle(principal.NewContext(context.Background(), p), message), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func TestServer_PolicyScopesResources(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(policyConfig(t), repos)
+
+	// A clerk of store 1 only sees the rentals of copies at store 1
+	mock.ExpectQuery(regexp.QuoteMeta("AND `rental`.`inventory_id` IN (SELECT inventory_id FROM `inventory` WHERE `store_id` = ?)")).
+		WithArgs(5, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rental_id"}))
+	if resp := readResourceAs(t, s, principal.Staff(1), "dvd://rentals/5"); resp.Error == nil || resp.Error.Code != CodeNotFound {
+		t.Errorf("Expected a rental of another store to be hidden, got %+v", resp.Error)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("AND `inventory`.`store_id` = ?")).
+		WithArgs("reserved", "out", "lost", 7, "active", sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"inventory_id", "film_id", "store_id"}))
+	resp := readResourceAs(t, s, principal.Staff(1), "dvd://stores/7/availability")
+	if resp.Error != nil || resp.Result.(map[string]any)["contents"].([]any)[0].(map[string]any)["text"] != "[]" {
+		t.Errorf("Expected no copies of another store, got %+v", resp)
+	}
+
+	if resp := readResourceAs(t, s, principal.Staff(9), "dvd://inventory/10"); resp.Error == nil || resp.Error.Code != CodeForbidden {
+		t.Errorf("Expected a principal without a role to be denied, got %+v", resp.Error)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"CortexMCP/pkg/mcp"
+	"CortexMCP/pkg/principal"
+	"context"
+)
+
+// principalMiddleware attributes the changes made by a tool to the MCP client calling it, unless a
+// principal has already been established for the request
+func principalMiddleware(name string, next mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, args mcp.Arguments) (any, error) {
+		return next(withClientPrincipal(ctx), args)
+	}
+}
+
+// principalResourceMiddleware establishes the principal reading a resource like principalMiddleware
+func principalResourceMiddleware(resource mcp.Resource, next mcp.ResourceHandler) mcp.ResourceHandler {
+	return func(ctx context.Context) (string, error) {
+		return next(withClientPrincipal(ctx))
+	}
+}
+
+// withClientPrincipal returns ctx with the MCP client as its principal when it has none
+func withClientPrincipal(ctx context.Context) context.Context {
+	if _, ok := principal.FromContext(ctx); !ok {
+		if client := mcp.ClientFromContext(ctx); client.Name != "" {
+			ctx = principal.NewContext(ctx, principal.Client(client.Name))
+		}
+	}
+	return ctx
+}
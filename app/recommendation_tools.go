@@ -0,0 +1,34 @@
+package app
+
+import (
+	"CortexMCP/db/repository"
+	"CortexMCP/pkg/mcp"
+	"context"
+)
+
+// registerRecommendationTools registers the film recommendation tools
+func registerRecommendationTools(s *mcp.Server, repo repository.RecommendationRepository) {
+	s.AddTool(mcp.Tool{
+		Name: "film_recommend",
+		Description: "Recommend films a customer has not rented yet, based on what customers with similar rentals watched, " +
+			"shared actors and shared categories. Only films with a copy available at the customer's store are returned.",
+		InputSchema: mcp.Schema{
+			Properties: map[string]mcp.Property{
+				"customer_id": {Type: "integer", Description: "Customer to recommend films for"},
+				"limit":       {Type: "integer", Description: "Maximum number of films (default 10)"},
+			},
+			Required: []string{"customer_id"},
+		},
+		Annotations: readOnly,
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		customerID, err := args.Uint("customer_id")
+		if err != nil {
+			return nil, err
+		}
+		limit, err := args.OptionalInt("limit", 0)
+		if err != nil {
+			return nil, err
+		}
+		return repo.RecommendForCustomer(ctx, customerID, limit)
+	})
+}
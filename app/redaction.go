@@ -0,0 +1,57 @@
+package app
+
+import (
+	"CortexMCP/db/transfer"
+	"CortexMCP/pkg/mcp"
+	"CortexMCP/pkg/policy"
+	"CortexMCP/pkg/redact"
+	"context"
+)
+
+// redactMiddleware redacts the personal data in every tool result with the rules of the caller's policy role.
+// It must run inside policyMiddleware to see the role; without a policy the rules for everyone apply.
+func redactMiddleware(redactor *redact.Redactor) mcp.ToolMiddleware {
+	return func(name string, next mcp.ToolHandler) mcp.ToolHandler {
+		return func(ctx context.Context, args mcp.Arguments) (any, error) {
+			result, err := next(ctx, args)
+			if err != nil {
+				return nil, err
+			}
+			return rules(ctx, redactor).Value(result)
+		}
+	}
+}
+
+// resourceRedactions redact resource text by MIME type: JSON results and import reports, and exports in each format
+var resourceRedactions = map[string]func(rules redact.Rules, text string) (string, error){
+	"application/json": func(rules redact.Rules, text string) (string, error) {
+		data, err := rules.JSON([]byte(text))
+		return string(data), err
+	},
+	transfer.JSONL.MimeType(): redact.Rules.JSONLines,
+	transfer.CSV.MimeType():   redact.Rules.CSV,
+}
+
+// redactResourceMiddleware redacts the personal data in every resource like redactMiddleware, with the rules of
+// the reader's policy role. Resources of other MIME types are served as they are.
+func redactResourceMiddleware(redactor *redact.Redactor) mcp.ResourceMiddleware {
+	return func(resource mcp.Resource, next mcp.ResourceHandler) mcp.ResourceHandler {
+		redaction, ok := resourceRedactions[resource.MimeType]
+		if !ok {
+			return next
+		}
+		return func(ctx context.Context) (string, error) {
+			text, err := next(ctx)
+			if err != nil {
+				return "", err
+			}
+			return redaction(rules(ctx, redactor), text)
+		}
+	}
+}
+
+// rules returns the redaction rules for the caller's policy role
+func rules(ctx context.Context, redactor *redact.Redactor) redact.Rules {
+	grant, _ := policy.FromContext(ctx)
+	return redactor.Rules(grant.Role)
+}
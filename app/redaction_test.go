@@ -0,0 +1,58 @@
+package app
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// rawPII is the personal data of the customer returned by the mocked queries
+var rawPII = []string{"mary.smith@dvdrental.com", "28303384290", "1913 Hanoi Way", "35200"}
+
+func customerRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "customer_id", "store_id", "first_name", "last_name", "email", "address", "postal_code", "phone"}).
+		AddRow(1, 1, 1, "Mary", "Smith", "mary.smith@dvdrental.com", "1913 Hanoi Way", "35200", "28303384290")
+}
+
+func assertNoPII(t *testing.T, source, text string) {
+	t.Helper()
+	for _, value := range rawPII {
+		if strings.Contains(text, value) {
+			t.Errorf("%s leaks %q: %s", source, value, text)
+		}
+	}
+}
+
+func TestServer_NoRawPIIByDefault(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer`")).WillReturnRows(customerRows())
+	text := toolText(t, callTool(t, s, "customer_find_by_id", map[string]any{"customer_id": 1}))
+	assertNoPII(t, "customer_find_by_id", text)
+	if !strings.Contains(text, `"Email":"m***@dvdrental.com"`) || !strings.Contains(text, `"Phone":"***4290"`) {
+		t.Errorf("Expected masked email and phone, got %s", text)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `audit_log`")).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_log_id", "table_name", "record_id", "action", "after_data", "principal", "changed_at"}).
+			AddRow(1, "customer", "1", "create", `{"email":"mary.smith@dvdrental.com","phone":"28303384290","address":"1913 Hanoi Way"}`, "staff:1", time.Now()))
+	assertNoPII(t, "audit_history", toolText(t, callTool(t, s, "audit_history", map[string]any{"table": "customer", "record_id": "1"})))
+
+	for _, format := range []string{"csv", "jsonl"} {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer`")).WillReturnRows(customerRows())
+		var export exportResult
+		if err := json.Unmarshal([]byte(toolText(t, callTool(t, s, "table_export", map[string]any{"table": "customer", "format": format}))), &export); err != nil {
+			t.Fatalf("Failed to decode export result: %v", err)
+		}
+		assertNoPII(t, "exported "+format, readResource(t, s, export.URI))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"CortexMCP/db/entity"
+	"CortexMCP/pkg/mcp"
+	"context"
+)
+
+// rentalStatuses are the values of the status argument of rental_set_status
+var rentalStatuses = []string{
+	string(entity.RentalReserved), string(entity.RentalOut), string(entity.RentalReturned),
+	string(entity.RentalLost), string(entity.RentalDamaged),
+}
+
+// registerRentalTools registers the tools moving rentals through their states
+func registerRentalTools(s *mcp.Server, repos *Repositories) {
+	s.AddTool(mcp.Tool{
+		Name: "rental_set_status",
+		Description: "Move a rental to another state and return it. A reserved rental may be checked out; a rental " +
+			"that is out may be returned, lost or damaged; a lost copy may still be returned or damaged, and a " +
+			"returned copy found damaged. Returning a copy sets the return date and reserves it for the customer " +
+			"who has waited longest for its film at its store, if any.",
+		InputSchema: mcp.Schema{
+			Properties: map[string]mcp.Property{
+				"rental_id": {Type: "integer", Description: "Rental to move"},
+				"version":   {Type: "integer", Description: "Version of the rental as last read; the change fails if the rental has changed since"},
+				"status":    {Type: "string", Description: "State to move the rental to", Enum: rentalStatuses},
+				"note":      {Type: "string", Description: "Reason for the change, kept with the rental's history"},
+			},
+			Required: []string{"rental_id", "version", "status"},
+		},
+		Annotations: &mcp.ToolAnnotations{},
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		rentalID, err := args.Uint("rental_id")
+		if err != nil {
+			return nil, err
+		}
+		version, err := args.Uint("version")
+		if err != nil {
+			return nil, err
+		}
+		status, err := args.String("status")
+		if err != nil {
+			return nil, err
+		}
+		note, err := args.OptionalString("note", "")
+		if err != nil {
+			return nil, err
+		}
+		return repos.Rental.Transition(ctx, rentalID, version, entity.RentalStatus(status), note)
+	})
+}
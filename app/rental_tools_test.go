@@ -0,0 +1,30 @@
+package app
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestServer_RentalSetStatusRejectsTransition(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE rental_id = ?")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "rental_id", "rental_date", "status"}).
+			AddRow(1, 1, 1, time.Now(), "reserved"))
+	mock.ExpectRollback()
+
+	resp := callTool(t, s, "rental_set_status", map[string]any{"rental_id": 1, "version": 1, "status": "lost"})
+	if resp.Error == nil || resp.Error.Code != CodeValidation {
+		t.Errorf("Expected a reserved rental to be rejected as lost, got %+v", resp.Error)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"CortexMCP/db/entity"
+	"CortexMCP/pkg/mcp"
+	"context"
+	"time"
+)
+
+// defaultHoldHours is how long a reservation made with reservation_create holds its copy by default
+const defaultHoldHours = 48
+
+// reservationAssociations are the associations the reservation tools may include
+var reservationAssociations = []string{"Inventory", "Inventory.Film", "Customer", "Rental"}
+
+// reservationIDSchema is the input schema of a tool acting on one reservation
+func reservationIDSchema(properties map[string]mcp.Property, required ...string) mcp.Schema {
+	properties["reservation_id"] = mcp.Property{Type: "integer", Description: "Reservation to act on"}
+	return mcp.Schema{Properties: properties, Required: append([]string{"reservation_id"}, required...)}
+}
+
+// registerReservationTools registers the tools placing, releasing and checking out holds on copies
+func registerReservationTools(s *mcp.Server, repos *Repositories) {
+	s.AddTool(mcp.Tool{
+		Name: "reservation_create",
+		Description: "Hold a copy for a customer and return the reservation. The copy must be available; it stays " +
+			"out of the available inventory until the reservation is cancelled, expires or is checked out.",
+		InputSchema: mcp.Schema{
+			Properties: map[string]mcp.Property{
+				"inventory_id": {Type: "integer", Description: "Copy to hold"},
+				"customer_id":  {Type: "integer", Description: "Customer the copy is held for"},
+				"hours":        {Type: "integer", Description: "Hours the copy is held for (default 48)"},
+			},
+			Required: []string{"inventory_id", "customer_id"},
+		},
+		Annotations: &mcp.ToolAnnotations{},
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		inventoryID, err := args.Uint("inventory_id")
+		if err != nil {
+			return nil, err
+		}
+		customerID, err := args.Uint("customer_id")
+		if err != nil {
+			return nil, err
+		}
+		hours, err := args.OptionalInt("hours", defaultHoldHours)
+		if err != nil {
+			return nil, err
+		}
+		reservation := &entity.Reservation{
+			InventoryID: inventoryID,
+			CustomerID:  customerID,
+			ExpiresAt:   time.Now().Add(time.Duration(hours) * time.Hour),
+		}
+		if err := repos.Reservation.Reserve(ctx, reservation); err != nil {
+			return nil, err
+		}
+		return reservation, nil
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "reservation_cancel",
+		Description: "Cancel an active reservation, releasing its copy, and return it",
+		InputSchema: reservationIDSchema(map[string]mcp.Property{}),
+		Annotations: &mcp.ToolAnnotations{},
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		reservationID, err := args.Uint("reservation_id")
+		if err != nil {
+			return nil, err
+		}
+		return repos.Reservation.Cancel(ctx, reservationID)
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "reservation_checkout",
+		Description: "Rent the copy held by an active reservation to its customer and return the rental",
+		InputSchema: reservationIDSchema(map[string]mcp.Property{
+			"staff_id": {Type: "integer", Description: "Staff member handing out the copy"},
+		}, "staff_id"),
+		Annotations: &mcp.ToolAnnotations{},
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		reservationID, err := args.Uint("reservation_id")
+		if err != nil {
+			return nil, err
+		}
+		staffID, err := args.Uint("staff_id")
+		if err != nil {
+			return nil, err
+		}
+		return repos.Reservation.Checkout(ctx, reservationID, staffID)
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "reservation_expire",
+		Description: "Mark the active reservations whose hold has run out expired and return how many there were",
+		InputSchema: mcp.Schema{Properties: map[string]mcp.Property{}},
+		Annotations: &mcp.ToolAnnotations{IdempotentHint: true},
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		expired, err := repos.Reservation.Expire(ctx, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]int64{"expired": expired}, nil
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "reservation_find_by_customer",
+		Description: "Find the reservations of a customer",
+		InputSchema: idSchema("customer_id", "Customer who reserved", reservationAssociations...),
+		Annotations: readOnly,
+	}, idHandler("customer_id", repos.Reservation.FindByCustomer))
+}
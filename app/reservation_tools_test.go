@@ -0,0 +1,30 @@
+package app
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestServer_ReservationCheckoutRejectsExpiredHold(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `reservation` WHERE reservation_id = ?")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"reservation_id", "inventory_id", "customer_id", "status", "expires_at"}).
+			AddRow(1, 7, 3, "active", time.Now().Add(-time.Hour)))
+	mock.ExpectRollback()
+
+	resp := callTool(t, s, "reservation_checkout", map[string]any{"reservation_id": 1, "staff_id": 2})
+	if resp.Error == nil || resp.Error.Code != CodeUnavailable {
+		t.Errorf("Expected an expired hold to be unavailable, got %+v", resp.Error)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
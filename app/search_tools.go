@@ -0,0 +1,54 @@
+package app
+
+import (
+	"CortexMCP/db/repository"
+	"CortexMCP/pkg/mcp"
+	"context"
+)
+
+var searchSchema = mcp.Schema{
+	Properties: map[string]mcp.Property{
+		"query": {Type: "string", Description: "Text to search for; matching is case-insensitive and tolerates typos on Postgres"},
+		"limit": {Type: "integer", Description: "Maximum number of results (default 20)"},
+	},
+	Required: []string{"query"},
+}
+
+// registerSearchTools registers the ranked search tools
+func registerSearchTools(s *mcp.Server, repo repository.SearchRepository) {
+	s.AddTool(mcp.Tool{
+		Name:        "film_search",
+		Description: "Search films by title, ranked by relevance",
+		InputSchema: searchSchema,
+		Annotations: readOnly,
+	}, searchHandler(repo.SearchFilms))
+
+	s.AddTool(mcp.Tool{
+		Name:        "actor_search",
+		Description: "Search actors by name, ranked by relevance",
+		InputSchema: searchSchema,
+		Annotations: readOnly,
+	}, searchHandler(repo.SearchActors))
+
+	s.AddTool(mcp.Tool{
+		Name:        "customer_search",
+		Description: "Search customers by name, ranked by relevance",
+		InputSchema: searchSchema,
+		Annotations: readOnly,
+	}, searchHandler(repo.SearchCustomers))
+}
+
+// searchHandler adapts a search method to a tool handler
+func searchHandler[T any](search func(ctx context.Context, query string, limit int) ([]repository.SearchResult[T], error)) mcp.ToolHandler {
+	return func(ctx context.Context, args mcp.Arguments) (any, error) {
+		query, err := args.String("query")
+		if err != nil {
+			return nil, err
+		}
+		limit, err := args.OptionalInt("limit", 0)
+		if err != nil {
+			return nil, err
+		}
+		return search(ctx, query, limit)
+	}
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"CortexMCP/db/repository"
+	"CortexMCP/db/transfer"
+	"CortexMCP/pkg/db"
+	"CortexMCP/pkg/mcp"
+	"CortexMCP/pkg/ratelimit"
+	"CortexMCP/pkg/redact"
+	"CortexMCP/pkg/telemetry"
+
+	"gorm.io/gorm"
+)
+
+// Name identifies the server to MCP clients
+const Name = "cortex-mcp"
+
+// Version is the server version reported to MCP clients, overridden at build time
+var Version = "dev"
+
+// readOnly annotates tools that only read data
+var readOnly = &mcp.ToolAnnotations{ReadOnlyHint: true, IdempotentHint: true}
+
+// Repositories are the repositories backing the MCP tools
+type Repositories struct {
+	Search         repository.SearchRepository
+	Recommendation repository.RecommendationRepository
+	Film           repository.FilmRepository
+	Actor          repository.ActorRepository
+	Category       repository.CategoryRepository
+	Store          repository.StoreRepository
+	Customer       repository.CustomerRepository
+	Inventory      repository.InventoryRepository
+	Rental         repository.RentalRepository
+	Reservation    repository.ReservationRepository
+	Waitlist       repository.WaitlistRepository
+	Payment        repository.PaymentRepository
+	AuditLog       repository.AuditLogRepository
+	Transfer       transfer.Service
+}
+
+// NewRepositories creates the repositories backing the MCP tools
+func NewRepositories(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Search:         repository.NewSearchRepository(db),
+		Recommendation: repository.NewRecommendationRepository(db),
+		Film:           repository.NewFilmRepository(db),
+		Actor:          repository.NewActorRepository(db),
+		Category:       repository.NewCategoryRepository(db),
+		Store:          repository.NewStoreRepository(db),
+		Customer:       repository.NewCustomerRepository(db),
+		Inventory:      repository.NewInventoryRepository(db),
+		Rental:         repository.NewRentalRepository(db),
+		Reservation:    repository.NewReservationRepository(db),
+		Waitlist:       repository.NewWaitlistRepository(db),
+		Payment:        repository.NewPaymentRepository(db),
+		AuditLog:       repository.NewAuditLogRepository(db),
+		Transfer:       transfer.NewService(db),
+	}
+}
+
+// Option configures optional features of the server created by NewServer
+type Option func(s *mcp.Server)
+
+// WithMetrics records tool call metrics in metrics
+func WithMetrics(metrics *telemetry.Metrics) Option {
+	return func(s *mcp.Server) {
+		s.Use(metricsMiddleware(metrics))
+	}
+}
+
+// WithSlowQueries publishes the slow queries kept by the SQL logger as the dvd://diagnostics/slow-queries
+// resource
+func WithSlowQueries(queries *db.SlowQueries) Option {
+	return func(s *mcp.Server) {
+		registerDiagnosticResources(s, queries)
+	}
+}
+
+// NewServer creates an MCP server exposing the DVD rental tools. Every request is traced with the global
+// tracer provider and given a correlation ID. Resources are read with the principal, policy and redaction
+// of the tools.
+func NewServer(cfg *Config, repos *Repositories, opts ...Option) *mcp.Server {
+	s := mcp.NewServer(Name, Version)
+	s.UseRequest(telemetry.TraceRequests, correlationMiddleware)
+	s.Use(errorMiddleware)
+	s.UseResource(errorResourceMiddleware, principalResourceMiddleware)
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Use(principalMiddleware)
+	if cfg.Limits.Enabled {
+		s.Use(limitMiddleware(ratelimit.New(cfg.Limits, cfg.Database.MaxOpenConns)))
+	}
+	if cfg.Policy.Enabled {
+		s.Use(policyMiddleware(s, cfg.Policy))
+		s.UseResource(policyResourceMiddleware(cfg.Policy))
+	}
+	redactor := redact.New(cfg.Redaction)
+	s.Use(redactMiddleware(redactor))
+	s.UseResource(redactResourceMiddleware(redactor))
+	registerSearchTools(s, repos.Search)
+	registerRecommendationTools(s, repos.Recommendation)
+	registerFinderTools(s, repos)
+	registerCastTools(s, repos)
+	registerRentalTools(s, repos)
+	registerReservationTools(s, repos)
+	registerWaitlistTools(s, repos)
+	registerPaymentTools(s, repos)
+	registerTransferTools(s, repos.Transfer, cfg.Transfer)
+	registerAuditTools(s, repos.AuditLog)
+	registerChangeResources(s, repos)
+	return s
+}
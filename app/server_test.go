@@ -0,0 +1,159 @@
+package app
+
+import (
+	"CortexMCP/db/entity"
+	"CortexMCP/db/repository"
+	"CortexMCP/pkg/mcp"
+	"CortexMCP/pkg/policy"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type fakeSearchRepository struct {
+	query string
+	limit int
+}
+
+func (f *fakeSearchRepository) SearchFilms(ctx context.Context, query string, limit int) ([]repository.SearchResult[entity.Film], error) {
+	f.query, f.limit = query, limit
+	return []repository.SearchResult[entity.Film]{{Entity: entity.Film{FilmID: 1, Title: "The Matrix"}, Score: 1}}, nil
+}
+
+func (f *fakeSearchRepository) SearchActors(ctx context.Context, query string, limit int) ([]repository.SearchResult[entity.Actor], error) {
+	return nil, nil
+}
+
+func (f *fakeSearchRepository) SearchCustomers(ctx context.Context, query string, limit int) ([]repository.SearchResult[entity.Customer], error) {
+	return nil, nil
+}
+
+type fakeRecommendationRepository struct {
+	customerID uint
+	limit      int
+}
+
+func (f *fakeRecommendationRepository) RecommendForCustomer(ctx context.Context, customerID uint, limit int) ([]repository.FilmRecommendation, error) {
+	f.customerID, f.limit = customerID, limit
+	return []repository.FilmRecommendation{{Film: entity.Film{FilmID: 2}, Score: 0.5, AvailableCopies: 3}}, nil
+}
+
+// newTestRepositories creates repositories over sqlmock with fake search and recommendation repositories and
+// the policy callbacks main registers
+func newTestRepositories(t *testing.T) (*Repositories, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open gorm database: %v", err)
+	}
+	if err := policy.Register(gormDB); err != nil {
+		t.Fatalf("Failed to register policy callbacks: %v", err)
+	}
+
+	repos := NewRepositories(gormDB)
+	repos.Search = &fakeSearchRepository{}
+	repos.Recommendation = &fakeRecommendationRepository{}
+	return repos, mock
+}
+
+// testConfig returns the default configuration with files written to a temporary directory
+func testConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("Failed to load default config: %v", err)
+	}
+	cfg.Transfer.Dir = t.TempDir()
+	return cfg
+}
+
+func callTool(t *testing.T, s *mcp.Server, name string, args map[string]any) mcp.Response {
+	t.Helper()
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]any{"name": name, "arguments": args},
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode request: %v", err)
+	}
+
+	var resp mcp.Response
+	if err := json.Unmarshal(s.Handle(context.Background(), message), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func toolText(t *testing.T, resp mcp.Response) string {
+	t.Helper()
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	return resp.Result.(map[string]any)["content"].([]any)[0].(map[string]any)["text"].(string)
+}
+
+func TestServer_FilmRecommend(t *testing.T) {
+	repos, _ := newTestRepositories(t)
+	recommendations := &fakeRecommendationRepository{}
+	repos.Recommendation = recommendations
+	s := NewServer(testConfig(t), repos)
+
+	var result []repository.FilmRecommendation
+	if err := json.Unmarshal([]byte(toolText(t, callTool(t, s, "film_recommend", map[string]any{"customer_id": 7, "limit": 5}))), &result); err != nil {
+		t.Fatalf("Failed to decode tool result: %v", err)
+	}
+
+	if recommendations.customerID != 7 || recommendations.limit != 5 {
+		t.Errorf("Expected customer 7 and limit 5, got %d and %d", recommendations.customerID, recommendations.limit)
+	}
+	if len(result) != 1 || result[0].AvailableCopies != 3 {
+		t.Errorf("Unexpected recommendations: %+v", result)
+	}
+
+	if resp := callTool(t, s, "film_recommend", map[string]any{}); resp.Error == nil || resp.Error.Code != mcp.InvalidParams {
+		t.Errorf("Expected invalid params without customer_id, got %+v", resp.Error)
+	}
+}
+
+func TestServer_FilmSearch(t *testing.T) {
+	repos, _ := newTestRepositories(t)
+	search := &fakeSearchRepository{}
+	repos.Search = search
+	s := NewServer(testConfig(t), repos)
+
+	text := toolText(t, callTool(t, s, "film_search", map[string]any{"query": "matrix"}))
+
+	if search.query != "matrix" || search.limit != 0 {
+		t.Errorf("Expected query matrix with default limit, got %q and %d", search.query, search.limit)
+	}
+	var result []repository.SearchResult[entity.Film]
+	if err := json.Unmarshal([]byte(text), &result); err != nil || len(result) != 1 {
+		t.Errorf("Unexpected search result %s: %v", text, err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("Failed to load default config: %v", err)
+	}
+	if cfg.Database.DbType != "POSTGRES" {
+		t.Errorf("Expected POSTGRES database, got %s", cfg.Database.DbType)
+	}
+
+	if _, err := LoadConfig("does-not-exist.yaml"); err == nil {
+		t.Error("Expected error for a missing config file")
+	}
+}
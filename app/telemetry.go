@@ -0,0 +1,43 @@
+package app
+
+import (
+	"CortexMCP/pkg/correlation"
+	"CortexMCP/pkg/mcp"
+	"CortexMCP/pkg/telemetry"
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// correlationMiddleware gives every request a correlation ID, unless it already has one, so that the
+// statements it runs can be told apart in the logs. The ID is the trace ID when the request is traced.
+func correlationMiddleware(next mcp.RequestHandler) mcp.RequestHandler {
+	return func(ctx context.Context, req *mcp.Request) (any, error) {
+		if _, ok := correlation.FromContext(ctx); !ok {
+			id := correlation.New()
+			if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+				id = span.TraceID().String()
+			}
+			ctx = correlation.NewContext(ctx, id)
+		}
+		return next(ctx, req)
+	}
+}
+
+// metricsMiddleware records the duration of every tool call and counts failed calls by error kind. It runs
+// inside errorMiddleware, so it sees the errors before they are mapped to JSON-RPC errors.
+func metricsMiddleware(metrics *telemetry.Metrics) mcp.ToolMiddleware {
+	return func(name string, next mcp.ToolHandler) mcp.ToolHandler {
+		return func(ctx context.Context, args mcp.Arguments) (any, error) {
+			start := time.Now()
+			result, err := next(ctx, args)
+			kind := ""
+			if err != nil {
+				kind = errorKind(err)
+			}
+			metrics.ObserveToolCall(name, time.Since(start), kind)
+			return result, err
+		}
+	}
+}
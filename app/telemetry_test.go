@@ -0,0 +1,45 @@
+package app
+
+import (
+	"CortexMCP/pkg/ratelimit"
+	"CortexMCP/pkg/telemetry"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestServer_Metrics(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	cfg := testConfig(t)
+	cfg.Limits = ratelimit.Config{
+		Enabled: true,
+		Tools:   map[string]ratelimit.Rate{"rental_find_overdue": {PerSecond: 0.1, Burst: 1}},
+	}
+	metrics := telemetry.NewMetrics()
+	s := NewServer(cfg, repos, WithMetrics(metrics))
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental`")).WillReturnRows(sqlmock.NewRows([]string{"rental_id"}))
+	callTool(t, s, "rental_find_overdue", map[string]any{})
+	callTool(t, s, "rental_find_overdue", map[string]any{})
+	callTool(t, s, "film_recommend", map[string]any{})
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`mcp_tool_call_duration_seconds_count{outcome="ok",tool="rental_find_overdue"} 1`,
+		`mcp_tool_call_errors_total{error="rate_limited",tool="rental_find_overdue"} 1`,
+		`mcp_tool_call_errors_total{error="invalid_params",tool="film_recommend"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %s", want)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package app
+
+import (
+	"CortexMCP/db/transfer"
+	"CortexMCP/pkg/mcp"
+	"CortexMCP/pkg/policy"
+	"CortexMCP/pkg/principal"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// transferFiles writes export files and import reports to a directory and publishes each one as a resource
+// readable by its owner, until both are deleted after ttl
+type transferFiles struct {
+	server *mcp.Server
+	dir    string
+	ttl    time.Duration
+}
+
+// transferOwner is the caller who created a transfer file, with the grant they created it under
+type transferOwner struct {
+	principal principal.Principal
+	grant     policy.Grant
+}
+
+// ownerFromContext returns the caller of a tool or reader of a resource as a transferOwner
+func ownerFromContext(ctx context.Context) transferOwner {
+	p, _ := principal.FromContext(ctx)
+	grant, _ := policy.FromContext(ctx)
+	return transferOwner{principal: p, grant: grant}
+}
+
+// allows reports whether reader may read the owner's file: only the owner may, and only while their grant
+// still covers every store the file was created for
+func (o transferOwner) allows(reader transferOwner) bool {
+	if reader.principal != o.principal {
+		return false
+	}
+	if reader.grant.AllStores {
+		return true
+	}
+	if o.grant.AllStores {
+		return false
+	}
+	for _, storeID := range o.grant.StoreIDs {
+		if !reader.grant.HasStore(storeID) {
+			return false
+		}
+	}
+	return true
+}
+
+// exportResult is the result of the table_export tool
+type exportResult struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType"`
+	Rows     int    `json:"rows"`
+}
+
+// importResult is the result of the table_import tool; the full report with every rejected line is at URI
+type importResult struct {
+	URI      string `json:"uri"`
+	Imported int    `json:"imported"`
+	Failed   int    `json:"failed"`
+}
+
+// registerTransferTools registers the CSV and JSON Lines import and export tools
+// Export files are written in full; reading the resource of one redacts it with the rules of the reader.
+func registerTransferTools(s *mcp.Server, service transfer.Service, cfg TransferConfig) {
+	dir := cfg.Dir
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), Name)
+	}
+	files := &transferFiles{server: s, dir: dir, ttl: cfg.TTL}
+
+	formats := make([]string, len(transfer.Formats))
+	for i, format := range transfer.Formats {
+		formats[i] = string(format)
+	}
+	tableProperty := mcp.Property{Type: "string", Description: "Table to transfer", Enum: service.Tables()}
+	formatProperty := mcp.Property{Type: "string", Description: "File format (default csv)", Enum: formats}
+
+	s.AddTool(mcp.Tool{
+		Name: "table_export",
+		Description: "Export every row of a table as CSV or JSON Lines, with columns named after the database columns. " +
+			"Returns the URI of a resource holding the file.",
+		InputSchema: mcp.Schema{
+			Properties: map[string]mcp.Property{"table": tableProperty, "format": formatProperty},
+			Required:   []string{"table"},
+		},
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		table, format, err := transferArguments(args)
+		if err != nil {
+			return nil, err
+		}
+
+		var rows int
+		uri, err := files.create(ctx, "exports", table, string(format), format.MimeType(), func(w io.Writer) error {
+			rows, err = service.Export(ctx, table, format, w)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return exportResult{URI: uri, MimeType: format.MimeType(), Rows: rows}, nil
+	})
+
+	s.AddTool(mcp.Tool{
+		Name: "table_import",
+		Description: "Import rows into a table from CSV (with a header row) or JSON Lines content. Invalid lines are " +
+			"skipped; returns the number of imported and failed rows and the URI of a report listing every failed line.",
+		InputSchema: mcp.Schema{
+			Properties: map[string]mcp.Property{
+				"table":   tableProperty,
+				"format":  formatProperty,
+				"content": {Type: "string", Description: "Rows to import"},
+				"conflict_key": {
+					Type:        "array",
+					Description: "Unique columns to upsert on, e.g. [\"email\"]; existing rows with the same values are updated",
+					Items:       &mcp.Property{Type: "string"},
+				},
+			},
+			Required: []string{"table", "content"},
+		},
+		Annotations: &mcp.ToolAnnotations{DestructiveHint: true},
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		table, format, err := transferArguments(args)
+		if err != nil {
+			return nil, err
+		}
+		content, err := args.String("content")
+		if err != nil {
+			return nil, err
+		}
+		conflictKey, err := args.OptionalStrings("conflict_key")
+		if err != nil {
+			return nil, err
+		}
+
+		report, err := service.Import(ctx, table, format, strings.NewReader(content), transfer.ImportOptions{ConflictKey: conflictKey})
+		if err != nil {
+			return nil, err
+		}
+		uri, err := files.create(ctx, "imports", table, "json", "application/json", func(w io.Writer) error {
+			return json.NewEncoder(w).Encode(report)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return importResult{URI: uri, Imported: report.Imported, Failed: report.Failed}, nil
+	})
+}
+
+// transferArguments returns the table and format arguments of a transfer tool
+func transferArguments(args mcp.Arguments) (string, transfer.Format, error) {
+	table, err := args.String("table")
+	if err != nil {
+		return "", "", err
+	}
+	format, err := args.OptionalString("format", string(transfer.CSV))
+	if err != nil {
+		return "", "", err
+	}
+	return table, transfer.Format(format), nil
+}
+
+// create writes a new file named after the table under dvd://<kind>/ and publishes it as a resource that only
+// the caller in ctx may read. The file and the resource are deleted after the TTL, when set.
+func (f *transferFiles) create(ctx context.Context, kind, table, extension, mimeType string, write func(w io.Writer) error) (string, error) {
+	dir := filepath.Join(f.dir, kind)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s-%d.%s", table, time.Now().UnixNano(), extension)
+	path := filepath.Join(dir, name)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", err
+	}
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return "", err
+	}
+
+	uri := "dvd://" + kind + "/" + name
+	owner := ownerFromContext(ctx)
+	f.server.AddResource(mcp.Resource{URI: uri, Name: name, MimeType: mimeType}, func(ctx context.Context) (string, error) {
+		if !owner.allows(ownerFromContext(ctx)) {
+			return "", fmt.Errorf("%w: %s belongs to %s", policy.ErrForbidden, uri, owner.principal)
+		}
+		data, err := os.ReadFile(path)
+		return string(data), err
+	})
+	if f.ttl > 0 {
+		time.AfterFunc(f.ttl, func() {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("failed to delete %s: %v", path, err)
+			}
+			f.server.RemoveResource(uri)
+		})
+	}
+	return uri, nil
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"CortexMCP/pkg/mcp"
+	"CortexMCP/pkg/principal"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// readResource reads a resource from the server and returns its text
+func readResource(t *testing.T, s *mcp.Server, uri string) string {
+	t.Helper()
+	message, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "resources/read", "params": map[string]any{"uri": uri}})
+
+	var resp mcp.Response
+	if err := json.Unmarshal(s.Handle(context.Background(), message), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	return resp.Result.(map[string]any)["contents"].([]any)[0].(map[string]any)["text"].(string)
+}
+
+func TestServer_TableExport(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `actor`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "actor_id", "first_name", "last_name"}).AddRow(1, 1, "Penelope", "Guiness"))
+
+	var result exportResult
+	if err := json.Unmarshal([]byte(toolText(t, callTool(t, s, "table_export", map[string]any{"table": "actor"}))), &result); err != nil {
+		t.Fatalf("Failed to decode tool result: %v", err)
+	}
+	if result.Rows != 1 || result.MimeType != "text/csv" {
+		t.Errorf("Unexpected export result: %+v", result)
+	}
+
+	if text := readResource(t, s, result.URI); text != "actor_id,first_name,last_name\n1,Penelope,Guiness\n" {
+		t.Errorf("Unexpected export file:\n%s", text)
+	}
+}
+
+func TestServer_TableImport(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `category`")).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	var result importResult
+	args := map[string]any{"table": "category", "format": "jsonl", "content": "{\"name\":\"Documentary\"}\n{\"name\":\"\"}\n"}
+	if err := json.Unmarshal([]byte(toolText(t, callTool(t, s, "table_import", args))), &result); err != nil {
+		t.Fatalf("Failed to decode tool result: %v", err)
+	}
+	if result.Imported != 1 || result.Failed != 1 {
+		t.Errorf("Unexpected import result: %+v", result)
+	}
+
+	var report struct {
+		Errors []struct {
+			Line int `json:"line"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(readResource(t, s, result.URI)), &report); err != nil || len(report.Errors) != 1 || report.Errors[0].Line != 2 {
+		t.Errorf("Expected the report to list line 2, got %+v (%v)", report, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestServer_TableExportBelongsToItsOwner(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(policyConfig(t), repos)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer`")).WillReturnRows(customerRows())
+	resp := callToolAs(t, s, principal.Staff(2), "table_export", map[string]any{"table": "customer", "format": "jsonl"})
+	var result exportResult
+	if err := json.Unmarshal([]byte(toolText(t, resp)), &result); err != nil {
+		t.Fatalf("Failed to decode tool result: %v", err)
+	}
+
+	if resp := readResourceAs(t, s, principal.Staff(1), result.URI); resp.Error == nil || resp.Error.Code != CodeForbidden {
+		t.Errorf("Expected another principal to be denied the export, got %+v", resp.Error)
+	}
+	resp = readResourceAs(t, s, principal.Staff(2), result.URI)
+	if resp.Error != nil {
+		t.Fatalf("Expected the owner to read the export, got %v", resp.Error)
+	}
+	assertNoPII(t, "exported jsonl", resp.Result.(map[string]any)["contents"].([]any)[0].(map[string]any)["text"].(string))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestServer_TransferFilesExpire(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	cfg := testConfig(t)
+	cfg.Transfer.TTL = 10 * time.Millisecond
+	s := NewServer(cfg, repos)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `actor`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "actor_id", "first_name", "last_name"}).AddRow(1, 1, "Penelope", "Guiness"))
+	var result exportResult
+	if err := json.Unmarshal([]byte(toolText(t, callTool(t, s, "table_export", map[string]any{"table": "actor"}))), &result); err != nil {
+		t.Fatalf("Failed to decode tool result: %v", err)
+	}
+	path := filepath.Join(cfg.Transfer.Dir, "exports", strings.TrimPrefix(result.URI, "dvd://exports/"))
+
+	deadline := time.Now().Add(time.Second)
+	for len(s.Resources()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(s.Resources()) > 0 {
+		t.Errorf("Expected the export resource to be removed after the TTL, got %+v", s.Resources())
+	}
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the export file to be deleted after the TTL, got %v", err)
+	}
+}
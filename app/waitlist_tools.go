@@ -0,0 +1,100 @@
+package app
+
+import (
+	"CortexMCP/db/entity"
+	"CortexMCP/pkg/mcp"
+	"context"
+)
+
+// waitlistAssociations are the associations the waitlist tools may include
+var waitlistAssociations = []string{"Film", "Store", "Customer", "Reservation"}
+
+// registerWaitlistTools registers the tools queueing customers for films no copy of which is available
+func registerWaitlistTools(s *mcp.Server, repos *Repositories) {
+	s.AddTool(mcp.Tool{
+		Name: "waitlist_join",
+		Description: "Put a customer on the waitlist for a film at a store and return the entry. Only possible while " +
+			"no copy of the film is available there; when a copy is returned it is reserved for the customer who has " +
+			"waited longest.",
+		InputSchema: mcp.Schema{
+			Properties: map[string]mcp.Property{
+				"customer_id": {Type: "integer", Description: "Customer waiting"},
+				"film_id":     {Type: "integer", Description: "Film the customer waits for"},
+				"store_id":    {Type: "integer", Description: "Store the customer waits at"},
+			},
+			Required: []string{"customer_id", "film_id", "store_id"},
+		},
+		Annotations: &mcp.ToolAnnotations{},
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		customerID, err := args.Uint("customer_id")
+		if err != nil {
+			return nil, err
+		}
+		filmID, err := args.Uint("film_id")
+		if err != nil {
+			return nil, err
+		}
+		storeID, err := args.Uint("store_id")
+		if err != nil {
+			return nil, err
+		}
+		entry := &entity.WaitlistEntry{CustomerID: customerID, FilmID: filmID, StoreID: storeID}
+		if err := repos.Waitlist.Join(ctx, entry); err != nil {
+			return nil, err
+		}
+		return entry, nil
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "waitlist_leave",
+		Description: "Take a customer off a waitlist and return the cancelled entry",
+		InputSchema: mcp.Schema{
+			Properties: map[string]mcp.Property{
+				"waitlist_id": {Type: "integer", Description: "Waitlist entry to cancel"},
+			},
+			Required: []string{"waitlist_id"},
+		},
+		Annotations: &mcp.ToolAnnotations{},
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		waitlistID, err := args.Uint("waitlist_id")
+		if err != nil {
+			return nil, err
+		}
+		return repos.Waitlist.Leave(ctx, waitlistID)
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "waitlist_view",
+		Description: "List the customers waiting for a film at a store, first come first",
+		InputSchema: mcp.Schema{
+			Properties: map[string]mcp.Property{
+				"film_id":  {Type: "integer", Description: "Film waited for"},
+				"store_id": {Type: "integer", Description: "Store waited at"},
+				"include":  includeProperty(waitlistAssociations...),
+			},
+			Required: []string{"film_id", "store_id"},
+		},
+		Annotations: readOnly,
+	}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		filmID, err := args.Uint("film_id")
+		if err != nil {
+			return nil, err
+		}
+		storeID, err := args.Uint("store_id")
+		if err != nil {
+			return nil, err
+		}
+		opts, err := include(args)
+		if err != nil {
+			return nil, err
+		}
+		return repos.Waitlist.FindQueue(ctx, filmID, storeID, opts...)
+	})
+
+	s.AddTool(mcp.Tool{
+		Name:        "waitlist_find_by_customer",
+		Description: "Find the waitlist entries of a customer, including those fulfilled with a reservation",
+		InputSchema: idSchema("customer_id", "Customer waiting", waitlistAssociations...),
+		Annotations: readOnly,
+	}, idHandler("customer_id", repos.Waitlist.FindByCustomer))
+}
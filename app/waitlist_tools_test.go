@@ -0,0 +1,28 @@
+package app
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestServer_WaitlistJoinRejectsAvailableFilm(t *testing.T) {
+	repos, mock := newTestRepositories(t)
+	s := NewServer(testConfig(t), repos)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `inventory`")).
+		WithArgs("reserved", "out", "lost", 3, 1, "active", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	resp := callTool(t, s, "waitlist_join", map[string]any{"customer_id": 9, "film_id": 3, "store_id": 1})
+	if resp.Error == nil || resp.Error.Code != CodeValidation {
+		t.Errorf("Expected joining with a copy available to fail validation, got %+v", resp.Error)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+package audit
+
+import (
+	"CortexMCP/db/entity"
+	"CortexMCP/pkg/principal"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// beforeKey stores the rows snapshotted before an update or delete on the statement
+const beforeKey = "audit:before"
+
+// ignoredColumns are gorm.Model and entity.Versioned bookkeeping columns left out of audit entries; changed_at
+// records the time
+var ignoredColumns = map[string]bool{"id": true, "created_at": true, "updated_at": true, "deleted_at": true, "version": true}
+
+// row is a snapshot of a row, keyed by column name
+type row map[string]any
+
+// Register adds callbacks to db that write an entry to the audit_log table for every row created, updated
+// or deleted through it. Entries are written in the same transaction as the change and record the principal
+// carried by the statement context.
+func Register(db *gorm.DB) error {
+	const commit = "gorm:commit_or_rollback_transaction"
+	err := errors.Join(
+		db.Callback().Create().After("gorm:create").Before(commit).Register("audit:after_create", afterCreate),
+		db.Callback().Update().Before("gorm:update").Register("audit:before_update", snapshotBefore),
+		db.Callback().Update().After("gorm:update").Before(commit).Register("audit:after_update", afterUpdate),
+		db.Callback().Delete().Before("gorm:delete").Register("audit:before_delete", snapshotBefore),
+		db.Callback().Delete().After("gorm:delete").Before(commit).Register("audit:after_delete", afterDelete),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register audit callbacks: %w", err)
+	}
+	return nil
+}
+
+// audited reports whether the statement changes a table that is audited
+func audited(db *gorm.DB) bool {
+	return db.Error == nil && !db.DryRun && db.Statement.Schema != nil && db.Statement.Table != entity.AuditLog{}.TableName()
+}
+
+func afterCreate(db *gorm.DB) {
+	if !audited(db) || db.RowsAffected == 0 {
+		return
+	}
+
+	var entries []entity.AuditLog
+	for _, model := range models(db.Statement.ReflectValue) {
+		after := row{}
+		for _, field := range db.Statement.Schema.Fields {
+			if field.DBName != "" {
+				after[field.DBName], _ = field.ValueOf(db.Statement.Context, model)
+			}
+		}
+		entries = append(entries, newEntry(db, entity.AuditCreate, after, nil, after))
+	}
+	write(db, entries)
+}
+
+// snapshotBefore loads the rows an update or delete is about to change
+func snapshotBefore(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+
+	tx := snapshotQuery(db)
+	conditions := false
+	if where, ok := db.Statement.Clauses["WHERE"]; ok {
+		tx = tx.Clauses(where.Expression)
+		conditions = true
+	}
+	if field := db.Statement.Schema.PrioritizedPrimaryField; field != nil {
+		var ids []any
+		for _, model := range models(db.Statement.ReflectValue) {
+			if id, zero := field.ValueOf(db.Statement.Context, model); !zero {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > 0 {
+			tx = tx.Where(clause.IN{Column: clause.Column{Table: db.Statement.Table, Name: field.DBName}, Values: ids})
+			conditions = true
+		}
+	}
+	if !conditions {
+		// GORM rejects updates and deletes without conditions
+		return
+	}
+
+	rows, err := load(tx)
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: failed to load rows before change: %w", err))
+		return
+	}
+	db.InstanceSet(beforeKey, rows)
+}
+
+func afterUpdate(db *gorm.DB) {
+	before, ok := snapshotted(db)
+	if !ok || db.RowsAffected == 0 {
+		return
+	}
+	field := db.Statement.Schema.PrioritizedPrimaryField
+	if field == nil {
+		return
+	}
+
+	ids := make([]any, len(before))
+	for i, r := range before {
+		ids[i] = r[field.DBName]
+	}
+	rows, err := load(snapshotQuery(db).Where(clause.IN{Column: clause.Column{Table: db.Statement.Table, Name: field.DBName}, Values: ids}))
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: failed to load rows after change: %w", err))
+		return
+	}
+	after := make(map[string]row, len(rows))
+	for _, r := range rows {
+		after[fmt.Sprint(r[field.DBName])] = r
+	}
+
+	var entries []entity.AuditLog
+	for _, old := range before {
+		changedBefore, changedAfter := diff(old, after[fmt.Sprint(old[field.DBName])])
+		if len(changedAfter) > 0 {
+			entries = append(entries, newEntry(db, entity.AuditUpdate, old, changedBefore, changedAfter))
+		}
+	}
+	write(db, entries)
+}
+
+func afterDelete(db *gorm.DB) {
+	before, ok := snapshotted(db)
+	if !ok || db.RowsAffected == 0 {
+		return
+	}
+
+	entries := make([]entity.AuditLog, len(before))
+	for i, old := range before {
+		entries[i] = newEntry(db, entity.AuditDelete, old, old, nil)
+	}
+	write(db, entries)
+}
+
+// snapshotQuery starts a query for rows of the statement's table within the statement's transaction
+func snapshotQuery(db *gorm.DB) *gorm.DB {
+	tx := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Table(db.Statement.Table)
+	if db.Statement.Schema.LookUpField("deleted_at") != nil && !db.Statement.Unscoped {
+		tx = tx.Where(clause.Eq{Column: clause.Column{Table: db.Statement.Table, Name: "deleted_at"}, Value: nil})
+	}
+	return tx
+}
+
+// load runs tx and returns the rows it finds
+func load(tx *gorm.DB) ([]row, error) {
+	var results []map[string]any
+	if err := tx.Find(&results).Error; err != nil {
+		return nil, err
+	}
+	rows := make([]row, len(results))
+	for i, result := range results {
+		rows[i] = result
+	}
+	return rows, nil
+}
+
+func snapshotted(db *gorm.DB) ([]row, bool) {
+	if !audited(db) {
+		return nil, false
+	}
+	value, ok := db.InstanceGet(beforeKey)
+	if !ok {
+		return nil, false
+	}
+	rows, ok := value.([]row)
+	return rows, ok && len(rows) > 0
+}
+
+// diff returns the columns whose values differ between before and after
+func diff(before, after row) (row, row) {
+	changedBefore, changedAfter := row{}, row{}
+	for column, value := range after {
+		if ignoredColumns[column] {
+			continue
+		}
+		if old := normalize(before[column]); !reflect.DeepEqual(old, normalize(value)) {
+			changedBefore[column] = old
+			changedAfter[column] = normalize(value)
+		}
+	}
+	return changedBefore, changedAfter
+}
+
+// newEntry creates an audit entry for the row identified by key
+func newEntry(db *gorm.DB, action string, key, before, after row) entity.AuditLog {
+	entry := entity.AuditLog{
+		Table:     db.Statement.Table,
+		RecordID:  recordID(db.Statement.Schema, key),
+		Action:    action,
+		Before:    encode(before),
+		After:     encode(after),
+		ChangedAt: time.Now(),
+	}
+	if p, ok := principal.FromContext(db.Statement.Context); ok {
+		entry.Principal = p.String()
+	}
+	return entry
+}
+
+// recordID identifies a row by its own primary key columns, ignoring the gorm.Model id where the entity has
+// its own key. Composite keys are joined with commas.
+func recordID(s *schema.Schema, r row) string {
+	var values []string
+	for _, field := range s.PrimaryFields {
+		if field.DBName != "id" || len(s.PrimaryFields) == 1 {
+			values = append(values, fmt.Sprint(normalize(r[field.DBName])))
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+// encode serializes a row without the bookkeeping columns, or returns nil for no row
+func encode(r row) entity.JSONData {
+	if r == nil {
+		return nil
+	}
+	clean := make(row, len(r))
+	for column, value := range r {
+		if !ignoredColumns[column] {
+			clean[column] = normalize(value)
+		}
+	}
+	data, err := json.Marshal(clean)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// normalize converts driver values to values that compare and serialize consistently
+func normalize(value any) any {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return v.UTC()
+	case time.Time:
+		return v.UTC()
+	}
+	return value
+}
+
+// write inserts entries in the statement's transaction, failing the statement if they cannot be written
+func write(db *gorm.DB, entries []entity.AuditLog) {
+	if len(entries) == 0 {
+		return
+	}
+	if err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Create(&entries).Error; err != nil {
+		_ = db.AddError(fmt.Errorf("audit: failed to write audit log: %w", err))
+	}
+}
+
+// models returns the structs held by a statement's reflect value, which is a struct or a slice of structs
+func models(value reflect.Value) []reflect.Value {
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{value}
+	case reflect.Slice, reflect.Array:
+		structs := make([]reflect.Value, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			if model := reflect.Indirect(value.Index(i)); model.Kind() == reflect.Struct {
+				structs = append(structs, model)
+			}
+		}
+		return structs
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"CortexMCP/db/entity"
+	"CortexMCP/db/repository"
+	"CortexMCP/pkg/principal"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupAuditTest(t *testing.T, dialect string) (sqlmock.Sqlmock, *gorm.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	var dialector gorm.Dialector
+	switch dialect {
+	case "postgres":
+		dialector = postgres.New(postgres.Config{Conn: db})
+	default:
+		dialector = mysql.New(mysql.Config{
+			Conn:                      db,
+			SkipInitializeWithVersion: true,
+		})
+	}
+
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open gorm database: %v", err)
+	}
+	if err := Register(gormDB); err != nil {
+		t.Fatalf("Failed to register audit callbacks: %v", err)
+	}
+	return mock, gormDB
+}
+
+// jsonArg matches a JSON argument by value
+type jsonArg string
+
+func (j jsonArg) Match(v driver.Value) bool {
+	data, ok := v.(string)
+	if !ok {
+		return false
+	}
+	var expected, actual any
+	return json.Unmarshal([]byte(j), &expected) == nil && json.Unmarshal([]byte(data), &actual) == nil && reflect.DeepEqual(expected, actual)
+}
+
+func auditCustomer() *entity.Customer {
+	return &entity.Customer{
+		StoreID:    1,
+		FirstName:  "Mary",
+		LastName:   "Smith",
+		Email:      "mary.smith@dvdrental.com",
+		Address:    "1913 Hanoi Way",
+		District:   "Nagasaki",
+		City:       "Sasebo",
+		Country:    "Japan",
+		PostalCode: "35200",
+		Phone:      "28303384290",
+	}
+}
+
+func TestAudit_Create(t *testing.T) {
+	mock, db := setupAuditTest(t, "postgres")
+	repo := repository.NewCustomerRepository(db)
+	ctx := principal.NewContext(context.Background(), principal.Staff(2))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "customer"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id", "create_date"}).AddRow(1, 7, time.Now()))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_log" ("table_name","record_id","action","before_data","after_data","principal","changed_at")`)).
+		WithArgs("customer", "7", entity.AuditCreate, nil, sqlmock.AnyArg(), "staff:2", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_log_id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	if err := repo.Create(ctx, auditCustomer()); err != nil {
+		t.Fatalf("Error creating customer: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestAudit_Update(t *testing.T) {
+	mock, db := setupAuditTest(t, "mysql")
+	repo := repository.NewCustomerRepository(db)
+	ctx := principal.NewContext(context.Background(), principal.Client("inspector"))
+
+	customer := auditCustomer()
+	customer.ID = 1
+	customer.CustomerID = 7
+	customer.Email = "mary@dvdrental.com"
+	customer.Version = 1
+
+	columns := []string{"id", "customer_id", "email", "first_name", "updated_at", "version"}
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer` WHERE `customer`.`deleted_at` IS NULL AND version = ? AND `customer`.`id` = ?")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 7, "mary.smith@dvdrental.com", "Mary", time.Now(), 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `customer`")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer` WHERE `customer`.`deleted_at` IS NULL AND `customer`.`id` = ?")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 7, "mary@dvdrental.com", "Mary", time.Now(), 2))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `audit_log`")).
+		WithArgs("customer", "7", entity.AuditUpdate,
+			jsonArg(`{"email":"mary.smith@dvdrental.com"}`), jsonArg(`{"email":"mary@dvdrental.com"}`),
+			"client:inspector", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Update(ctx, customer); err != nil {
+		t.Fatalf("Error updating customer: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestAudit_DeleteWhere(t *testing.T) {
+	mock, db := setupAuditTest(t, "mysql")
+	repo := repository.NewInventoryRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `inventory` WHERE `inventory`.`deleted_at` IS NULL AND store_id = ?")).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "inventory_id", "film_id", "store_id"}).AddRow(10, 10, 1, 2).AddRow(11, 11, 1, 2))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `inventory` SET `deleted_at`=?")).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `audit_log`")).
+		WithArgs(
+			"inventory", "10", entity.AuditDelete, jsonArg(`{"inventory_id":10,"film_id":1,"store_id":2}`), nil, "", sqlmock.AnyArg(),
+			"inventory", "11", entity.AuditDelete, jsonArg(`{"inventory_id":11,"film_id":1,"store_id":2}`), nil, "", sqlmock.AnyArg(),
+		).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectCommit()
+
+	if _, err := repo.DeleteWhere(context.Background(), "store_id = ?", 2); err != nil {
+		t.Fatalf("Error deleting inventory: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
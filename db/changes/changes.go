@@ -0,0 +1,85 @@
+package changes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Modes of watching for changes
+const (
+	// ModeAuto listens for notifications on Postgres and polls other databases
+	ModeAuto = "auto"
+
+	// ModeListen listens for the notifications sent by the triggers of migration 000005 on Postgres
+	ModeListen = "listen"
+
+	// ModePoll polls the updated_at and deleted_at columns of the watched tables
+	ModePoll = "poll"
+)
+
+// Change is a change to a row of a watched table. The IDs relevant to the table are set: the rental and its
+// copy and store for rentals, the copy and store for inventory, the reservation and its copy and store for
+// reservations, the payment and rental for payments, and the entry, customer, film and store for waitlists.
+type Change struct {
+	Table         string `json:"table"`
+	Op            string `json:"op"`
+	RentalID      uint   `json:"rental_id,omitempty"`
+	InventoryID   uint   `json:"inventory_id,omitempty"`
+	StoreID       uint   `json:"store_id,omitempty"`
+	PaymentID     uint   `json:"payment_id,omitempty"`
+	ReservationID uint   `json:"reservation_id,omitempty"`
+	WaitlistID    uint   `json:"waitlist_id,omitempty"`
+	CustomerID    uint   `json:"customer_id,omitempty"`
+	FilmID        uint   `json:"film_id,omitempty"`
+
+	// OldInventoryID and OldStoreID are the copy and store before an update that changed them
+	OldInventoryID uint `json:"old_inventory_id,omitempty"`
+	OldStoreID     uint `json:"old_store_id,omitempty"`
+}
+
+// Source delivers changes to the watched tables to handle until ctx is cancelled
+type Source interface {
+	Run(ctx context.Context, handle func(Change)) error
+}
+
+// Config configures how changes are watched for
+type Config struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// Mode is auto, listen or poll
+	Mode string `yaml:"mode" mapstructure:"mode" validate:"omitempty,oneof=auto listen poll"`
+
+	// PollInterval is how often changes are polled for, and how far back each poll looks for changes committed
+	// late. Defaults to 5s.
+	PollInterval time.Duration `yaml:"pollInterval" mapstructure:"pollInterval" validate:"min=0"`
+}
+
+// NewSource creates the source of changes to the tables of db as configured
+func NewSource(db *gorm.DB, cfg Config) (Source, error) {
+	mode := cfg.Mode
+	if mode == "" || mode == ModeAuto {
+		mode = ModePoll
+		if db.Dialector.Name() == "postgres" {
+			mode = ModeListen
+		}
+	}
+
+	switch mode {
+	case ModeListen:
+		if db.Dialector.Name() != "postgres" {
+			return nil, fmt.Errorf("listening for changes needs Postgres, not %s", db.Dialector.Name())
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		return NewListener(sqlDB), nil
+	case ModePoll:
+		return NewPoller(db, cfg.PollInterval), nil
+	default:
+		return nil, fmt.Errorf("unsupported change mode %q", cfg.Mode)
+	}
+}
@@ -0,0 +1,189 @@
+package changes
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupPollerTest(t *testing.T) (sqlmock.Sqlmock, *gorm.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open gorm database: %v", err)
+	}
+	return mock, gormDB
+}
+
+func TestNewSource(t *testing.T) {
+	_, db := setupPollerTest(t)
+
+	source, err := NewSource(db, Config{Enabled: true})
+	if _, ok := source.(*Poller); err != nil || !ok {
+		t.Errorf("Expected MySQL to be polled, got %T and %v", source, err)
+	}
+	if _, err := NewSource(db, Config{Enabled: true, Mode: ModeListen}); err == nil {
+		t.Error("Expected listening on MySQL to fail")
+	}
+}
+
+func TestChange_TriggerPayload(t *testing.T) {
+	payload := `{"op": "UPDATE", "table": "inventory", "store_id": 2, "inventory_id": 10, "old_store_id": 1, "old_inventory_id": 10}`
+
+	var change Change
+	if err := json.Unmarshal([]byte(payload), &change); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	want := Change{Table: "inventory", Op: "UPDATE", InventoryID: 10, StoreID: 2, OldInventoryID: 10, OldStoreID: 1}
+	if change != want {
+		t.Errorf("Expected %+v, got %+v", want, change)
+	}
+}
+
+func TestChange_ReservationTriggerPayload(t *testing.T) {
+	payload := `{"op": "INSERT", "table": "reservation", "store_id": 1, "inventory_id": 10, "reservation_id": 3}`
+
+	var change Change
+	if err := json.Unmarshal([]byte(payload), &change); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	want := Change{Table: "reservation", Op: "INSERT", ReservationID: 3, InventoryID: 10, StoreID: 1}
+	if change != want {
+		t.Errorf("Expected %+v, got %+v", want, change)
+	}
+}
+
+func TestPoller_Poll(t *testing.T) {
+	mock, db := setupPollerTest(t)
+	poller := NewPoller(db, time.Second)
+	since := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	state := &pollState{since: since, seen: map[string]time.Time{}}
+	later := since.Add(time.Second)
+
+	query := regexp.QuoteMeta("SELECT rental.rental_id, rental.inventory_id, inventory.store_id, rental.created_at, rental.updated_at, rental.deleted_at FROM `rental` " +
+		"LEFT JOIN inventory ON inventory.inventory_id = rental.inventory_id WHERE rental.updated_at >= ? OR rental.deleted_at >= ?")
+	columns := []string{"rental_id", "inventory_id", "store_id", "created_at", "updated_at", "deleted_at"}
+	mock.ExpectQuery(query).
+		WithArgs(since.Add(-time.Second), since.Add(-time.Second)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, 10, 1, later, later, nil).
+			AddRow(2, 11, 1, since, since, later))
+	// The next poll looks back one interval, selecting the rows reported already again, which are skipped
+	mock.ExpectQuery(query).
+		WithArgs(since, since).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, 10, 1, later, later, nil).
+			AddRow(2, 11, 1, since, since, later))
+
+	var changes []Change
+	for i := 0; i < 2; i++ {
+		if err := poller.poll(context.Background(), polledTables[0], state, func(c Change) { changes = append(changes, c) }); err != nil {
+			t.Fatalf("Error polling: %v", err)
+		}
+	}
+
+	want := []Change{
+		{Table: "rental", Op: "INSERT", RentalID: 1, InventoryID: 10, StoreID: 1},
+		{Table: "rental", Op: "DELETE", RentalID: 2, InventoryID: 11, StoreID: 1},
+	}
+	if len(changes) != len(want) || changes[0] != want[0] || changes[1] != want[1] {
+		t.Errorf("Expected %+v, got %+v", want, changes)
+	}
+	if !state.since.Equal(later) {
+		t.Errorf("Expected the high-water mark to advance to %v, got %v", later, state.since)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPoller_PollReservationsOfTheSameCopy(t *testing.T) {
+	mock, db := setupPollerTest(t)
+	poller := NewPoller(db, time.Second)
+	since := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	state := &pollState{since: since, seen: map[string]time.Time{}}
+	later := since.Add(time.Second)
+
+	query := regexp.QuoteMeta("SELECT reservation.reservation_id, reservation.inventory_id, inventory.store_id, reservation.created_at, reservation.updated_at, reservation.deleted_at FROM `reservation` " +
+		"LEFT JOIN inventory ON inventory.inventory_id = reservation.inventory_id WHERE reservation.updated_at >= ? OR reservation.deleted_at >= ?")
+	columns := []string{"reservation_id", "inventory_id", "store_id", "created_at", "updated_at", "deleted_at"}
+	mock.ExpectQuery(query).
+		WithArgs(since.Add(-time.Second), since.Add(-time.Second)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 10, 1, since, later, nil))
+	// A new hold on the copy is made in the same instant the expired one was released
+	mock.ExpectQuery(query).
+		WithArgs(since, since).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, 10, 1, since, later, nil).
+			AddRow(2, 10, 1, later, later, nil))
+
+	var changes []Change
+	for i := 0; i < 2; i++ {
+		if err := poller.poll(context.Background(), polledTables[3], state, func(c Change) { changes = append(changes, c) }); err != nil {
+			t.Fatalf("Error polling: %v", err)
+		}
+	}
+
+	want := []Change{
+		{Table: "reservation", Op: "UPDATE", ReservationID: 1, InventoryID: 10, StoreID: 1},
+		{Table: "reservation", Op: "INSERT", ReservationID: 2, InventoryID: 10, StoreID: 1},
+	}
+	if len(changes) != len(want) || changes[0] != want[0] || changes[1] != want[1] {
+		t.Errorf("Expected %+v, got %+v", want, changes)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPoller_PollLateCommit(t *testing.T) {
+	mock, db := setupPollerTest(t)
+	poller := NewPoller(db, time.Second)
+	since := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	state := &pollState{since: since, seen: map[string]time.Time{}}
+	early, late := since.Add(200*time.Millisecond), since.Add(500*time.Millisecond)
+
+	query := regexp.QuoteMeta("FROM `inventory` WHERE inventory.updated_at >= ? OR inventory.deleted_at >= ?")
+	columns := []string{"inventory_id", "store_id", "created_at", "updated_at", "deleted_at"}
+	// Copy 11 is updated by a transaction that commits after the update of copy 10, stamped later, was polled
+	mock.ExpectQuery(query).
+		WithArgs(since.Add(-time.Second), since.Add(-time.Second)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(10, 1, since, late, nil))
+	mock.ExpectQuery(query).
+		WithArgs(late.Add(-time.Second), late.Add(-time.Second)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(11, 1, since, early, nil).
+			AddRow(10, 1, since, late, nil))
+
+	var changes []Change
+	for i := 0; i < 2; i++ {
+		if err := poller.poll(context.Background(), polledTables[1], state, func(c Change) { changes = append(changes, c) }); err != nil {
+			t.Fatalf("Error polling: %v", err)
+		}
+	}
+
+	want := []Change{
+		{Table: "inventory", Op: "UPDATE", InventoryID: 10, StoreID: 1},
+		{Table: "inventory", Op: "UPDATE", InventoryID: 11, StoreID: 1},
+	}
+	if len(changes) != len(want) || changes[0] != want[0] || changes[1] != want[1] {
+		t.Errorf("Expected %+v, got %+v", want, changes)
+	}
+	if !state.since.Equal(late) {
+		t.Errorf("Expected the high-water mark to stay at %v, got %v", late, state.since)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package changes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Channel is the Postgres channel the change triggers notify
+const Channel = "dvd_changes"
+
+// Listener is a Source receiving the notifications of the change triggers on a dedicated Postgres connection,
+// opened outside the pool so that listening does not take a connection from the queries for the life of the process
+type Listener struct {
+	db    *sql.DB
+	retry time.Duration
+}
+
+// NewListener creates a Listener connecting like the connections of db, which must use the pgx driver
+func NewListener(db *sql.DB) *Listener {
+	return &Listener{db: db, retry: 5 * time.Second}
+}
+
+// Run listens for changes until ctx is cancelled, reconnecting when the connection fails
+func (l *Listener) Run(ctx context.Context, handle func(Change)) error {
+	for {
+		err := l.listen(ctx, handle)
+		if ctx.Err() != nil {
+			return nil
+		}
+		log.Printf("change listener failed, reconnecting in %s: %v", l.retry, err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(l.retry):
+		}
+	}
+}
+
+// listen listens on one connection until it fails or ctx is cancelled
+func (l *Listener) listen(ctx context.Context, handle func(Change)) error {
+	conn, err := l.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var change Change
+		if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+			log.Printf("ignoring malformed change notification %q: %v", notification.Payload, err)
+			continue
+		}
+		handle(change)
+	}
+}
+
+// connect opens a connection with the configuration of a connection of the pool, which is returned at once
+func (l *Listener) connect(ctx context.Context) (*pgx.Conn, error) {
+	poolConn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var config *pgx.ConnConfig
+	err = poolConn.Raw(func(driverConn any) error {
+		pgxConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("listening for changes needs the pgx driver, got %T", driverConn)
+		}
+		config = pgxConn.Conn().Config().Copy()
+		return nil
+	})
+	if closeErr := poolConn.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pgx.ConnectConfig(ctx, config)
+}
@@ -0,0 +1,166 @@
+package changes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// polledTable is a watched table and the columns selected from it when polling
+type polledTable struct {
+	name    string
+	columns string
+	joins   string
+}
+
+// polledTables are the watched tables; rentals and reservations are joined to their copy for its store
+var polledTables = []polledTable{
+	{name: "rental", columns: "rental.rental_id, rental.inventory_id, inventory.store_id", joins: "LEFT JOIN inventory ON inventory.inventory_id = rental.inventory_id"},
+	{name: "inventory", columns: "inventory.inventory_id, inventory.store_id"},
+	{name: "payment", columns: "payment.payment_id, payment.rental_id"},
+	{name: "reservation", columns: "reservation.reservation_id, reservation.inventory_id, inventory.store_id", joins: "LEFT JOIN inventory ON inventory.inventory_id = reservation.inventory_id"},
+	{name: "waitlist", columns: "waitlist.waitlist_id, waitlist.customer_id, waitlist.film_id, waitlist.store_id"},
+}
+
+// polledRow is a changed row of a watched table
+type polledRow struct {
+	RentalID      uint
+	InventoryID   uint
+	StoreID       uint
+	PaymentID     uint
+	ReservationID uint
+	WaitlistID    uint
+	CustomerID    uint
+	FilmID        uint
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     *time.Time
+}
+
+// changedAt returns when the row was last changed
+func (r polledRow) changedAt() time.Time {
+	if r.DeletedAt != nil && r.DeletedAt.After(r.UpdatedAt) {
+		return *r.DeletedAt
+	}
+	return r.UpdatedAt
+}
+
+// Poller is a Source finding changes by polling the updated_at and deleted_at columns of the watched tables,
+// for databases without notifications. Rows deleted outright rather than soft-deleted are not seen, and
+// an update reports no previous copy or store.
+//
+// The timestamps are set when a statement runs, not when its transaction commits, so a row may become visible
+// after rows stamped later were already polled, and they come from the server's clock rather than the
+// database's. Every poll therefore looks back one interval before the latest change seen, and skips the rows
+// already reported; rows committed more than an interval late are missed.
+type Poller struct {
+	db       *gorm.DB
+	interval time.Duration
+}
+
+// NewPoller creates a Poller querying db every interval, 5s when zero
+func NewPoller(db *gorm.DB, interval time.Duration) *Poller {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &Poller{db: db, interval: interval}
+}
+
+// pollState is the high-water mark of the changes seen so far, and when each row changed within an interval
+// before it was last reported, so that polls overlapping the previous ones report every change once
+type pollState struct {
+	since time.Time
+	seen  map[string]time.Time
+}
+
+// Run polls for changes made from now on until ctx is cancelled
+func (p *Poller) Run(ctx context.Context, handle func(Change)) error {
+	var now time.Time
+	if err := p.db.WithContext(ctx).Raw("SELECT CURRENT_TIMESTAMP").Scan(&now).Error; err != nil {
+		return fmt.Errorf("failed to read the database time: %w", err)
+	}
+	states := make(map[string]*pollState, len(polledTables))
+	for _, table := range polledTables {
+		states[table.name] = &pollState{since: now, seen: map[string]time.Time{}}
+	}
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			for _, table := range polledTables {
+				if err := p.poll(ctx, table, states[table.name], handle); err != nil && ctx.Err() == nil {
+					log.Printf("failed to poll %s for changes: %v", table.name, err)
+				}
+			}
+		}
+	}
+}
+
+// poll reports the rows of table changed since an interval before the state's high-water mark that were not
+// reported yet, and advances the mark
+func (p *Poller) poll(ctx context.Context, table polledTable, state *pollState, handle func(Change)) error {
+	from := state.since.Add(-p.interval)
+	var rows []polledRow
+	query := p.db.WithContext(ctx).Table(table.name).
+		Select(fmt.Sprintf("%s, %s.created_at, %s.updated_at, %s.deleted_at", table.columns, table.name, table.name, table.name))
+	if table.joins != "" {
+		query = query.Joins(table.joins)
+	}
+	err := query.Where(fmt.Sprintf("%s.updated_at >= ? OR %s.deleted_at >= ?", table.name, table.name), from, from).
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		changedAt := row.changedAt()
+		key := fmt.Sprintf("%d/%d/%d/%d/%d", row.RentalID, row.InventoryID, row.PaymentID, row.ReservationID, row.WaitlistID)
+		if seenAt, ok := state.seen[key]; ok && !changedAt.After(seenAt) {
+			continue
+		}
+		state.seen[key] = changedAt
+		if changedAt.After(state.since) {
+			state.since = changedAt
+		}
+		handle(row.change(table.name))
+	}
+
+	// Rows changed before the next poll's window are not selected again
+	from = state.since.Add(-p.interval)
+	for key, changedAt := range state.seen {
+		if changedAt.Before(from) {
+			delete(state.seen, key)
+		}
+	}
+	return nil
+}
+
+// change converts a polled row of table to a change, telling inserts and deletes apart by the timestamps
+func (r polledRow) change(table string) Change {
+	op := "UPDATE"
+	switch {
+	case r.DeletedAt != nil:
+		op = "DELETE"
+	case r.CreatedAt.Equal(r.UpdatedAt):
+		op = "INSERT"
+	}
+	return Change{
+		Table:         table,
+		Op:            op,
+		RentalID:      r.RentalID,
+		InventoryID:   r.InventoryID,
+		StoreID:       r.StoreID,
+		PaymentID:     r.PaymentID,
+		ReservationID: r.ReservationID,
+		WaitlistID:    r.WaitlistID,
+		CustomerID:    r.CustomerID,
+		FilmID:        r.FilmID,
+	}
+}
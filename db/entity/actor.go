@@ -7,9 +7,10 @@ import (
 // Actor represents an actor in the DVD rental system
 type Actor struct {
 	gorm.Model
+	Versioned
 	ActorID   uint   `gorm:"primaryKey;column:actor_id;autoIncrement"`
-	FirstName string `gorm:"column:first_name;not null"`
-	LastName  string `gorm:"column:last_name;not null"`
+	FirstName string `gorm:"column:first_name;not null" validate:"required,max=50"`
+	LastName  string `gorm:"column:last_name;not null" validate:"required,max=50"`
 
 	// Relationships
 }
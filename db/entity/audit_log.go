@@ -0,0 +1,77 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Audit log actions
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// AuditLog records a change to a row of another table. Entries are append-only, so unlike the other
+// entities it does not embed gorm.Model.
+type AuditLog struct {
+	AuditLogID uint      `gorm:"primaryKey;column:audit_log_id;autoIncrement"`
+	Table      string    `gorm:"column:table_name;not null"`
+	RecordID   string    `gorm:"column:record_id;not null"`
+	Action     string    `gorm:"column:action;not null"`
+	Before     JSONData  `gorm:"column:before_data"`
+	After      JSONData  `gorm:"column:after_data"`
+	Principal  string    `gorm:"column:principal;not null"`
+	ChangedAt  time.Time `gorm:"column:changed_at;not null"`
+}
+
+// TableName overrides the table name
+func (AuditLog) TableName() string {
+	return "audit_log"
+}
+
+// JSONData is a JSON document stored in a JSONB column on Postgres and a text column elsewhere
+type JSONData []byte
+
+// Scan implements sql.Scanner; drivers return JSON columns as either bytes or strings
+func (j *JSONData) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append(JSONData(nil), v...)
+	case string:
+		*j = JSONData(v)
+	default:
+		return fmt.Errorf("cannot scan %T into JSONData", value)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer
+func (j JSONData) Value() (driver.Value, error) {
+	if j == nil {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+// MarshalJSON embeds the document as is, or null when there is none
+func (j JSONData) MarshalJSON() ([]byte, error) {
+	if j == nil {
+		return []byte("null"), nil
+	}
+	return json.RawMessage(j).MarshalJSON()
+}
+
+// UnmarshalJSON stores a copy of the document
+func (j *JSONData) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*j = nil
+		return nil
+	}
+	*j = append(JSONData(nil), data...)
+	return nil
+}
@@ -7,8 +7,9 @@ import (
 // Category represents a film genre in the DVD rental system
 type Category struct {
 	gorm.Model
+	Versioned
 	CategoryID uint   `gorm:"primaryKey;column:category_id;autoIncrement"`
-	Name       string `gorm:"column:name;not null;unique"`
+	Name       string `gorm:"column:name;not null;unique" validate:"required,max=50"`
 }
 
 // TableName overrides the table name
@@ -8,23 +8,24 @@ import (
 // Customer represents a customer in the DVD rental system
 type Customer struct {
 	gorm.Model
+	Versioned
 	CustomerID uint      `gorm:"primaryKey;column:customer_id;autoIncrement"`
-	StoreID    uint      `gorm:"column:store_id;not null"`
-	FirstName  string    `gorm:"column:first_name;not null"`
-	LastName   string    `gorm:"column:last_name;not null"`
-	Email      string    `gorm:"column:email;not null;unique"`
-	Address    string    `gorm:"column:address;not null"`
-	Address2   string    `gorm:"column:address2"`
-	District   string    `gorm:"column:district;not null"`
-	City       string    `gorm:"column:city;not null"`
-	Country    string    `gorm:"column:country;not null"`
-	PostalCode string    `gorm:"column:postal_code;not null"`
-	Phone      string    `gorm:"column:phone;not null"`
+	StoreID    uint      `gorm:"column:store_id;not null" validate:"required"`
+	FirstName  string    `gorm:"column:first_name;not null" validate:"required,max=50"`
+	LastName   string    `gorm:"column:last_name;not null" validate:"required,max=50"`
+	Email      string    `gorm:"column:email;not null;unique" validate:"required,email,max=100"`
+	Address    string    `gorm:"column:address;not null" validate:"required,max=100"`
+	Address2   string    `gorm:"column:address2" validate:"max=100"`
+	District   string    `gorm:"column:district;not null" validate:"required,max=50"`
+	City       string    `gorm:"column:city;not null" validate:"required,max=50"`
+	Country    string    `gorm:"column:country;not null" validate:"required,max=50"`
+	PostalCode string    `gorm:"column:postal_code;not null" validate:"required,max=20"`
+	Phone      string    `gorm:"column:phone;not null" validate:"required,phone"`
 	Active     bool      `gorm:"column:active;not null;default:true"`
 	CreateDate time.Time `gorm:"column:create_date;not null;default:CURRENT_DATE"`
 
 	// Relationships
-	Store Store `gorm:"foreignKey:StoreID"`
+	Store Store `gorm:"foreignKey:StoreID;references:StoreID" validate:"-"`
 }
 
 // TableName overrides the table name
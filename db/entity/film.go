@@ -7,15 +7,16 @@ import (
 // Film represents a movie in the DVD rental system
 type Film struct {
 	gorm.Model
+	Versioned
 	FilmID      uint   `gorm:"primaryKey;column:film_id;autoIncrement"`
-	Title       string `gorm:"column:title;not null"`
-	ReleaseYear int16  `gorm:"column:release_year;not null"`
-	Length      int16  `gorm:"column:length;not null"`
-	CategoryID  uint   `gorm:"column:category_id;not null"`
+	Title       string `gorm:"column:title;not null" validate:"required,max=255"`
+	ReleaseYear int16  `gorm:"column:release_year;not null" validate:"releaseyear"`
+	Length      int16  `gorm:"column:length;not null" validate:"gt=0"`
+	CategoryID  uint   `gorm:"column:category_id;not null" validate:"required"`
 
 	// Relationships
-	Category Category `gorm:"foreignKey:CategoryID"`
-	Actors   []*Actor `gorm:"many2many:film_actors;foreignKey:FilmID;joinForeignKey:film_id;References:ActorID;joinReferences:actor_id"`
+	Category Category `gorm:"foreignKey:CategoryID;references:CategoryID" validate:"-"`
+	Actors   []*Actor `gorm:"many2many:film_actors;foreignKey:FilmID;joinForeignKey:film_id;References:ActorID;joinReferences:actor_id" validate:"-"`
 }
 
 // TableName overrides the table name
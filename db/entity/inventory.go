@@ -7,13 +7,14 @@ import (
 // Inventory represents a copy of a film in a store in the DVD rental system
 type Inventory struct {
 	gorm.Model
+	Versioned
 	InventoryID uint `gorm:"primaryKey;column:inventory_id;autoIncrement"`
-	FilmID      uint `gorm:"column:film_id;not null"`
-	StoreID     uint `gorm:"column:store_id;not null"`
+	FilmID      uint `gorm:"column:film_id;not null" validate:"required"`
+	StoreID     uint `gorm:"column:store_id;not null" validate:"required"`
 
 	// Relationships
-	Film  Film  `gorm:"foreignKey:FilmID"`
-	Store Store `gorm:"foreignKey:StoreID"`
+	Film  Film  `gorm:"foreignKey:FilmID;references:FilmID" validate:"-"`
+	Store Store `gorm:"foreignKey:StoreID;references:StoreID" validate:"-"`
 }
 
 // TableName overrides the table name
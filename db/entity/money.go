@@ -0,0 +1,177 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of the amounts stored in the database, and of Money without a currency
+const DefaultCurrency = "USD"
+
+// moneyScale is the number of minor units in a major unit, e.g. cents in a dollar
+const moneyScale = 100
+
+// ErrCurrency is returned when Money of another currency than DefaultCurrency is stored
+var ErrCurrency = errors.New("amounts are stored in " + DefaultCurrency)
+
+// Money is an exact amount of a currency, kept as a whole number of minor units such as cents. Its zero value
+// is zero of DefaultCurrency. It is stored as a two-decimal NUMERIC or DECIMAL column, and its text form, used
+// for JSON, exports and configuration, is the decimal amount followed by the currency, e.g. "4.99 USD".
+type Money struct {
+	minor    int64
+	currency string
+}
+
+// NewMoney returns the given number of minor units of currency, DefaultCurrency when empty
+func NewMoney(minor int64, currency string) Money {
+	if currency == DefaultCurrency {
+		currency = ""
+	}
+	return Money{minor: minor, currency: currency}
+}
+
+// Cents returns an amount of DefaultCurrency given in minor units
+func Cents(minor int64) Money {
+	return Money{minor: minor}
+}
+
+// ParseMoney parses a decimal amount with an optional currency code after it, e.g. "4.99" or "4.99 EUR". Digits
+// beyond the minor unit are rounded half away from zero.
+func ParseMoney(text string) (Money, error) {
+	amount, currency, _ := strings.Cut(strings.TrimSpace(text), " ")
+	minor, err := parseMinor(amount)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q", text)
+	}
+	currency = strings.TrimSpace(currency)
+	if currency != "" && (len(currency) != 3 || strings.ToUpper(currency) != currency) {
+		return Money{}, fmt.Errorf("invalid currency %q", currency)
+	}
+	return NewMoney(minor, currency), nil
+}
+
+// Minor returns the amount in minor units
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+// Currency returns the ISO 4217 code of the currency
+func (m Money) Currency() string {
+	if m.currency == "" {
+		return DefaultCurrency
+	}
+	return m.currency
+}
+
+// Decimal returns the amount as a decimal number with two fraction digits, without the currency
+func (m Money) Decimal() string {
+	sign, minor := "", m.minor
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/moneyScale, minor%moneyScale)
+}
+
+// String returns the amount followed by the currency
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency()
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (m Money) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler with ParseMoney
+func (m *Money) UnmarshalText(text []byte) error {
+	parsed, err := ParseMoney(string(text))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner. MySQL and SQL Server return decimals as bytes and PostgreSQL as a string;
+// floats and integers, e.g. from SUM over an integer expression, are accepted too. NULL scans as zero.
+func (m *Money) Scan(value any) error {
+	var minor int64
+	var err error
+	switch v := value.(type) {
+	case nil:
+	case []byte:
+		minor, err = parseMinor(string(v))
+	case string:
+		minor, err = parseMinor(v)
+	case float64:
+		minor = int64(math.Round(v * moneyScale))
+	case int64:
+		minor = v * moneyScale
+	default:
+		err = fmt.Errorf("unsupported type %T", value)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to scan money: %w", err)
+	}
+	*m = Money{minor: minor}
+	return nil
+}
+
+// Value implements driver.Valuer, passing the amount as a decimal string that all dialects convert to their
+// decimal type exactly
+func (m Money) Value() (driver.Value, error) {
+	if m.Currency() != DefaultCurrency {
+		return nil, ErrCurrency
+	}
+	return m.Decimal(), nil
+}
+
+// GormDataType implements schema.GormDataTypeInterface
+func (Money) GormDataType() string {
+	return "decimal"
+}
+
+// GormDBDataType implements migrator.GormDataTypeInterface with the column type of the dialect
+func (Money) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "NUMERIC(10,2)"
+	}
+	return "DECIMAL(10,2)"
+}
+
+// parseMinor parses a decimal number into minor units, rounding half away from zero
+func parseMinor(text string) (int64, error) {
+	text = strings.TrimSpace(text)
+	negative := strings.HasPrefix(text, "-")
+	digits := strings.TrimLeft(text, "+-")
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole == "" && fraction == "" || len(text)-len(digits) > 1 {
+		return 0, fmt.Errorf("invalid decimal %q", text)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	fraction += "000"
+	major, err := strconv.ParseUint(whole, 10, 63)
+	if err != nil {
+		return 0, err
+	}
+	thousandths, err := strconv.ParseUint(fraction[:3], 10, 16)
+	if err != nil || strings.TrimRight(fraction[3:], "0123456789") != "" {
+		return 0, fmt.Errorf("invalid decimal %q", text)
+	}
+	if major > math.MaxInt64/moneyScale-1 {
+		return 0, fmt.Errorf("decimal %q out of range", text)
+	}
+	minor := int64(major)*moneyScale + int64(thousandths+5)/10
+	if negative {
+		minor = -minor
+	}
+	return minor, nil
+}
@@ -0,0 +1,83 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		text  string
+		want  Money
+		valid bool
+	}{
+		{"4.99", Cents(499), true},
+		{"4.99 USD", Cents(499), true},
+		{"1234567.8 EUR", NewMoney(123456780, "EUR"), true},
+		{"-0.5", Cents(-50), true},
+		{".25", Cents(25), true},
+		{"12", Cents(1200), true},
+		{"2.345", Cents(235), true},
+		{"-2.345", Cents(-235), true},
+		{"2.3449", Cents(234), true},
+		{"", Money{}, false},
+		{"four", Money{}, false},
+		{"1.2.3", Money{}, false},
+		{"--1", Money{}, false},
+		{"4.99 dollars", Money{}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.text)
+		if (err == nil) != tt.valid || got != tt.want {
+			t.Errorf("ParseMoney(%q) = %v, %v, want %v valid %v", tt.text, got, err, tt.want, tt.valid)
+		}
+	}
+}
+
+func TestMoney_Scan(t *testing.T) {
+	tests := []struct {
+		value any
+		want  Money
+	}{
+		{[]byte("19.99"), Cents(1999)},       // MySQL DECIMAL
+		{"0.10", Cents(10)},                  // PostgreSQL NUMERIC
+		{[]byte("1234.5000"), Cents(123450)}, // SQL Server DECIMAL
+		{0.1 + 0.2, Cents(30)},
+		{int64(3), Cents(300)},
+		{nil, Money{}},
+	}
+
+	for _, tt := range tests {
+		var got Money
+		if err := got.Scan(tt.value); err != nil || got != tt.want {
+			t.Errorf("Scan(%#v) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+
+	var m Money
+	if err := m.Scan(true); err == nil {
+		t.Error("Expected scanning a bool to fail")
+	}
+}
+
+func TestMoney_Value(t *testing.T) {
+	if value, err := Cents(-1205).Value(); err != nil || value != "-12.05" {
+		t.Errorf("Value() = %v, %v, want -12.05", value, err)
+	}
+	if _, err := NewMoney(100, "EUR").Value(); !errors.Is(err, ErrCurrency) {
+		t.Errorf("Expected storing euros to fail with ErrCurrency, got %v", err)
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(struct{ Fee Money }{Cents(1999)})
+	if err != nil || string(data) != `{"Fee":"19.99 USD"}` {
+		t.Fatalf("Marshal = %s, %v", data, err)
+	}
+	var decoded struct{ Fee Money }
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Fee != Cents(1999) {
+		t.Errorf("Unmarshal = %v, %v", decoded.Fee, err)
+	}
+}
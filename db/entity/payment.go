@@ -5,23 +5,45 @@ import (
 	"time"
 )
 
-// Payment represents a payment for a rental in the DVD rental system
+// PaymentKind is what a payment line of a rental is for
+type PaymentKind string
+
+// Payment kinds. Every kind but a refund is money the customer paid; a refund is money paid back to them.
+const (
+	PaymentCharge      PaymentKind = "charge"
+	PaymentLateFee     PaymentKind = "late_fee"
+	PaymentRefund      PaymentKind = "refund"
+	PaymentReplacement PaymentKind = "replacement"
+)
+
+// Payment represents a payment line of a rental in the DVD rental system. A rental may have several lines,
+// e.g. its charge and a late fee, and refunds of them.
 type Payment struct {
 	gorm.Model
-	PaymentID   uint      `gorm:"primaryKey;column:payment_id;autoIncrement"`
-	CustomerID  uint      `gorm:"column:customer_id;not null"`
-	StaffID     uint      `gorm:"column:staff_id;not null"`
-	RentalID    uint      `gorm:"column:rental_id;not null;unique"`
-	Amount      float64   `gorm:"column:amount;not null;type:numeric(5,2)"`
-	PaymentDate time.Time `gorm:"column:payment_date;not null"`
+	Versioned
+	PaymentID   uint        `gorm:"primaryKey;column:payment_id;autoIncrement"`
+	CustomerID  uint        `gorm:"column:customer_id;not null" validate:"required"`
+	StaffID     uint        `gorm:"column:staff_id;not null" validate:"required"`
+	RentalID    uint        `gorm:"column:rental_id;not null;index" validate:"required"`
+	Kind        PaymentKind `gorm:"column:kind;not null;default:charge" validate:"omitempty,oneof=charge late_fee refund replacement"`
+	Amount      Money       `gorm:"column:amount;not null" validate:"gte=0"`
+	PaymentDate time.Time   `gorm:"column:payment_date;not null" validate:"required"`
 
 	// Relationships
-	Customer Customer `gorm:"foreignKey:CustomerID"`
-	Staff    Staff    `gorm:"foreignKey:StaffID"`
-	Rental   Rental   `gorm:"foreignKey:RentalID"`
+	Customer Customer `gorm:"foreignKey:CustomerID;references:CustomerID" validate:"-"`
+	Staff    Staff    `gorm:"foreignKey:StaffID;references:StaffID" validate:"-"`
+	Rental   Rental   `gorm:"foreignKey:RentalID;references:RentalID" validate:"-"`
 }
 
 // TableName overrides the table name
 func (Payment) TableName() string {
 	return "payment"
 }
+
+// BeforeSave defaults a missing kind to a charge
+func (p *Payment) BeforeSave(tx *gorm.DB) error {
+	if p.Kind == "" {
+		p.Kind = PaymentCharge
+	}
+	return nil
+}
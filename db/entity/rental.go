@@ -8,21 +8,44 @@ import (
 // Rental represents a film rental transaction in the DVD rental system
 type Rental struct {
 	gorm.Model
-	RentalID    uint       `gorm:"primaryKey;column:rental_id;autoIncrement"`
-	RentalDate  time.Time  `gorm:"column:rental_date;not null"`
-	InventoryID uint       `gorm:"column:inventory_id;not null"`
-	CustomerID  uint       `gorm:"column:customer_id;not null"`
-	ReturnDate  *time.Time `gorm:"column:return_date"`
-	StaffID     uint       `gorm:"column:staff_id;not null"`
+	Versioned
+	RentalID    uint         `gorm:"primaryKey;column:rental_id;autoIncrement"`
+	RentalDate  time.Time    `gorm:"column:rental_date;not null" validate:"required"`
+	InventoryID uint         `gorm:"column:inventory_id;not null" validate:"required"`
+	CustomerID  uint         `gorm:"column:customer_id;not null" validate:"required"`
+	ReturnDate  *time.Time   `gorm:"column:return_date"`
+	StaffID     uint         `gorm:"column:staff_id;not null" validate:"required"`
+	Status      RentalStatus `gorm:"column:status;not null;default:out" validate:"omitempty,oneof=reserved out returned lost damaged"`
 
 	// Relationships
-	Inventory Inventory `gorm:"foreignKey:InventoryID"`
-	Customer  Customer  `gorm:"foreignKey:CustomerID"`
-	Staff     Staff     `gorm:"foreignKey:StaffID"`
-	Payment   *Payment  `gorm:"foreignKey:RentalID"`
+	Inventory   Inventory          `gorm:"foreignKey:InventoryID;references:InventoryID" validate:"-"`
+	Customer    Customer           `gorm:"foreignKey:CustomerID;references:CustomerID" validate:"-"`
+	Staff       Staff              `gorm:"foreignKey:StaffID;references:StaffID" validate:"-"`
+	Payments    []Payment          `gorm:"foreignKey:RentalID;references:RentalID" validate:"-"`
+	Transitions []RentalTransition `gorm:"foreignKey:RentalID;references:RentalID" validate:"-"`
 }
 
 // TableName overrides the table name
 func (Rental) TableName() string {
 	return "rental"
 }
+
+// BeforeSave defaults a missing status to returned if the rental has a return date and out otherwise
+func (r *Rental) BeforeSave(tx *gorm.DB) error {
+	if r.Status == "" {
+		r.Status = RentalOut
+		if r.ReturnDate != nil {
+			r.Status = RentalReturned
+		}
+	}
+	return nil
+}
+
+// AfterCreate records the status the rental was created with as its first transition. Upserts record none,
+// since their rows may have existed already.
+func (r *Rental) AfterCreate(tx *gorm.DB) error {
+	if _, upsert := tx.Statement.Clauses["ON CONFLICT"]; upsert {
+		return nil
+	}
+	return tx.Session(&gorm.Session{NewDB: true}).Create(NewRentalTransition(tx.Statement.Context, r.RentalID, "", r.Status, "", r.CreatedAt)).Error
+}
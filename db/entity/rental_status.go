@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"CortexMCP/pkg/principal"
+	"context"
+	"time"
+)
+
+// RentalStatus is the state of a rental
+type RentalStatus string
+
+// Rental states. A rental starts out reserved or out; returned, lost and damaged close it.
+const (
+	RentalReserved RentalStatus = "reserved"
+	RentalOut      RentalStatus = "out"
+	RentalReturned RentalStatus = "returned"
+	RentalLost     RentalStatus = "lost"
+	RentalDamaged  RentalStatus = "damaged"
+)
+
+// rentalTransitions lists the states each state may move to. A lost copy may still turn up and be returned,
+// and a returned copy may be found damaged on inspection.
+var rentalTransitions = map[RentalStatus][]RentalStatus{
+	RentalReserved: {RentalOut},
+	RentalOut:      {RentalReturned, RentalLost, RentalDamaged},
+	RentalLost:     {RentalReturned, RentalDamaged},
+	RentalReturned: {RentalDamaged},
+}
+
+// CanTransition reports whether a rental in state s may move to state to
+func (s RentalStatus) CanTransition(to RentalStatus) bool {
+	for _, next := range rentalTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Returned reports whether the copy of a rental in state s has come back to the store
+func (s RentalStatus) Returned() bool {
+	return s == RentalReturned || s == RentalDamaged
+}
+
+// HoldingRentalStatuses are the states whose rentals keep their copy from being rented to someone else
+var HoldingRentalStatuses = []RentalStatus{RentalReserved, RentalOut, RentalLost}
+
+// RentalTransition records a change of the status of a rental. Entries are append-only, so like AuditLog it
+// does not embed gorm.Model. From is empty for the status a rental was created with.
+type RentalTransition struct {
+	RentalTransitionID uint         `gorm:"primaryKey;column:rental_transition_id;autoIncrement"`
+	RentalID           uint         `gorm:"column:rental_id;not null"`
+	From               RentalStatus `gorm:"column:from_status"`
+	To                 RentalStatus `gorm:"column:to_status;not null"`
+	Actor              string       `gorm:"column:actor;not null"`
+	Note               string       `gorm:"column:note"`
+	ChangedAt          time.Time    `gorm:"column:changed_at;not null"`
+}
+
+// TableName overrides the table name
+func (RentalTransition) TableName() string {
+	return "rental_transition"
+}
+
+// NewRentalTransition records a change of the status of a rental made at the given time by the principal in ctx
+func NewRentalTransition(ctx context.Context, rentalID uint, from, to RentalStatus, note string, at time.Time) *RentalTransition {
+	transition := &RentalTransition{RentalID: rentalID, From: from, To: to, Note: note, ChangedAt: at}
+	if p, ok := principal.FromContext(ctx); ok {
+		transition.Actor = p.String()
+	}
+	return transition
+}
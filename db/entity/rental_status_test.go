@@ -0,0 +1,27 @@
+package entity
+
+import "testing"
+
+func TestRentalStatus_CanTransition(t *testing.T) {
+	tests := []struct {
+		from, to RentalStatus
+		want     bool
+	}{
+		{RentalReserved, RentalOut, true},
+		{RentalReserved, RentalReturned, false},
+		{RentalOut, RentalReturned, true},
+		{RentalOut, RentalLost, true},
+		{RentalOut, RentalReserved, false},
+		{RentalLost, RentalReturned, true},
+		{RentalReturned, RentalDamaged, true},
+		{RentalReturned, RentalOut, false},
+		{RentalDamaged, RentalReturned, false},
+		{RentalOut, RentalOut, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanTransition(tt.to); got != tt.want {
+			t.Errorf("%s.CanTransition(%s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// ReservationStatus is the state of a reservation
+type ReservationStatus string
+
+// Reservation states. Only an active reservation holds its copy; checking it out fulfils it.
+const (
+	ReservationActive    ReservationStatus = "active"
+	ReservationCancelled ReservationStatus = "cancelled"
+	ReservationExpired   ReservationStatus = "expired"
+	ReservationFulfilled ReservationStatus = "fulfilled"
+)
+
+// Reservation represents a hold a customer placed on a copy of a film until it expires. Its table has no id
+// column, so it declares the timestamps of gorm.Model instead of embedding it.
+type Reservation struct {
+	Versioned
+	ReservationID uint              `gorm:"primaryKey;column:reservation_id;autoIncrement"`
+	InventoryID   uint              `gorm:"column:inventory_id;not null" validate:"required"`
+	CustomerID    uint              `gorm:"column:customer_id;not null" validate:"required"`
+	Status        ReservationStatus `gorm:"column:status;not null;default:active" validate:"omitempty,oneof=active cancelled expired fulfilled"`
+	ExpiresAt     time.Time         `gorm:"column:expires_at;not null" validate:"required"`
+	RentalID      *uint             `gorm:"column:rental_id"`
+	CreatedAt     time.Time         `gorm:"column:created_at"`
+	UpdatedAt     time.Time         `gorm:"column:updated_at"`
+	DeletedAt     gorm.DeletedAt    `gorm:"column:deleted_at;index"`
+
+	// Relationships
+	Inventory Inventory `gorm:"foreignKey:InventoryID;references:InventoryID" validate:"-"`
+	Customer  Customer  `gorm:"foreignKey:CustomerID;references:CustomerID" validate:"-"`
+	Rental    *Rental   `gorm:"foreignKey:RentalID;references:RentalID" validate:"-"`
+}
+
+// TableName overrides the table name
+func (Reservation) TableName() string {
+	return "reservation"
+}
+
+// BeforeSave defaults a missing status to active
+func (r *Reservation) BeforeSave(tx *gorm.DB) error {
+	if r.Status == "" {
+		r.Status = ReservationActive
+	}
+	return nil
+}
+
+// Holds reports whether the reservation holds its copy at the given time
+func (r *Reservation) Holds(at time.Time) bool {
+	return r.Status == ReservationActive && r.ExpiresAt.After(at)
+}
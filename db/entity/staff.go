@@ -8,24 +8,25 @@ import (
 // Staff represents an employee in the DVD rental system
 type Staff struct {
 	gorm.Model
+	Versioned
 	StaffID    uint      `gorm:"primaryKey;column:staff_id;autoIncrement"`
-	StoreID    uint      `gorm:"column:store_id;not null"`
-	FirstName  string    `gorm:"column:first_name;not null"`
-	LastName   string    `gorm:"column:last_name;not null"`
-	Email      string    `gorm:"column:email;not null;unique"`
-	Username   string    `gorm:"column:username;not null;unique"`
-	Address    string    `gorm:"column:address;not null"`
-	Address2   string    `gorm:"column:address2"`
-	District   string    `gorm:"column:district;not null"`
-	City       string    `gorm:"column:city;not null"`
-	Country    string    `gorm:"column:country;not null"`
-	PostalCode string    `gorm:"column:postal_code;not null"`
-	Phone      string    `gorm:"column:phone;not null"`
+	StoreID    uint      `gorm:"column:store_id;not null" validate:"required"`
+	FirstName  string    `gorm:"column:first_name;not null" validate:"required,max=50"`
+	LastName   string    `gorm:"column:last_name;not null" validate:"required,max=50"`
+	Email      string    `gorm:"column:email;not null;unique" validate:"required,email,max=100"`
+	Username   string    `gorm:"column:username;not null;unique" validate:"required,max=50"`
+	Address    string    `gorm:"column:address;not null" validate:"required,max=100"`
+	Address2   string    `gorm:"column:address2" validate:"max=100"`
+	District   string    `gorm:"column:district;not null" validate:"required,max=50"`
+	City       string    `gorm:"column:city;not null" validate:"required,max=50"`
+	Country    string    `gorm:"column:country;not null" validate:"required,max=50"`
+	PostalCode string    `gorm:"column:postal_code;not null" validate:"required,max=20"`
+	Phone      string    `gorm:"column:phone;not null" validate:"required,phone"`
 	Active     bool      `gorm:"column:active;not null;default:true"`
 	LastUpdate time.Time `gorm:"column:last_update;not null;default:CURRENT_TIMESTAMP"`
 
 	// Relationships
-	Store Store `gorm:"foreignKey:StoreID"`
+	Store Store `gorm:"foreignKey:StoreID;references:StoreID" validate:"-"`
 }
 
 // TableName overrides the table name
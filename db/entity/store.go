@@ -7,15 +7,16 @@ import (
 // Store represents a store in the DVD rental system
 type Store struct {
 	gorm.Model
+	Versioned
 	StoreID    uint   `gorm:"primaryKey;column:store_id;autoIncrement"`
-	StoreName  string `gorm:"column:store_name;not null"`
-	Address    string `gorm:"column:address;not null"`
-	Address2   string `gorm:"column:address2"`
-	District   string `gorm:"column:district;not null"`
-	City       string `gorm:"column:city;not null"`
-	Country    string `gorm:"column:country;not null"`
-	PostalCode string `gorm:"column:postal_code;not null"`
-	Phone      string `gorm:"column:phone;not null"`
+	StoreName  string `gorm:"column:store_name;not null" validate:"required,max=100"`
+	Address    string `gorm:"column:address;not null" validate:"required,max=100"`
+	Address2   string `gorm:"column:address2" validate:"max=100"`
+	District   string `gorm:"column:district;not null" validate:"required,max=50"`
+	City       string `gorm:"column:city;not null" validate:"required,max=50"`
+	Country    string `gorm:"column:country;not null" validate:"required,max=50"`
+	PostalCode string `gorm:"column:postal_code;not null" validate:"required,max=20"`
+	Phone      string `gorm:"column:phone;not null" validate:"required,phone"`
 
 	// Relationships
 }
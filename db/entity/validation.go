@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// minReleaseYear is the year of the earliest surviving motion picture
+const minReleaseYear = 1888
+
+// phonePattern accepts digits with an optional leading '+' and spaces, dots, dashes or parentheses as separators
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ().-]{4,18}[0-9]$`)
+
+var validate = newValidator()
+
+// Validate checks an entity against its validate tags and the custom rules registered below.
+// It returns validator.ValidationErrors with fields named after their gorm columns.
+func Validate(entity any) error {
+	return validate.Struct(entity)
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	v.RegisterTagNameFunc(columnName)
+
+	// phone: a plausible phone number of at most 20 characters, matching the column width
+	_ = v.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		return phonePattern.MatchString(fl.Field().String())
+	})
+
+	// releaseyear: no earlier than the first film and no later than next year
+	_ = v.RegisterValidation("releaseyear", func(fl validator.FieldLevel) bool {
+		year := fl.Field().Int()
+		return year >= minReleaseYear && year <= int64(time.Now().Year()+1)
+	})
+
+	// Money is validated as its number of minor units, e.g. gte=0 for a non-negative amount
+	v.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		return field.Interface().(Money).Minor()
+	}, Money{})
+
+	v.RegisterStructValidation(validateRental, Rental{})
+
+	return v
+}
+
+// validateRental requires a return date, if any, to come after the rental date, and to be set exactly when
+// the status says the copy was returned
+func validateRental(sl validator.StructLevel) {
+	rental := sl.Current().Interface().(Rental)
+	if rental.ReturnDate != nil && !rental.ReturnDate.After(rental.RentalDate) {
+		sl.ReportError(rental.ReturnDate, "return_date", "ReturnDate", "gtfield", "rental_date")
+	}
+	if rental.Status != "" && rental.Status.Returned() != (rental.ReturnDate != nil) {
+		sl.ReportError(rental.ReturnDate, "return_date", "ReturnDate", "status", string(rental.Status))
+	}
+}
+
+// columnName names a field after its gorm column so that validation errors match the schema
+func columnName(field reflect.StructField) string {
+	for _, part := range strings.Split(field.Tag.Get("gorm"), ";") {
+		if name, ok := strings.CutPrefix(part, "column:"); ok {
+			return name
+		}
+	}
+	return field.Name
+}
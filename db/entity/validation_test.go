@@ -0,0 +1,153 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func validCustomer() Customer {
+	return Customer{
+		StoreID:    1,
+		FirstName:  "Mary",
+		LastName:   "Smith",
+		Email:      "mary.smith@dvdrental.com",
+		Address:    "1913 Hanoi Way",
+		District:   "Nagasaki",
+		City:       "Sasebo",
+		Country:    "Japan",
+		PostalCode: "35200",
+		Phone:      "28303384290",
+	}
+}
+
+func failedFields(t *testing.T, err error) map[string]string {
+	t.Helper()
+	fields := map[string]string{}
+	if err == nil {
+		return fields
+	}
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		t.Fatalf("Expected validator.ValidationErrors, got %v", err)
+	}
+	for _, fieldErr := range validationErrors {
+		fields[fieldErr.Field()] = fieldErr.Tag()
+	}
+	return fields
+}
+
+func TestValidate_Customer(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Customer)
+		field  string
+		rule   string
+	}{
+		{name: "valid", modify: func(c *Customer) {}},
+		{name: "international phone", modify: func(c *Customer) { c.Phone = "+1 (555) 123-4567" }},
+		{name: "missing first name", modify: func(c *Customer) { c.FirstName = "" }, field: "first_name", rule: "required"},
+		{name: "invalid email", modify: func(c *Customer) { c.Email = "mary.smith" }, field: "email", rule: "email"},
+		{name: "phone with letters", modify: func(c *Customer) { c.Phone = "555-CALL-NOW" }, field: "phone", rule: "phone"},
+		{name: "phone too long", modify: func(c *Customer) { c.Phone = "123456789012345678901" }, field: "phone", rule: "phone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customer := validCustomer()
+			tt.modify(&customer)
+
+			fields := failedFields(t, Validate(&customer))
+			if tt.field == "" {
+				if len(fields) != 0 {
+					t.Errorf("Expected no errors, got %v", fields)
+				}
+				return
+			}
+			if fields[tt.field] != tt.rule {
+				t.Errorf("Expected %s to fail %s, got %v", tt.field, tt.rule, fields)
+			}
+		})
+	}
+}
+
+func TestValidate_FilmReleaseYear(t *testing.T) {
+	tests := []struct {
+		year  int
+		valid bool
+	}{
+		{year: 2006, valid: true},
+		{year: minReleaseYear, valid: true},
+		{year: time.Now().Year() + 1, valid: true},
+		{year: minReleaseYear - 1, valid: false},
+		{year: time.Now().Year() + 2, valid: false},
+	}
+
+	for _, tt := range tests {
+		film := Film{Title: "Academy Dinosaur", ReleaseYear: int16(tt.year), Length: 86, CategoryID: 1}
+		fields := failedFields(t, Validate(&film))
+		if got := fields["release_year"] == ""; got != tt.valid {
+			t.Errorf("Release year %d: expected valid=%v, got errors %v", tt.year, tt.valid, fields)
+		}
+	}
+}
+
+func TestValidate_RentalReturnDate(t *testing.T) {
+	rentalDate := time.Date(2005, 5, 24, 22, 53, 30, 0, time.UTC)
+	before := rentalDate.Add(-time.Hour)
+	after := rentalDate.Add(72 * time.Hour)
+
+	rental := Rental{RentalDate: rentalDate, InventoryID: 1, CustomerID: 1, StaffID: 1}
+	if err := Validate(&rental); err != nil {
+		t.Errorf("Expected open rental to be valid, got %v", err)
+	}
+
+	rental.ReturnDate = &after
+	if err := Validate(&rental); err != nil {
+		t.Errorf("Expected returned rental to be valid, got %v", err)
+	}
+
+	rental.ReturnDate = &before
+	if fields := failedFields(t, Validate(&rental)); fields["return_date"] != "gtfield" {
+		t.Errorf("Expected return_date to fail gtfield, got %v", fields)
+	}
+}
+
+func TestValidate_RentalStatus(t *testing.T) {
+	rentalDate := time.Date(2005, 5, 24, 22, 53, 30, 0, time.UTC)
+	returnDate := rentalDate.Add(72 * time.Hour)
+
+	rental := Rental{RentalDate: rentalDate, InventoryID: 1, CustomerID: 1, StaffID: 1, Status: RentalLost}
+	if err := Validate(&rental); err != nil {
+		t.Errorf("Expected lost rental without a return date to be valid, got %v", err)
+	}
+
+	rental.ReturnDate = &returnDate
+	if fields := failedFields(t, Validate(&rental)); fields["return_date"] != "status" {
+		t.Errorf("Expected return_date of a lost rental to fail status, got %v", fields)
+	}
+
+	rental.Status = RentalDamaged
+	if err := Validate(&rental); err != nil {
+		t.Errorf("Expected damaged rental with a return date to be valid, got %v", err)
+	}
+
+	rental.Status = "stolen"
+	if fields := failedFields(t, Validate(&rental)); fields["status"] != "oneof" {
+		t.Errorf("Expected status to fail oneof, got %v", fields)
+	}
+}
+
+func TestValidate_PaymentAmount(t *testing.T) {
+	payment := Payment{CustomerID: 1, StaffID: 1, RentalID: 1, Amount: Cents(0), PaymentDate: time.Now()}
+	if err := Validate(&payment); err != nil {
+		t.Errorf("Expected a payment of zero to be valid, got %v", err)
+	}
+
+	payment.Amount = Cents(-1)
+	if fields := failedFields(t, Validate(&payment)); fields["amount"] != "gte" {
+		t.Errorf("Expected a negative amount to fail gte, got %v", fields)
+	}
+}
@@ -0,0 +1,18 @@
+package entity
+
+// Versioned is embedded by entities that are updated with optimistic concurrency control. Version counts
+// the updates of the row: repositories only update a row whose version still matches the entity's and
+// increment it, so concurrent edits of the same row cannot silently overwrite each other.
+type Versioned struct {
+	Version uint `gorm:"column:version;not null;default:1"`
+}
+
+// Versioning returns the version of the entity for repositories to check and increment
+func (v *Versioned) Versioning() *Versioned {
+	return v
+}
+
+// VersionedEntity is an entity embedding Versioned
+type VersionedEntity interface {
+	Versioning() *Versioned
+}
@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// WaitlistStatus is the state of a waitlist entry
+type WaitlistStatus string
+
+// Waitlist states. A waiting entry is fulfilled with a reservation when a copy comes back.
+const (
+	WaitlistWaiting   WaitlistStatus = "waiting"
+	WaitlistFulfilled WaitlistStatus = "fulfilled"
+	WaitlistCancelled WaitlistStatus = "cancelled"
+)
+
+// WaitlistEntry represents a customer waiting for a copy of a film at a store. Entries of a film and store are
+// served first come, first served. Like Reservation it declares the timestamps of gorm.Model instead of
+// embedding it.
+type WaitlistEntry struct {
+	Versioned
+	WaitlistID    uint           `gorm:"primaryKey;column:waitlist_id;autoIncrement"`
+	FilmID        uint           `gorm:"column:film_id;not null" validate:"required"`
+	StoreID       uint           `gorm:"column:store_id;not null" validate:"required"`
+	CustomerID    uint           `gorm:"column:customer_id;not null" validate:"required"`
+	Status        WaitlistStatus `gorm:"column:status;not null;default:waiting" validate:"omitempty,oneof=waiting fulfilled cancelled"`
+	ReservationID *uint          `gorm:"column:reservation_id"`
+	CreatedAt     time.Time      `gorm:"column:created_at"`
+	UpdatedAt     time.Time      `gorm:"column:updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"column:deleted_at;index"`
+
+	// Relationships
+	Film        Film         `gorm:"foreignKey:FilmID;references:FilmID" validate:"-"`
+	Store       Store        `gorm:"foreignKey:StoreID;references:StoreID" validate:"-"`
+	Customer    Customer     `gorm:"foreignKey:CustomerID;references:CustomerID" validate:"-"`
+	Reservation *Reservation `gorm:"foreignKey:ReservationID;references:ReservationID" validate:"-"`
+}
+
+// TableName overrides the table name
+func (WaitlistEntry) TableName() string {
+	return "waitlist"
+}
+
+// BeforeSave defaults a missing status to waiting
+func (w *WaitlistEntry) BeforeSave(tx *gorm.DB) error {
+	if w.Status == "" {
+		w.Status = WaitlistWaiting
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+-- 000003_search.down.sql: Drop search indexes
+
+DROP INDEX IF EXISTS idx_customer_name_fts;
+DROP INDEX IF EXISTS idx_customer_name_trgm;
+DROP INDEX IF EXISTS idx_actor_name_fts;
+DROP INDEX IF EXISTS idx_actor_name_trgm;
+DROP INDEX IF EXISTS idx_film_title_fts;
+DROP INDEX IF EXISTS idx_film_title_trgm;
+
+DROP EXTENSION IF EXISTS pg_trgm;
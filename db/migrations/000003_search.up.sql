@@ -0,0 +1,16 @@
+-- 000003_search.up.sql: Full-text and trigram indexes backing ranked search
+
+-- Trigram similarity (fuzzy matching) and index support for ILIKE
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Film titles
+CREATE INDEX idx_film_title_trgm ON film USING gin (title gin_trgm_ops);
+CREATE INDEX idx_film_title_fts ON film USING gin (to_tsvector('english', title));
+
+-- Actor full names
+CREATE INDEX idx_actor_name_trgm ON actor USING gin ((first_name || ' ' || last_name) gin_trgm_ops);
+CREATE INDEX idx_actor_name_fts ON actor USING gin (to_tsvector('simple', first_name || ' ' || last_name));
+
+-- Customer full names
+CREATE INDEX idx_customer_name_trgm ON customer USING gin ((first_name || ' ' || last_name) gin_trgm_ops);
+CREATE INDEX idx_customer_name_fts ON customer USING gin (to_tsvector('simple', first_name || ' ' || last_name));
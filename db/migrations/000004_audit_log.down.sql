@@ -0,0 +1,3 @@
+-- 000004_audit_log.down.sql: Drop the audit log
+
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,19 @@
+-- 000004_audit_log.up.sql: Audit log of data changes written by the GORM audit callbacks
+
+CREATE TABLE audit_log
+(
+    audit_log_id BIGSERIAL PRIMARY KEY,
+    table_name   VARCHAR(64)  NOT NULL,
+    record_id    VARCHAR(255) NOT NULL,
+    action       VARCHAR(10)  NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    before_data  JSONB,
+    after_data   JSONB,
+    principal    VARCHAR(255) NOT NULL DEFAULT '',
+    changed_at   TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- History of a single row
+CREATE INDEX idx_audit_log_record ON audit_log (table_name, record_id, changed_at);
+
+-- Changes made by a principal
+CREATE INDEX idx_audit_log_principal ON audit_log (principal, changed_at);
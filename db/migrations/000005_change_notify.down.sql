@@ -0,0 +1,6 @@
+-- 000005_change_notify.down.sql: Remove the change notification triggers
+
+DROP TRIGGER IF EXISTS payment_notify_change ON payment;
+DROP TRIGGER IF EXISTS inventory_notify_change ON inventory;
+DROP TRIGGER IF EXISTS rental_notify_change ON rental;
+DROP FUNCTION IF EXISTS notify_dvd_change();
@@ -0,0 +1,46 @@
+-- 000005_change_notify.up.sql: Triggers notifying the dvd_changes channel of changes to rentals, inventory and payments
+
+CREATE OR REPLACE FUNCTION notify_dvd_change() RETURNS trigger AS
+$$
+DECLARE
+    new_row  JSONB := CASE WHEN TG_OP = 'DELETE' THEN NULL ELSE to_jsonb(NEW) END;
+    old_row  JSONB := CASE WHEN TG_OP = 'INSERT' THEN NULL ELSE to_jsonb(OLD) END;
+    row_data JSONB := COALESCE(new_row, old_row);
+    store    INTEGER := (row_data ->> 'store_id')::INTEGER;
+BEGIN
+    -- Rentals change the availability of their copy's store
+    IF TG_TABLE_NAME = 'rental' THEN
+        SELECT i.store_id INTO store FROM inventory i WHERE i.inventory_id = (row_data ->> 'inventory_id')::INTEGER;
+    END IF;
+
+    PERFORM pg_notify('dvd_changes', jsonb_strip_nulls(jsonb_build_object(
+            'table', TG_TABLE_NAME,
+            'op', TG_OP,
+            'rental_id', row_data -> 'rental_id',
+            'inventory_id', row_data -> 'inventory_id',
+            'store_id', store,
+            'payment_id', row_data -> 'payment_id',
+            'old_inventory_id', CASE WHEN TG_OP = 'UPDATE' THEN old_row -> 'inventory_id' END,
+            'old_store_id', CASE WHEN TG_OP = 'UPDATE' AND TG_TABLE_NAME = 'inventory' THEN old_row -> 'store_id' END
+        ))::TEXT);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER rental_notify_change
+    AFTER INSERT OR UPDATE OR DELETE
+    ON rental
+    FOR EACH ROW
+EXECUTE FUNCTION notify_dvd_change();
+
+CREATE TRIGGER inventory_notify_change
+    AFTER INSERT OR UPDATE OR DELETE
+    ON inventory
+    FOR EACH ROW
+EXECUTE FUNCTION notify_dvd_change();
+
+CREATE TRIGGER payment_notify_change
+    AFTER INSERT OR UPDATE OR DELETE
+    ON payment
+    FOR EACH ROW
+EXECUTE FUNCTION notify_dvd_change();
@@ -0,0 +1,11 @@
+-- 000006_version.down.sql: Remove the row versions
+
+ALTER TABLE payment DROP COLUMN IF EXISTS version;
+ALTER TABLE rental DROP COLUMN IF EXISTS version;
+ALTER TABLE inventory DROP COLUMN IF EXISTS version;
+ALTER TABLE actor DROP COLUMN IF EXISTS version;
+ALTER TABLE film DROP COLUMN IF EXISTS version;
+ALTER TABLE category DROP COLUMN IF EXISTS version;
+ALTER TABLE customer DROP COLUMN IF EXISTS version;
+ALTER TABLE staff DROP COLUMN IF EXISTS version;
+ALTER TABLE store DROP COLUMN IF EXISTS version;
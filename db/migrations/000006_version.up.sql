@@ -0,0 +1,12 @@
+-- 000006_version.up.sql: Row versions for optimistic concurrency control. Repository updates only change a row
+-- whose version is still the one they read, and increment it.
+
+ALTER TABLE store ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE staff ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE customer ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE category ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE film ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE actor ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE inventory ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE rental ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE payment ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,32 @@
+-- 000007_soft_delete.down.sql: Remove soft deletion. Soft-deleted rows become live again; restoring the unique
+-- constraints fails if a deleted row duplicates a live one, which has to be purged first.
+
+DROP TRIGGER IF EXISTS payment_forbid_live_delete ON payment;
+DROP TRIGGER IF EXISTS rental_forbid_live_delete ON rental;
+DROP TRIGGER IF EXISTS inventory_forbid_live_delete ON inventory;
+DROP TRIGGER IF EXISTS actor_forbid_live_delete ON actor;
+DROP TRIGGER IF EXISTS film_forbid_live_delete ON film;
+DROP TRIGGER IF EXISTS category_forbid_live_delete ON category;
+DROP TRIGGER IF EXISTS customer_forbid_live_delete ON customer;
+DROP TRIGGER IF EXISTS staff_forbid_live_delete ON staff;
+DROP TRIGGER IF EXISTS store_forbid_live_delete ON store;
+DROP FUNCTION IF EXISTS forbid_live_delete();
+
+DROP INDEX IF EXISTS category_name_key;
+DROP INDEX IF EXISTS customer_email_key;
+DROP INDEX IF EXISTS staff_username_key;
+DROP INDEX IF EXISTS staff_email_key;
+ALTER TABLE category ADD CONSTRAINT category_name_key UNIQUE (name);
+ALTER TABLE customer ADD CONSTRAINT customer_email_key UNIQUE (email);
+ALTER TABLE staff ADD CONSTRAINT staff_username_key UNIQUE (username);
+ALTER TABLE staff ADD CONSTRAINT staff_email_key UNIQUE (email);
+
+ALTER TABLE payment DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE rental DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE inventory DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE actor DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE film DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE category DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE customer DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE staff DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE store DROP COLUMN IF EXISTS deleted_at;
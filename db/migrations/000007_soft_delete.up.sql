@@ -0,0 +1,45 @@
+-- 000007_soft_delete.up.sql: Soft deletion of stores, staff, customers, the catalog, inventory, rentals and payments.
+-- Their rows are deleted by setting deleted_at and can be restored until they are purged. The film_actors links
+-- and the audit log have no deleted_at and are deleted permanently.
+
+ALTER TABLE store ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE staff ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE customer ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE category ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE film ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE actor ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE inventory ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE rental ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE payment ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Unique values only have to be unique among live rows, so a deleted customer's email can be reused
+ALTER TABLE staff DROP CONSTRAINT IF EXISTS staff_email_key;
+ALTER TABLE staff DROP CONSTRAINT IF EXISTS staff_username_key;
+ALTER TABLE customer DROP CONSTRAINT IF EXISTS customer_email_key;
+ALTER TABLE category DROP CONSTRAINT IF EXISTS category_name_key;
+CREATE UNIQUE INDEX staff_email_key ON staff (email) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX staff_username_key ON staff (username) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX customer_email_key ON customer (email) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX category_name_key ON category (name) WHERE deleted_at IS NULL;
+
+-- Rows must be soft-deleted before they are purged
+CREATE OR REPLACE FUNCTION forbid_live_delete() RETURNS trigger AS
+$$
+BEGIN
+    IF OLD.deleted_at IS NULL THEN
+        RAISE EXCEPTION 'rows of % must be soft-deleted before they are purged', TG_TABLE_NAME
+            USING ERRCODE = 'check_violation';
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER store_forbid_live_delete BEFORE DELETE ON store FOR EACH ROW EXECUTE FUNCTION forbid_live_delete();
+CREATE TRIGGER staff_forbid_live_delete BEFORE DELETE ON staff FOR EACH ROW EXECUTE FUNCTION forbid_live_delete();
+CREATE TRIGGER customer_forbid_live_delete BEFORE DELETE ON customer FOR EACH ROW EXECUTE FUNCTION forbid_live_delete();
+CREATE TRIGGER category_forbid_live_delete BEFORE DELETE ON category FOR EACH ROW EXECUTE FUNCTION forbid_live_delete();
+CREATE TRIGGER film_forbid_live_delete BEFORE DELETE ON film FOR EACH ROW EXECUTE FUNCTION forbid_live_delete();
+CREATE TRIGGER actor_forbid_live_delete BEFORE DELETE ON actor FOR EACH ROW EXECUTE FUNCTION forbid_live_delete();
+CREATE TRIGGER inventory_forbid_live_delete BEFORE DELETE ON inventory FOR EACH ROW EXECUTE FUNCTION forbid_live_delete();
+CREATE TRIGGER rental_forbid_live_delete BEFORE DELETE ON rental FOR EACH ROW EXECUTE FUNCTION forbid_live_delete();
+CREATE TRIGGER payment_forbid_live_delete BEFORE DELETE ON payment FOR EACH ROW EXECUTE FUNCTION forbid_live_delete();
@@ -0,0 +1,6 @@
+-- 000008_rental_status.down.sql: Remove rental states. Whether a rental is open is again told by its return
+-- date alone, so lost rentals count as open and the transition history is lost.
+
+DROP TABLE IF EXISTS rental_transition;
+DROP INDEX IF EXISTS idx_rental_status;
+ALTER TABLE rental DROP COLUMN IF EXISTS status;
@@ -0,0 +1,37 @@
+-- 000008_rental_status.up.sql: Rental states and the history of their transitions. Existing rentals are
+-- returned if they have a return date and out otherwise; their history is rebuilt from the rental and return
+-- dates.
+
+ALTER TABLE rental
+    ADD COLUMN status VARCHAR(10) NOT NULL DEFAULT 'out'
+        CHECK (status IN ('reserved', 'out', 'returned', 'lost', 'damaged'));
+
+UPDATE rental SET status = 'returned' WHERE return_date IS NOT NULL;
+
+-- Open rentals are looked up by status to find overdue rentals and available copies
+CREATE INDEX idx_rental_status ON rental (status, inventory_id);
+
+CREATE TABLE rental_transition
+(
+    rental_transition_id BIGSERIAL PRIMARY KEY,
+    rental_id            INTEGER      NOT NULL REFERENCES rental (rental_id) ON DELETE CASCADE,
+    from_status          VARCHAR(10)  NOT NULL DEFAULT ''
+        CHECK (from_status IN ('', 'reserved', 'out', 'returned', 'lost', 'damaged')),
+    to_status            VARCHAR(10)  NOT NULL
+        CHECK (to_status IN ('reserved', 'out', 'returned', 'lost', 'damaged')),
+    actor                VARCHAR(255) NOT NULL DEFAULT '',
+    note                 TEXT         NOT NULL DEFAULT '',
+    changed_at           TIMESTAMPTZ  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- History of a single rental
+CREATE INDEX idx_rental_transition_rental ON rental_transition (rental_id, changed_at);
+
+INSERT INTO rental_transition (rental_id, from_status, to_status, actor, note, changed_at)
+SELECT rental_id, '', 'out', 'migration', '', rental_date
+FROM rental;
+
+INSERT INTO rental_transition (rental_id, from_status, to_status, actor, note, changed_at)
+SELECT rental_id, 'out', 'returned', 'migration', '', return_date
+FROM rental
+WHERE return_date IS NOT NULL;
@@ -0,0 +1,6 @@
+-- 000009_reservation.down.sql: Remove reservations. notify_dvd_change keeps looking up the store of
+-- reservations, which is harmless once no trigger fires for them.
+
+DROP TRIGGER IF EXISTS reservation_notify_change ON reservation;
+DROP TRIGGER IF EXISTS reservation_forbid_live_delete ON reservation;
+DROP TABLE IF EXISTS reservation;
@@ -0,0 +1,60 @@
+-- 000009_reservation.up.sql: Holds customers place on copies. An active reservation that has not expired keeps
+-- its copy out of the available inventory until it is cancelled, expires or is checked out into a rental.
+
+CREATE TABLE reservation
+(
+    reservation_id SERIAL PRIMARY KEY,
+    inventory_id   INT         NOT NULL REFERENCES inventory (inventory_id),
+    customer_id    INT         NOT NULL REFERENCES customer (customer_id),
+    status         VARCHAR(10) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'cancelled', 'expired', 'fulfilled')),
+    expires_at     TIMESTAMPTZ NOT NULL,
+    rental_id      INT REFERENCES rental (rental_id),
+    version        INTEGER     NOT NULL DEFAULT 1,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at     TIMESTAMPTZ,
+    CHECK ((status = 'fulfilled') = (rental_id IS NOT NULL))
+);
+
+-- A copy is held by at most one reservation at a time
+CREATE UNIQUE INDEX reservation_active_inventory_key ON reservation (inventory_id)
+    WHERE status = 'active' AND deleted_at IS NULL;
+
+CREATE INDEX idx_reservation_customer_id ON reservation (customer_id);
+
+CREATE TRIGGER reservation_forbid_live_delete BEFORE DELETE ON reservation FOR EACH ROW EXECUTE FUNCTION forbid_live_delete();
+
+-- Notifications of reservations carry the store of their copy like those of rentals
+CREATE OR REPLACE FUNCTION notify_dvd_change() RETURNS trigger AS
+$$
+DECLARE
+    new_row  JSONB := CASE WHEN TG_OP = 'DELETE' THEN NULL ELSE to_jsonb(NEW) END;
+    old_row  JSONB := CASE WHEN TG_OP = 'INSERT' THEN NULL ELSE to_jsonb(OLD) END;
+    row_data JSONB := COALESCE(new_row, old_row);
+    store    INTEGER := (row_data ->> 'store_id')::INTEGER;
+BEGIN
+    -- Rentals and reservations change the availability of their copy's store
+    IF TG_TABLE_NAME IN ('rental', 'reservation') THEN
+        SELECT i.store_id INTO store FROM inventory i WHERE i.inventory_id = (row_data ->> 'inventory_id')::INTEGER;
+    END IF;
+
+    PERFORM pg_notify('dvd_changes', jsonb_strip_nulls(jsonb_build_object(
+            'table', TG_TABLE_NAME,
+            'op', TG_OP,
+            'rental_id', row_data -> 'rental_id',
+            'inventory_id', row_data -> 'inventory_id',
+            'store_id', store,
+            'payment_id', row_data -> 'payment_id',
+            'old_inventory_id', CASE WHEN TG_OP = 'UPDATE' THEN old_row -> 'inventory_id' END,
+            'old_store_id', CASE WHEN TG_OP = 'UPDATE' AND TG_TABLE_NAME = 'inventory' THEN old_row -> 'store_id' END
+        ))::TEXT);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER reservation_notify_change
+    AFTER INSERT OR UPDATE OR DELETE
+    ON reservation
+    FOR EACH ROW
+EXECUTE FUNCTION notify_dvd_change();
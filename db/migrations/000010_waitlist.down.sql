@@ -0,0 +1,6 @@
+-- 000010_waitlist.down.sql: Remove waitlists. notify_dvd_change keeps reporting the waitlist columns, which are
+-- absent from the other tables.
+
+DROP TRIGGER IF EXISTS waitlist_notify_change ON waitlist;
+DROP TRIGGER IF EXISTS waitlist_forbid_live_delete ON waitlist;
+DROP TABLE IF EXISTS waitlist;
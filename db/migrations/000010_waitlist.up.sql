@@ -0,0 +1,67 @@
+-- 000010_waitlist.up.sql: Customers waiting for a film at a store while no copy of it is available there. When a
+-- copy is returned, the entry waiting longest for its film and store is fulfilled with a reservation of it.
+
+CREATE TABLE waitlist
+(
+    waitlist_id    SERIAL PRIMARY KEY,
+    film_id        INT         NOT NULL REFERENCES film (film_id),
+    store_id       INT         NOT NULL REFERENCES store (store_id),
+    customer_id    INT         NOT NULL REFERENCES customer (customer_id),
+    status         VARCHAR(10) NOT NULL DEFAULT 'waiting'
+        CHECK (status IN ('waiting', 'fulfilled', 'cancelled')),
+    reservation_id INT REFERENCES reservation (reservation_id),
+    version        INTEGER     NOT NULL DEFAULT 1,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at     TIMESTAMPTZ,
+    CHECK ((status = 'fulfilled') = (reservation_id IS NOT NULL))
+);
+
+-- A customer waits at most once for a film at a store
+CREATE UNIQUE INDEX waitlist_waiting_customer_key ON waitlist (film_id, store_id, customer_id)
+    WHERE status = 'waiting' AND deleted_at IS NULL;
+
+-- The queue of a film at a store, first come first
+CREATE INDEX idx_waitlist_queue ON waitlist (film_id, store_id, created_at)
+    WHERE status = 'waiting' AND deleted_at IS NULL;
+
+CREATE INDEX idx_waitlist_customer_id ON waitlist (customer_id);
+
+CREATE TRIGGER waitlist_forbid_live_delete BEFORE DELETE ON waitlist FOR EACH ROW EXECUTE FUNCTION forbid_live_delete();
+
+-- Notifications carry the entry, customer and film of waitlist changes
+CREATE OR REPLACE FUNCTION notify_dvd_change() RETURNS trigger AS
+$$
+DECLARE
+    new_row  JSONB := CASE WHEN TG_OP = 'DELETE' THEN NULL ELSE to_jsonb(NEW) END;
+    old_row  JSONB := CASE WHEN TG_OP = 'INSERT' THEN NULL ELSE to_jsonb(OLD) END;
+    row_data JSONB := COALESCE(new_row, old_row);
+    store    INTEGER := (row_data ->> 'store_id')::INTEGER;
+BEGIN
+    -- Rentals and reservations change the availability of their copy's store
+    IF TG_TABLE_NAME IN ('rental', 'reservation') THEN
+        SELECT i.store_id INTO store FROM inventory i WHERE i.inventory_id = (row_data ->> 'inventory_id')::INTEGER;
+    END IF;
+
+    PERFORM pg_notify('dvd_changes', jsonb_strip_nulls(jsonb_build_object(
+            'table', TG_TABLE_NAME,
+            'op', TG_OP,
+            'rental_id', row_data -> 'rental_id',
+            'inventory_id', row_data -> 'inventory_id',
+            'store_id', store,
+            'payment_id', row_data -> 'payment_id',
+            'waitlist_id', row_data -> 'waitlist_id',
+            'customer_id', CASE WHEN TG_TABLE_NAME = 'waitlist' THEN row_data -> 'customer_id' END,
+            'film_id', CASE WHEN TG_TABLE_NAME = 'waitlist' THEN row_data -> 'film_id' END,
+            'old_inventory_id', CASE WHEN TG_OP = 'UPDATE' THEN old_row -> 'inventory_id' END,
+            'old_store_id', CASE WHEN TG_OP = 'UPDATE' AND TG_TABLE_NAME = 'inventory' THEN old_row -> 'store_id' END
+        ))::TEXT);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER waitlist_notify_change
+    AFTER INSERT OR UPDATE OR DELETE
+    ON waitlist
+    FOR EACH ROW
+EXECUTE FUNCTION notify_dvd_change();
@@ -0,0 +1,8 @@
+-- 000011_payment_kind.down.sql: Back to one payment per rental. Fails while a rental has several payment lines;
+-- merge or remove them first.
+
+DROP INDEX IF EXISTS idx_payment_rental_id;
+
+ALTER TABLE payment DROP COLUMN IF EXISTS kind;
+
+ALTER TABLE payment ADD CONSTRAINT payment_rental_id_key UNIQUE (rental_id);
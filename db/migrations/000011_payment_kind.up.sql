@@ -0,0 +1,9 @@
+-- 000011_payment_kind.up.sql: Several payment lines per rental, e.g. a charge and a late fee, and refunds of
+-- them. Existing payments become charges.
+
+ALTER TABLE payment DROP CONSTRAINT IF EXISTS payment_rental_id_key;
+
+ALTER TABLE payment ADD COLUMN kind VARCHAR(11) NOT NULL DEFAULT 'charge'
+    CHECK (kind IN ('charge', 'late_fee', 'refund', 'replacement'));
+
+CREATE INDEX idx_payment_rental_id ON payment (rental_id);
@@ -0,0 +1,2 @@
+-- 000012_money.down.sql: Back to payment amounts up to 999.99. Fails while a larger amount is stored.
+ALTER TABLE payment ALTER COLUMN amount TYPE NUMERIC(5, 2);
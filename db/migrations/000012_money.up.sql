@@ -0,0 +1,2 @@
+-- 000012_money.up.sql: Payment amounts up to 99,999,999.99 rather than 999.99, still exact to the cent
+ALTER TABLE payment ALTER COLUMN amount TYPE NUMERIC(10, 2);
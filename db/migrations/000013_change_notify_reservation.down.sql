@@ -0,0 +1,31 @@
+-- 000013_change_notify_reservation.down.sql: Notifications of reservations no longer carry the reservation
+
+CREATE OR REPLACE FUNCTION notify_dvd_change() RETURNS trigger AS
+$$
+DECLARE
+    new_row  JSONB := CASE WHEN TG_OP = 'DELETE' THEN NULL ELSE to_jsonb(NEW) END;
+    old_row  JSONB := CASE WHEN TG_OP = 'INSERT' THEN NULL ELSE to_jsonb(OLD) END;
+    row_data JSONB := COALESCE(new_row, old_row);
+    store    INTEGER := (row_data ->> 'store_id')::INTEGER;
+BEGIN
+    -- Rentals and reservations change the availability of their copy's store
+    IF TG_TABLE_NAME IN ('rental', 'reservation') THEN
+        SELECT i.store_id INTO store FROM inventory i WHERE i.inventory_id = (row_data ->> 'inventory_id')::INTEGER;
+    END IF;
+
+    PERFORM pg_notify('dvd_changes', jsonb_strip_nulls(jsonb_build_object(
+            'table', TG_TABLE_NAME,
+            'op', TG_OP,
+            'rental_id', row_data -> 'rental_id',
+            'inventory_id', row_data -> 'inventory_id',
+            'store_id', store,
+            'payment_id', row_data -> 'payment_id',
+            'waitlist_id', row_data -> 'waitlist_id',
+            'customer_id', CASE WHEN TG_TABLE_NAME = 'waitlist' THEN row_data -> 'customer_id' END,
+            'film_id', CASE WHEN TG_TABLE_NAME = 'waitlist' THEN row_data -> 'film_id' END,
+            'old_inventory_id', CASE WHEN TG_OP = 'UPDATE' THEN old_row -> 'inventory_id' END,
+            'old_store_id', CASE WHEN TG_OP = 'UPDATE' AND TG_TABLE_NAME = 'inventory' THEN old_row -> 'store_id' END
+        ))::TEXT);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"CortexMCP/db/entity"
+	"io/fs"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/schema"
+)
+
+var (
+	sqlComment  = regexp.MustCompile(`--[^\n]*`)
+	createTable = regexp.MustCompile(`(?i)CREATE TABLE (\w+)\s*\(`)
+	alterTable  = regexp.MustCompile(`(?is)ALTER TABLE (\w+)\s([^;]*);`)
+	addColumn   = regexp.MustCompile(`(?i)ADD COLUMN (?:IF NOT EXISTS )?(\w+)`)
+	dropColumn  = regexp.MustCompile(`(?i)DROP COLUMN (?:IF EXISTS )?(\w+)`)
+)
+
+// migratedColumns replays the table definitions of the up migrations and returns the columns of each table
+func migratedColumns(t *testing.T) map[string]map[string]bool {
+	files, err := fs.Glob(migrationsFS, "migrations/*.up.sql")
+	if err != nil {
+		t.Fatalf("Failed to list migrations: %v", err)
+	}
+	tables := make(map[string]map[string]bool)
+	for _, file := range files {
+		data, err := migrationsFS.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		sql := sqlComment.ReplaceAllString(string(data), "")
+
+		for _, match := range createTable.FindAllStringSubmatchIndex(sql, -1) {
+			columns := make(map[string]bool)
+			for _, definition := range splitDefinitions(sql[match[1]:]) {
+				name := strings.Fields(definition)[0]
+				switch strings.ToUpper(name) {
+				case "CHECK", "CONSTRAINT", "PRIMARY", "UNIQUE", "FOREIGN", "EXCLUDE":
+				default:
+					columns[name] = true
+				}
+			}
+			tables[sql[match[2]:match[3]]] = columns
+		}
+		for _, match := range alterTable.FindAllStringSubmatch(sql, -1) {
+			for _, column := range addColumn.FindAllStringSubmatch(match[2], -1) {
+				tables[match[1]][column[1]] = true
+			}
+			for _, column := range dropColumn.FindAllStringSubmatch(match[2], -1) {
+				delete(tables[match[1]], column[1])
+			}
+		}
+	}
+	return tables
+}
+
+// splitDefinitions splits the column and constraint definitions of a CREATE TABLE statement, given the text
+// after its opening parenthesis, at the commas outside parentheses
+func splitDefinitions(sql string) []string {
+	var definitions []string
+	depth, start := 0, 0
+	for i, c := range sql {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return append(definitions, strings.TrimSpace(sql[start:i]))
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				definitions = append(definitions, strings.TrimSpace(sql[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return definitions
+}
+
+func TestMigrations_EntityColumns(t *testing.T) {
+	tables := migratedColumns(t)
+
+	// The entities whose tables were created with all the columns GORM maps them to
+	entities := []any{
+		&entity.AuditLog{},
+		&entity.RentalTransition{},
+		&entity.Reservation{},
+		&entity.WaitlistEntry{},
+	}
+	for _, e := range entities {
+		s, err := schema.Parse(e, &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatalf("Failed to parse %T: %v", e, err)
+		}
+		columns, ok := tables[s.Table]
+		if !ok {
+			t.Errorf("No migration creates table %s of %T", s.Table, e)
+			continue
+		}
+		for _, name := range s.DBNames {
+			if !columns[name] {
+				t.Errorf("Table %s has no column %s of %T", s.Table, name, e)
+			}
+		}
+	}
+}
@@ -6,15 +6,25 @@ import (
 	"gorm.io/gorm"
 )
 
-// ActorRepository is an interface for actor operations
+// ActorRepository is an interface for actor operations. Cast edits take the expected version of the actor: they fail
+// with ErrStaleEntity if the actor has changed since and increment its version otherwise.
 type ActorRepository interface {
 	Repository[entity.Actor]
 
 	// FindByName finds actors by first or last name
-	FindByName(ctx context.Context, name string) ([]entity.Actor, error)
+	FindByName(ctx context.Context, name string, opts ...QueryOption) ([]entity.Actor, error)
 
 	// FindByFilm finds actors by film ID
-	FindByFilm(ctx context.Context, filmID uint) ([]entity.Actor, error)
+	FindByFilm(ctx context.Context, filmID uint, opts ...QueryOption) ([]entity.Actor, error)
+
+	// AddFilms adds an actor to the cast of films, skipping films the actor already appears in
+	AddFilms(ctx context.Context, actorID, version uint, filmIDs ...uint) error
+
+	// RemoveFilms removes an actor from the cast of films, ignoring films the actor does not appear in
+	RemoveFilms(ctx context.Context, actorID, version uint, filmIDs ...uint) error
+
+	// ReplaceFilms sets the films an actor appears in to exactly the given films
+	ReplaceFilms(ctx context.Context, actorID, version uint, filmIDs ...uint) error
 }
 
 // ActorRepositoryImpl is an implementation of ActorRepository
@@ -32,20 +42,37 @@ func NewActorRepository(db *gorm.DB) ActorRepository {
 }
 
 // FindByName finds actors by first or last name
-func (r *ActorRepositoryImpl) FindByName(ctx context.Context, name string) ([]entity.Actor, error) {
+func (r *ActorRepositoryImpl) FindByName(ctx context.Context, name string, opts ...QueryOption) ([]entity.Actor, error) {
 	var actors []entity.Actor
-	if err := r.DB.WithContext(ctx).Where("first_name LIKE ? OR last_name LIKE ?", "%"+name+"%", "%"+name+"%").Find(&actors).Error; err != nil {
-		return nil, err
+	firstName, pattern := containsCondition(r.DB, "first_name", name)
+	lastName, _ := containsCondition(r.DB, "last_name", name)
+	if err := r.query(ctx, opts).Where(firstName+" OR "+lastName, pattern, pattern).Find(&actors).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return actors, nil
 }
 
 // FindByFilm finds actors by film ID
-func (r *ActorRepositoryImpl) FindByFilm(ctx context.Context, filmID uint) ([]entity.Actor, error) {
+func (r *ActorRepositoryImpl) FindByFilm(ctx context.Context, filmID uint, opts ...QueryOption) ([]entity.Actor, error) {
 	var actors []entity.Actor
-	if err := r.DB.WithContext(ctx).Joins("JOIN film_actors ON film_actors.actor_id = actor.actor_id").
+	if err := r.query(ctx, opts).Joins("JOIN film_actors ON film_actors.actor_id = actor.actor_id").
 		Where("film_actors.film_id = ?", filmID).Find(&actors).Error; err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return actors, nil
 }
+
+// AddFilms adds an actor to the cast of films, skipping films the actor already appears in
+func (r *ActorRepositoryImpl) AddFilms(ctx context.Context, actorID, version uint, filmIDs ...uint) error {
+	return actorFilms.edit(r.DB.WithContext(ctx), actorID, version, actorFilms.add, filmIDs)
+}
+
+// RemoveFilms removes an actor from the cast of films, ignoring films the actor does not appear in
+func (r *ActorRepositoryImpl) RemoveFilms(ctx context.Context, actorID, version uint, filmIDs ...uint) error {
+	return actorFilms.edit(r.DB.WithContext(ctx), actorID, version, actorFilms.remove, filmIDs)
+}
+
+// ReplaceFilms sets the films an actor appears in to exactly the given films
+func (r *ActorRepositoryImpl) ReplaceFilms(ctx context.Context, actorID, version uint, filmIDs ...uint) error {
+	return actorFilms.edit(r.DB.WithContext(ctx), actorID, version, actorFilms.replace, filmIDs)
+}
@@ -52,6 +52,7 @@ func TestActorRepository_Create(t *testing.T) {
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
 			sqlmock.AnyArg(), // DeletedAt
+			1,                // Version
 			actor.FirstName,  // FirstName
 			actor.LastName,   // LastName
 		).
@@ -181,16 +182,19 @@ func TestActorRepository_Update(t *testing.T) {
 		LastName:  "Doe",
 	}
 	actor.ID = 1
+	actor.Version = 1
 
 	// Expect the UPDATE query
 	mock.ExpectBegin()
-	mock.ExpectExec("UPDATE").
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `actor` SET ")+".* WHERE version = \\?").
 		WithArgs(
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
 			sqlmock.AnyArg(), // DeletedAt
+			2,                // Version
 			actor.FirstName,
 			actor.LastName,
+			1, // expected Version
 			actor.ID,
 			actor.ActorID,
 		).
@@ -286,7 +290,7 @@ func TestActorRepository_FindByName(t *testing.T) {
 	}
 
 	mock.ExpectQuery("SELECT").
-		WithArgs("%John%", "%John%").
+		WithArgs("%john%", "%john%").
 		WillReturnRows(rows)
 
 	actors, err := repo.FindByName(context.Background(), "John")
@@ -393,3 +397,28 @@ func TestActorRepository_FindByID_NotFound(t *testing.T) {
 		t.Errorf("Unfulfilled expectations: %v", err)
 	}
 }
+
+func TestActorRepository_ReplaceFilms(t *testing.T) {
+	_, mock, repo, cleanup := setupActorTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `actor` SET `version`=version + 1,`updated_at`=? WHERE actor_id = ? AND version = ? AND `actor`.`deleted_at` IS NULL")).
+		WithArgs(sqlmock.AnyArg(), 5, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `film_actors` WHERE actor_id = ? AND film_id NOT IN (?,?)")).
+		WithArgs(5, 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `film_actors` (`film_id`,`actor_id`) VALUES (?,?),(?,?) ON DUPLICATE KEY UPDATE `film_id`=`film_id`")).
+		WithArgs(1, 5, 2, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.ReplaceFilms(context.Background(), 5, 3, 1, 2); err != nil {
+		t.Errorf("Error replacing films: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
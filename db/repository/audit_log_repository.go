@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"CortexMCP/db/entity"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditLogRepository is an interface for reading the audit log
+type AuditLogRepository interface {
+	// FindByRecord finds the changes to a row, oldest first
+	FindByRecord(ctx context.Context, table, recordID string) ([]entity.AuditLog, error)
+
+	// FindByPrincipal finds the changes made by a principal, oldest first
+	FindByPrincipal(ctx context.Context, principal string) ([]entity.AuditLog, error)
+
+	// FindByDateRange finds the changes made between start and end, oldest first
+	FindByDateRange(ctx context.Context, start, end time.Time) ([]entity.AuditLog, error)
+}
+
+// AuditLogRepositoryImpl is an implementation of AuditLogRepository
+type AuditLogRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// NewAuditLogRepository creates a new AuditLogRepository
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &AuditLogRepositoryImpl{
+		DB: db,
+	}
+}
+
+// FindByRecord finds the changes to a row, oldest first
+func (r *AuditLogRepositoryImpl) FindByRecord(ctx context.Context, table, recordID string) ([]entity.AuditLog, error) {
+	return r.find(ctx, "table_name = ? AND record_id = ?", table, recordID)
+}
+
+// FindByPrincipal finds the changes made by a principal, oldest first
+func (r *AuditLogRepositoryImpl) FindByPrincipal(ctx context.Context, principal string) ([]entity.AuditLog, error) {
+	return r.find(ctx, "principal = ?", principal)
+}
+
+// FindByDateRange finds the changes made between start and end, oldest first
+func (r *AuditLogRepositoryImpl) FindByDateRange(ctx context.Context, start, end time.Time) ([]entity.AuditLog, error) {
+	return r.find(ctx, "changed_at BETWEEN ? AND ?", start, end)
+}
+
+func (r *AuditLogRepositoryImpl) find(ctx context.Context, query string, args ...any) ([]entity.AuditLog, error) {
+	var entries []entity.AuditLog
+	if err := r.DB.WithContext(ctx).Where(query, args...).Order("changed_at, audit_log_id").Find(&entries).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return entries, nil
+}
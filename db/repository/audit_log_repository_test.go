@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupAuditLogTest(t *testing.T) (sqlmock.Sqlmock, AuditLogRepository) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open gorm database: %v", err)
+	}
+	return mock, NewAuditLogRepository(gormDB)
+}
+
+func TestAuditLogRepository_FindByRecord(t *testing.T) {
+	mock, repo := setupAuditLogTest(t)
+
+	rows := sqlmock.NewRows([]string{"audit_log_id", "table_name", "record_id", "action", "before_data", "after_data", "principal", "changed_at"}).
+		AddRow(1, "customer", "7", "create", nil, `{"email":"mary.smith@dvdrental.com"}`, "staff:2", time.Now()).
+		AddRow(2, "customer", "7", "update", `{"email":"mary.smith@dvdrental.com"}`, `{"email":"mary@dvdrental.com"}`, "client:inspector", time.Now())
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `audit_log` WHERE table_name = ? AND record_id = ? ORDER BY changed_at, audit_log_id")).
+		WithArgs("customer", "7").
+		WillReturnRows(rows)
+
+	entries, err := repo.FindByRecord(context.Background(), "customer", "7")
+	if err != nil {
+		t.Fatalf("Error finding audit history: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Principal != "client:inspector" || string(entries[1].After) != `{"email":"mary@dvdrental.com"}` {
+		t.Errorf("Unexpected audit history: %+v", entries)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestAuditLogRepository_FindByDateRange(t *testing.T) {
+	mock, repo := setupAuditLogTest(t)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `audit_log` WHERE changed_at BETWEEN ? AND ? ORDER BY changed_at, audit_log_id")).
+		WithArgs(start, end).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_log_id"}))
+
+	entries, err := repo.FindByDateRange(context.Background(), start, end)
+	if err != nil {
+		t.Fatalf("Error finding audit entries: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no entries, got %d", len(entries))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
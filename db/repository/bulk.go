@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+)
+
+// defaultBatchSize is the number of rows written per statement when no batch size is given
+const defaultBatchSize = 100
+
+// mergeCallback is the name of the create callback building the SQL Server MERGE of Upsert
+const mergeCallback = "repository:merge_on_conflict"
+
+// RowResult is the outcome of one row of a bulk write
+type RowResult struct {
+	// Index is the position of the row in the input
+	Index int
+
+	// Err is nil when the row was written, a *ValidationError when it was rejected before reaching the database,
+	// or the classified database error otherwise
+	Err error
+}
+
+// BulkError is returned when some rows of a bulk write fail. The rows that succeeded are still written;
+// the per-row results tell which ones failed.
+type BulkError struct {
+	Failed int
+	Total  int
+
+	// Err is the error of the first failed row
+	Err error
+}
+
+// Error implements the error interface
+func (e *BulkError) Error() string {
+	return fmt.Sprintf("%d of %d rows failed: %v", e.Failed, e.Total, e.Err)
+}
+
+// Unwrap exposes the error of the first failed row
+func (e *BulkError) Unwrap() error {
+	return e.Err
+}
+
+// CreateMany validates and creates entities in batches of batchSize rows, each batch in its own transaction.
+// Invalid rows are skipped; when a batch fails its rows are retried one at a time so that only the offending
+// rows fail.
+func (r *BaseRepository[T]) CreateMany(ctx context.Context, entities []*T, batchSize int) ([]RowResult, error) {
+	return r.writeMany(ctx, entities, batchSize, func(tx *gorm.DB, rows any) error {
+		return tx.Create(rows).Error
+	})
+}
+
+// Upsert validates and inserts entities, updating the existing row instead when one with the same conflict key
+// exists. The conflict key names the columns of a unique constraint, e.g. "email". Postgres uses
+// ON CONFLICT, SQL Server a MERGE built by the callback added by Register, and MySQL ON DUPLICATE KEY UPDATE,
+// which ignores the key and uses whichever unique key conflicts. Versioned rows that are updated get their
+// version incremented.
+func (r *BaseRepository[T]) Upsert(ctx context.Context, entities []*T, conflictKey ...string) ([]RowResult, error) {
+	columns, err := r.conflictColumns(conflictKey)
+	if err != nil {
+		return nil, err
+	}
+	if r.DB.Dialector.Name() == "sqlserver" && r.DB.Callback().Create().Get(mergeCallback) == nil {
+		return nil, errors.New("upserts on SQL Server need the callback added by repository.Register")
+	}
+	onConflict, err := r.upsertClause(columns)
+	if err != nil {
+		return nil, err
+	}
+	return r.writeMany(ctx, entities, defaultBatchSize, func(tx *gorm.DB, rows any) error {
+		return tx.Clauses(onConflict).Create(rows).Error
+	})
+}
+
+// upsertClause updates every column of a conflicting row like UpdateAll, except that the version of a
+// versioned row is incremented rather than overwritten
+func (r *BaseRepository[T]) upsertClause(columns []clause.Column) (clause.OnConflict, error) {
+	if _, ok := any(new(T)).(entityVersion); !ok {
+		return clause.OnConflict{Columns: columns, UpdateAll: true}, nil
+	}
+	stmt := &gorm.Statement{DB: r.DB}
+	if err := stmt.Parse(new(T)); err != nil {
+		return clause.OnConflict{}, err
+	}
+
+	var updates []string
+	for _, field := range stmt.Schema.Fields {
+		// The fields UpdateAll skips: primary keys, creation times and database defaults
+		if field.DBName == "" || field.DBName == "version" || field.PrimaryKey || field.AutoCreateTime > 0 ||
+			(field.HasDefaultValue && field.DefaultValueInterface == nil && !strings.EqualFold(field.DefaultValue, "NULL")) {
+			continue
+		}
+		updates = append(updates, field.DBName)
+	}
+	version := clause.Column{Table: clause.CurrentTable, Name: "version"}
+	return clause.OnConflict{
+		Columns:   columns,
+		DoUpdates: append(clause.AssignmentColumns(updates), clause.Assignment{Column: clause.Column{Name: "version"}, Value: gorm.Expr("? + 1", version)}),
+	}, nil
+}
+
+// Register adds the create callback to db that builds the MERGE of Upsert on SQL Server, where GORM's own MERGE
+// only matches rows on their primary key. It does nothing for other dialects.
+func Register(db *gorm.DB) error {
+	err := db.Callback().Create().After("gorm:before_create").Before("gorm:create").Register(mergeCallback, mergeOnConflict)
+	if err != nil {
+		return fmt.Errorf("failed to register repository callbacks: %w", err)
+	}
+	return nil
+}
+
+// DeleteWhere deletes the entities matching the condition and returns how many were deleted.
+// A condition is required; use a condition such as "1 = 1" to delete everything deliberately.
+func (r *BaseRepository[T]) DeleteWhere(ctx context.Context, query any, args ...any) (int64, error) {
+	var entity T
+	result := r.DB.WithContext(ctx).Where(query, args...).Delete(&entity)
+	return result.RowsAffected, translateError(result.Error)
+}
+
+// writeMany writes the valid entities in batches with write, which receives either a slice of rows or a single row
+func (r *BaseRepository[T]) writeMany(ctx context.Context, entities []*T, batchSize int, write func(tx *gorm.DB, rows any) error) ([]RowResult, error) {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	results := make([]RowResult, len(entities))
+	valid := make([]int, 0, len(entities))
+	for i, entity := range entities {
+		results[i] = RowResult{Index: i, Err: validateEntity(entity)}
+		if results[i].Err == nil {
+			valid = append(valid, i)
+		}
+	}
+
+	db := r.DB.WithContext(ctx)
+	for start := 0; start < len(valid); start += batchSize {
+		batch := valid[start:min(start+batchSize, len(valid))]
+		if err := writeBatch(db, entities, batch, results, write); err != nil {
+			return results, translateError(err)
+		}
+	}
+
+	return results, bulkError(results)
+}
+
+// writeBatch writes the rows of entities at the given indexes in one transaction. When the batch insert fails,
+// each row is retried in its own savepoint and its outcome recorded in results. The returned error is only set
+// when the transaction itself fails.
+func writeBatch[T any](db *gorm.DB, entities []*T, batch []int, results []RowResult, write func(tx *gorm.DB, rows any) error) error {
+	rows := make([]*T, len(batch))
+	for i, index := range batch {
+		rows[i] = entities[index]
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			return write(tx, &rows)
+		})
+		if err == nil {
+			return nil
+		}
+
+		for i, index := range batch {
+			results[index].Err = translateError(tx.Transaction(func(tx *gorm.DB) error {
+				return write(tx, rows[i])
+			}))
+		}
+		return nil
+	})
+}
+
+// conflictColumns checks that every column of the conflict key belongs to T
+func (r *BaseRepository[T]) conflictColumns(conflictKey []string) ([]clause.Column, error) {
+	stmt := &gorm.Statement{DB: r.DB}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+
+	if len(conflictKey) == 0 {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "conflict_key", Rule: "required"}}}
+	}
+	columns := make([]clause.Column, len(conflictKey))
+	for i, name := range conflictKey {
+		field := stmt.Schema.LookUpField(name)
+		if field == nil || field.DBName == "" {
+			return nil, &ValidationError{Fields: []FieldError{{Field: "conflict_key", Rule: "column", Param: name}}}
+		}
+		columns[i] = clause.Column{Name: field.DBName}
+	}
+	return columns, nil
+}
+
+// mergeOnConflict is a create callback building a SQL Server MERGE for a statement with an ON CONFLICT clause,
+// matching existing rows on the clause's columns. The driver's own MERGE only matches on the primary key; it runs
+// the statement built here instead, and the hooks and the policy and audit callbacks run around it as for any
+// other create.
+func mergeOnConflict(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || db.Dialector.Name() != "sqlserver" || stmt.Schema == nil || stmt.SQL.Len() > 0 {
+		return
+	}
+	if onConflict, ok := stmt.Clauses["ON CONFLICT"].Expression.(clause.OnConflict); !ok || len(onConflict.Columns) == 0 {
+		return
+	}
+	values := callbacks.ConvertToCreateValues(stmt)
+	if db.Error != nil {
+		return
+	}
+	// Converting the values turns UpdateAll into the assignments of the updated columns
+	onConflict := stmt.Clauses["ON CONFLICT"].Expression.(clause.OnConflict)
+
+	stmt.WriteString("MERGE INTO ")
+	stmt.WriteQuoted(stmt.Table)
+	stmt.WriteString(" WITH (HOLDLOCK) USING (VALUES ")
+	for i, row := range values.Values {
+		if i > 0 {
+			stmt.WriteString(", ")
+		}
+		stmt.WriteByte('(')
+		stmt.AddVar(stmt, row...)
+		stmt.WriteByte(')')
+	}
+	stmt.WriteString(") AS excluded (")
+	writeColumns(stmt, "", values.Columns)
+	stmt.WriteString(") ON ")
+	for i, column := range onConflict.Columns {
+		if i > 0 {
+			stmt.WriteString(" AND ")
+		}
+		stmt.WriteQuoted(clause.Column{Table: stmt.Table, Name: column.Name})
+		stmt.WriteString(" = ")
+		stmt.WriteQuoted(clause.Column{Table: "excluded", Name: column.Name})
+	}
+	if len(onConflict.DoUpdates) > 0 {
+		stmt.WriteString(" WHEN MATCHED THEN UPDATE SET ")
+		onConflict.DoUpdates.Build(stmt)
+	}
+	stmt.WriteString(" WHEN NOT MATCHED THEN INSERT (")
+	writeColumns(stmt, "", values.Columns)
+	stmt.WriteString(") VALUES (")
+	writeColumns(stmt, "excluded", values.Columns)
+	stmt.WriteByte(')')
+
+	// The driver scans the generated columns of the merged rows from the output
+	if fields := stmt.Schema.FieldsWithDefaultDBValue; len(fields) > 0 {
+		stmt.WriteString(" OUTPUT ")
+		for i, field := range fields {
+			if i > 0 {
+				stmt.WriteString(", ")
+			}
+			stmt.WriteString("INSERTED.")
+			stmt.WriteQuoted(field.DBName)
+		}
+	}
+	stmt.WriteByte(';')
+}
+
+// writeColumns writes the comma-separated columns, qualified with table unless it is empty
+func writeColumns(stmt *gorm.Statement, table string, columns []clause.Column) {
+	for i, column := range columns {
+		if i > 0 {
+			stmt.WriteString(", ")
+		}
+		stmt.WriteQuoted(clause.Column{Table: table, Name: column.Name})
+	}
+}
+
+// bulkError summarizes the failed rows of results, or returns nil when every row succeeded
+func bulkError(results []RowResult) error {
+	var bulkErr *BulkError
+	for _, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		if bulkErr == nil {
+			bulkErr = &BulkError{Total: len(results), Err: result.Err}
+		}
+		bulkErr.Failed++
+	}
+	if bulkErr == nil {
+		return nil
+	}
+	return bulkErr
+}
@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"CortexMCP/db/audit"
+	"CortexMCP/db/entity"
+	"CortexMCP/pkg/policy"
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	mysqlDriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlserver"
+	"gorm.io/gorm"
+)
+
+func setupBulkTest(t *testing.T, dialect string) (sqlmock.Sqlmock, *gorm.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	var dialector gorm.Dialector
+	switch dialect {
+	case "postgres":
+		dialector = postgres.New(postgres.Config{Conn: db})
+	case "sqlserver":
+		dialector = sqlserver.New(sqlserver.Config{Conn: db})
+	default:
+		dialector = mysql.New(mysql.Config{
+			Conn:                      db,
+			SkipInitializeWithVersion: true,
+		})
+	}
+
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open gorm database: %v", err)
+	}
+	if err := Register(gormDB); err != nil {
+		t.Fatal(err)
+	}
+	return mock, gormDB
+}
+
+func bulkCustomer(email string) *entity.Customer {
+	return &entity.Customer{
+		StoreID:    1,
+		FirstName:  "Mary",
+		LastName:   "Smith",
+		Email:      email,
+		Address:    "1913 Hanoi Way",
+		District:   "Nagasaki",
+		City:       "Sasebo",
+		Country:    "Japan",
+		PostalCode: "35200",
+		Phone:      "28303384290",
+	}
+}
+
+func TestBaseRepository_CreateMany(t *testing.T) {
+	mock, db := setupBulkTest(t, "mysql")
+	repo := NewInventoryRepository(db)
+
+	items := []*entity.Inventory{
+		{FilmID: 1, StoreID: 1},
+		{FilmID: 2, StoreID: 1},
+		{FilmID: 0, StoreID: 1}, // invalid: skipped without reaching the database
+		{FilmID: 3, StoreID: 2},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `inventory`")).WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `inventory`")).WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	results, err := repo.CreateMany(context.Background(), items, 2)
+
+	var bulkErr *BulkError
+	if !errors.As(err, &bulkErr) || bulkErr.Failed != 1 || bulkErr.Total != 4 {
+		t.Fatalf("Expected one failed row of four, got %v", err)
+	}
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected the bulk error to wrap ErrValidation, got %v", err)
+	}
+	for i, result := range results {
+		if result.Index != i || (result.Err != nil) != (i == 2) {
+			t.Errorf("Unexpected result for row %d: %+v", i, result)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestBaseRepository_CreateMany_RetriesFailedBatchPerRow(t *testing.T) {
+	mock, db := setupBulkTest(t, "mysql")
+	repo := NewCustomerRepository(db)
+
+	duplicate := &mysqlDriver.MySQLError{Number: mysqlDuplicateEntry, Message: "Duplicate entry"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `customer`")).WillReturnError(duplicate)
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `customer`")).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `customer`")).WillReturnError(duplicate)
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	results, err := repo.CreateMany(context.Background(), []*entity.Customer{
+		bulkCustomer("new@dvdrental.com"),
+		bulkCustomer("taken@dvdrental.com"),
+	}, 0)
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("Expected ErrConflict, got %v", err)
+	}
+	if results[0].Err != nil || !errors.Is(results[1].Err, ErrConflict) {
+		t.Errorf("Expected only the second row to conflict, got %+v", results)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestBaseRepository_Upsert(t *testing.T) {
+	tests := []struct {
+		dialect string
+		sql     string
+	}{
+		{dialect: "mysql", sql: "INSERT INTO `customer` .* ON DUPLICATE KEY UPDATE .*`first_name`=VALUES\\(`first_name`\\).*`version`=`customer`.`version` \\+ 1"},
+		{dialect: "postgres", sql: `INSERT INTO "customer" .* ON CONFLICT \("email"\) DO UPDATE SET .*"first_name"="excluded"."first_name".*"version"="customer"."version" \+ 1`},
+		{dialect: "sqlserver", sql: `MERGE INTO "customer" WITH \(HOLDLOCK\) USING \(VALUES .*\) AS excluded .* ON "customer"."email" = "excluded"."email" WHEN MATCHED THEN UPDATE SET .*"first_name"="excluded"."first_name".*"version"="customer"."version" \+ 1 WHEN NOT MATCHED THEN INSERT \(.*\) VALUES \(.*"excluded"."email".*\) OUTPUT INSERTED."id", INSERTED."customer_id".*;$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			mock, db := setupBulkTest(t, tt.dialect)
+			repo := NewCustomerRepository(db)
+
+			mock.ExpectBegin()
+			mock.ExpectExec("SAVE").WillReturnResult(sqlmock.NewResult(0, 0))
+			if tt.dialect == "mysql" {
+				mock.ExpectExec(tt.sql).WillReturnResult(sqlmock.NewResult(1, 1))
+			} else {
+				mock.ExpectQuery(tt.sql).WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id"}).AddRow(1, 1))
+			}
+			mock.ExpectCommit()
+
+			results, err := repo.Upsert(context.Background(), []*entity.Customer{bulkCustomer("mary.smith@dvdrental.com")}, "email")
+			if err != nil {
+				t.Fatalf("Error upserting customer: %v", err)
+			}
+			if len(results) != 1 || results[0].Err != nil {
+				t.Errorf("Unexpected results: %+v", results)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestBaseRepository_Upsert_RecordsNoRentalTransition(t *testing.T) {
+	mock, db := setupBulkTest(t, "postgres")
+	repo := NewRentalRepository(db)
+
+	rental := &entity.Rental{RentalID: 5, RentalDate: time.Now(), InventoryID: 7, CustomerID: 3, StaffID: 1, Status: entity.RentalOut}
+
+	// Only the rental is written: the conflicting row keeps its history
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVE").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO "rental" .* ON CONFLICT \("rental_id"\) DO UPDATE SET`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	if _, err := repo.Upsert(context.Background(), []*entity.Rental{rental}, "rental_id"); err != nil {
+		t.Fatalf("Error upserting rental: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestBaseRepository_Upsert_SQLServerRunsCreateCallbacks(t *testing.T) {
+	mock, db := setupBulkTest(t, "sqlserver")
+	if err := errors.Join(audit.Register(db), policy.Register(db)); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewRentalRepository(db)
+
+	// The status hook runs before the MERGE and the audit callback after it
+	returned := time.Now()
+	rental := &entity.Rental{RentalID: 5, RentalDate: returned.AddDate(0, 0, -3), InventoryID: 7, CustomerID: 3, ReturnDate: &returned, StaffID: 1}
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVE TRANSACTION").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`MERGE INTO "rental" WITH \(HOLDLOCK\) .* ON "rental"."rental_id" = "excluded"."rental_id"`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, 1, rental.RentalDate, 7, 3, returned, 1, entity.RentalReturned, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rental_id"}).AddRow(1, 5))
+	mock.ExpectQuery(`INSERT INTO "audit_log"`).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_log_id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	if _, err := repo.Upsert(context.Background(), []*entity.Rental{rental}, "rental_id"); err != nil {
+		t.Fatalf("Error upserting rental: %v", err)
+	}
+
+	// Store-scoped principals cannot overwrite rows, so nothing is merged
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVE TRANSACTION").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TRANSACTION").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVE TRANSACTION").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TRANSACTION").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	ctx := policy.NewContext(context.Background(), policy.Grant{StoreIDs: []uint{1}})
+	if _, err := repo.Upsert(ctx, []*entity.Rental{rental}, "rental_id"); !errors.Is(err, policy.ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestBaseRepository_Upsert_UnknownConflictKey(t *testing.T) {
+	mock, db := setupBulkTest(t, "mysql")
+	repo := NewCustomerRepository(db)
+
+	_, err := repo.Upsert(context.Background(), []*entity.Customer{bulkCustomer("mary.smith@dvdrental.com")}, "nickname")
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestBaseRepository_DeleteWhere(t *testing.T) {
+	mock, db := setupBulkTest(t, "mysql")
+	repo := NewInventoryRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `inventory` SET `deleted_at`=? WHERE store_id = ? AND `inventory`.`deleted_at` IS NULL")).
+		WithArgs(sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	deleted, err := repo.DeleteWhere(context.Background(), "store_id = ?", 2)
+	if err != nil {
+		t.Fatalf("Error deleting inventory: %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("Expected 3 deleted rows, got %d", deleted)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheBackend stores encoded query results by key. Implementations must be safe for concurrent use and
+// may drop entries at any time; errors of remote backends are reported as misses.
+type CacheBackend interface {
+	// Get returns the value stored at key
+	Get(ctx context.Context, key string) ([]byte, bool)
+
+	// Set stores value at key
+	Set(ctx context.Context, key string, value []byte)
+
+	// DeletePrefix deletes every key starting with prefix
+	DeletePrefix(ctx context.Context, prefix string)
+}
+
+// CacheStats are the hit and miss counts of the cached queries of a table
+type CacheStats struct {
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	Invalidations uint64 `json:"invalidations"`
+}
+
+// Cache caches query results of the caching repositories in a backend and counts hits and misses by table
+type Cache struct {
+	backend CacheBackend
+	scope   func(ctx context.Context) string
+
+	mu    sync.Mutex
+	stats map[string]*CacheStats
+
+	// generation counts invalidations, so that results loaded while a write invalidated them are not stored
+	generation uint64
+}
+
+// NewCache creates a cache over backend. scope, when set, returns the part of the key that separates callers
+// seeing different rows of the same query, e.g. callers restricted to different stores.
+func NewCache(backend CacheBackend, scope func(ctx context.Context) string) *Cache {
+	return &Cache{backend: backend, scope: scope, stats: make(map[string]*CacheStats)}
+}
+
+// Stats returns the cache statistics by table
+func (c *Cache) Stats() map[string]CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := make(map[string]CacheStats, len(c.stats))
+	for table, s := range c.stats {
+		stats[table] = *s
+	}
+	return stats
+}
+
+// count updates the statistics of table
+func (c *Cache) count(table string, update func(s *CacheStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.stats[table]
+	if !ok {
+		s = &CacheStats{}
+		c.stats[table] = s
+	}
+	update(s)
+}
+
+// key builds the key of a query built by idQuery or listQuery. Queries preloading associations are kept
+// apart under withPrefix, as writes to the associated tables change their results too.
+func (c *Cache) key(ctx context.Context, query string, opts []QueryOption) string {
+	var o queryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	preload := make([]string, len(o.preload))
+	for i, name := range o.preload {
+		preload[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+	sort.Strings(preload)
+
+	scope := ""
+	if c.scope != nil {
+		scope = c.scope(ctx)
+	}
+	key := query + scope + ":" + strings.Join(preload, ",")
+	if o.includeDeleted {
+		key += ":deleted"
+	}
+	if len(preload) > 0 {
+		key = withPrefix + key
+	}
+	return key
+}
+
+// withPrefix starts the keys of queries preloading associations
+const withPrefix = "with:"
+
+// Invalidate invalidates every cached result of table, for writes made around the caching repositories
+func (c *Cache) Invalidate(ctx context.Context, table string) {
+	c.invalidate(ctx, table)
+}
+
+// invalidate deletes the cached results of table that a write may have changed: the entities with the given
+// IDs, or every entity when there are none, every list query, and every query preloading associations
+func (c *Cache) invalidate(ctx context.Context, table string, ids ...uint) {
+	if len(ids) == 0 {
+		c.backend.DeletePrefix(ctx, table+":")
+	} else {
+		for _, id := range ids {
+			c.backend.DeletePrefix(ctx, idQuery(table, id))
+		}
+		c.backend.DeletePrefix(ctx, table+":list:")
+	}
+	c.backend.DeletePrefix(ctx, withPrefix)
+	c.count(table, func(s *CacheStats) { s.Invalidations++ })
+	c.mu.Lock()
+	c.generation++
+	c.mu.Unlock()
+}
+
+// currentGeneration returns the number of invalidations so far
+func (c *Cache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// cachedQuery returns the result of a query of table stored at key, or runs load and stores its result.
+// Errors are never cached.
+func cachedQuery[V any](ctx context.Context, c *Cache, table, key string, load func() (V, error)) (V, error) {
+	if data, ok := c.backend.Get(ctx, key); ok {
+		var value V
+		if err := json.Unmarshal(data, &value); err == nil {
+			c.count(table, func(s *CacheStats) { s.Hits++ })
+			return value, nil
+		}
+	}
+	c.count(table, func(s *CacheStats) { s.Misses++ })
+
+	generation := c.currentGeneration()
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	if data, err := json.Marshal(value); err == nil && c.currentGeneration() == generation {
+		c.backend.Set(ctx, key, data)
+	}
+	return value, nil
+}
+
+// idQuery is the query part of the keys of an entity found by ID
+func idQuery(table string, id uint) string {
+	return fmt.Sprintf("%s:id:%d:", table, id)
+}
+
+// listQuery is the query part of the keys of a finder returning a list, e.g. listQuery("film", "title", "matrix")
+func listQuery(table, finder string, args ...any) string {
+	return fmt.Sprintf("%s:list:%s%q:", table, finder, args)
+}
+
+// LRUCache is an in-memory cache backend evicting the least recently used entries beyond its size, and
+// entries older than its TTL
+type LRUCache struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRUCache creates an in-memory cache backend holding at most size entries for ttl each.
+// A zero TTL keeps entries until they are evicted.
+func NewLRUCache(size int, ttl time.Duration) *LRUCache {
+	if size < 1 {
+		size = 1
+	}
+	return &LRUCache{size: size, ttl: ttl, now: time.Now, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Get returns the value stored at key unless it expired
+func (c *LRUCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*lruEntry)
+	if c.ttl > 0 && !c.now().Before(entry.expires) {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set stores value at key, evicting the least recently used entry when the cache is full
+func (c *LRUCache) Set(ctx context.Context, key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := c.now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value, entry.expires = value, expires
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// DeletePrefix deletes every key starting with prefix
+func (c *LRUCache) DeletePrefix(ctx context.Context, prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, element := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(element)
+		}
+	}
+}
+
+// Len returns the number of entries held, including expired entries not yet removed
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRUCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*lruEntry).key)
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"CortexMCP/db/entity"
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestLRUCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	cache := NewLRUCache(2, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.Set(ctx, "a", []byte("1"))
+	cache.Set(ctx, "b", []byte("2"))
+	cache.Get(ctx, "a")
+	cache.Set(ctx, "c", []byte("3"))
+	if _, ok := cache.Get(ctx, "b"); ok {
+		t.Error("Expected the least recently used entry to be evicted")
+	}
+	if value, ok := cache.Get(ctx, "a"); !ok || string(value) != "1" {
+		t.Errorf("Expected a recently used entry to be kept, got %q", value)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := cache.Get(ctx, "c"); ok {
+		t.Error("Expected an entry to expire after its TTL")
+	}
+
+	cache.Set(ctx, "film:1", nil)
+	cache.Set(ctx, "store:1", nil)
+	cache.DeletePrefix(ctx, "film:")
+	if _, ok := cache.Get(ctx, "film:1"); ok || cache.Len() != 1 {
+		t.Errorf("Expected only the store entry to remain, got %d entries", cache.Len())
+	}
+}
+
+func TestCachedFilmRepository(t *testing.T) {
+	_, mock, films, cleanup := setupFilmTest(t)
+	defer cleanup()
+	cache := NewCache(NewLRUCache(100, time.Minute), nil)
+	repo := NewCachedFilmRepository(films, cache)
+	ctx := context.Background()
+
+	columns := []string{"id", "film_id", "title", "release_year", "length", "category_id"}
+	expectFind := func(title string) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `film` WHERE `film`.`id` = ? AND `film`.`deleted_at` IS NULL ORDER BY `film`.`id` LIMIT ?")).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 1, title, 1999, 136, 1))
+	}
+	expectFind("The Matrix")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `film` WHERE LOWER(title) LIKE ? ESCAPE '!' AND `film`.`deleted_at` IS NULL")).
+		WithArgs("%matrix%").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 1, "The Matrix", 1999, 136, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `film`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	expectFind("The Matrix Reloaded")
+
+	for i := 0; i < 2; i++ {
+		film, err := repo.FindByID(ctx, 1)
+		if err != nil || film.Title != "The Matrix" {
+			t.Fatalf("Expected the film, got %+v and %v", film, err)
+		}
+		if found, err := repo.FindByTitle(ctx, "matrix"); err != nil || len(found) != 1 {
+			t.Fatalf("Expected one film by title, got %+v and %v", found, err)
+		}
+	}
+
+	// Writes invalidate the entity and the lists
+	film := &entity.Film{FilmID: 1, Title: "The Matrix Reloaded", ReleaseYear: 2003, Length: 138, CategoryID: 1}
+	film.ID = 1
+	if err := repo.Update(ctx, film); err != nil {
+		t.Fatalf("Error updating film: %v", err)
+	}
+	if found, err := repo.FindByID(ctx, 1); err != nil || found.Title != "The Matrix Reloaded" {
+		t.Errorf("Expected the updated film to be loaded again, got %+v and %v", found, err)
+	}
+
+	stats := cache.Stats()["film"]
+	if stats.Hits != 2 || stats.Misses != 3 || stats.Invalidations != 1 {
+		t.Errorf("Unexpected cache stats: %+v", stats)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCache_Key(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache(NewLRUCache(10, 0), func(ctx context.Context) string { return "stores=1,2" })
+
+	plain := cache.key(ctx, idQuery("film", 1), nil)
+	preloaded := cache.key(ctx, idQuery("film", 1), []QueryOption{With("Actors", "category")})
+	same := cache.key(ctx, idQuery("film", 1), []QueryOption{With("Category"), With("actors")})
+	if plain != "film:id:1:stores=1,2:" {
+		t.Errorf("Unexpected key %q", plain)
+	}
+	if preloaded != withPrefix+"film:id:1:stores=1,2:actors,category" || same != preloaded {
+		t.Errorf("Expected preloads to be normalized into the key, got %q and %q", preloaded, same)
+	}
+	if deleted := cache.key(ctx, idQuery("film", 1), []QueryOption{IncludeDeleted()}); deleted != plain+":deleted" {
+		t.Errorf("Expected queries including deleted films to be kept apart, got %q", deleted)
+	}
+	if got := listQuery("film", "title", "matrix"); got != fmt.Sprintf("film:list:title%q:", []any{"matrix"}) {
+		t.Errorf("Unexpected list query %q", got)
+	}
+}
@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"CortexMCP/db/entity"
+	"context"
+	"reflect"
+	"strings"
+)
+
+// CachedRepository is a Repository caching the results of FindByID and FindAll. Writes through it invalidate
+// the entities they change and every cached list; writes made elsewhere are seen once cached results expire.
+type CachedRepository[T any] struct {
+	repo  Repository[T]
+	cache *Cache
+	table string
+}
+
+// NewCachedRepository creates a Repository caching the results of repo in cache
+func NewCachedRepository[T any](repo Repository[T], cache *Cache) *CachedRepository[T] {
+	return &CachedRepository[T]{repo: repo, cache: cache, table: tableName[T]()}
+}
+
+// Create creates an entity and invalidates the cached lists
+func (r *CachedRepository[T]) Create(ctx context.Context, entity *T) error {
+	err := r.repo.Create(ctx, entity)
+	r.invalidate(ctx, entity)
+	return err
+}
+
+// FindByID finds an entity by its ID, from the cache when possible
+func (r *CachedRepository[T]) FindByID(ctx context.Context, id uint, opts ...QueryOption) (*T, error) {
+	return cachedQuery(ctx, r.cache, r.table, r.cache.key(ctx, idQuery(r.table, id), opts), func() (*T, error) {
+		return r.repo.FindByID(ctx, id, opts...)
+	})
+}
+
+// FindAll returns all entities, from the cache when possible
+func (r *CachedRepository[T]) FindAll(ctx context.Context, opts ...QueryOption) ([]T, error) {
+	return r.list(ctx, listQuery(r.table, "all"), opts, func() ([]T, error) {
+		return r.repo.FindAll(ctx, opts...)
+	})
+}
+
+// Update updates an entity and invalidates it
+func (r *CachedRepository[T]) Update(ctx context.Context, entity *T) error {
+	err := r.repo.Update(ctx, entity)
+	r.invalidate(ctx, entity)
+	return err
+}
+
+// Delete deletes an entity and invalidates it
+func (r *CachedRepository[T]) Delete(ctx context.Context, entity *T) error {
+	err := r.repo.Delete(ctx, entity)
+	r.invalidate(ctx, entity)
+	return err
+}
+
+// DeleteByID deletes an entity by its ID and invalidates it
+func (r *CachedRepository[T]) DeleteByID(ctx context.Context, id uint) error {
+	err := r.repo.DeleteByID(ctx, id)
+	r.cache.invalidate(ctx, r.table, id)
+	return err
+}
+
+// FindDeleted returns the soft-deleted entities, which are never cached
+func (r *CachedRepository[T]) FindDeleted(ctx context.Context, opts ...QueryOption) ([]T, error) {
+	return r.repo.FindDeleted(ctx, opts...)
+}
+
+// Restore undeletes an entity by its ID and invalidates it
+func (r *CachedRepository[T]) Restore(ctx context.Context, id uint) error {
+	err := r.repo.Restore(ctx, id)
+	r.cache.invalidate(ctx, r.table, id)
+	return err
+}
+
+// Purge permanently deletes an entity by its ID and invalidates it
+func (r *CachedRepository[T]) Purge(ctx context.Context, id uint) error {
+	err := r.repo.Purge(ctx, id)
+	r.cache.invalidate(ctx, r.table, id)
+	return err
+}
+
+// CreateMany creates entities and invalidates the cached lists
+func (r *CachedRepository[T]) CreateMany(ctx context.Context, entities []*T, batchSize int) ([]RowResult, error) {
+	results, err := r.repo.CreateMany(ctx, entities, batchSize)
+	r.cache.invalidate(ctx, r.table)
+	return results, err
+}
+
+// Upsert inserts or updates entities and invalidates every cached result of the table
+func (r *CachedRepository[T]) Upsert(ctx context.Context, entities []*T, conflictKey ...string) ([]RowResult, error) {
+	results, err := r.repo.Upsert(ctx, entities, conflictKey...)
+	r.cache.invalidate(ctx, r.table)
+	return results, err
+}
+
+// DeleteWhere deletes the matching entities and invalidates every cached result of the table
+func (r *CachedRepository[T]) DeleteWhere(ctx context.Context, query any, args ...any) (int64, error) {
+	deleted, err := r.repo.DeleteWhere(ctx, query, args...)
+	r.cache.invalidate(ctx, r.table)
+	return deleted, err
+}
+
+// list returns the result of a finder returning a list, from the cache when possible
+func (r *CachedRepository[T]) list(ctx context.Context, query string, opts []QueryOption, load func() ([]T, error)) ([]T, error) {
+	return cachedQuery(ctx, r.cache, r.table, r.cache.key(ctx, query, opts), load)
+}
+
+// invalidate invalidates the cached results an entity written through the repository may have changed.
+// Every cached result of the table is invalidated when the entity carries no ID.
+func (r *CachedRepository[T]) invalidate(ctx context.Context, entity *T) {
+	r.cache.invalidate(ctx, r.table, entityIDs(entity)...)
+}
+
+// CachedFilmRepository is a FilmRepository caching the results of its finders
+type CachedFilmRepository struct {
+	*CachedRepository[entity.Film]
+	films FilmRepository
+}
+
+// NewCachedFilmRepository creates a FilmRepository caching the results of repo in cache
+func NewCachedFilmRepository(repo FilmRepository, cache *Cache) FilmRepository {
+	return &CachedFilmRepository{CachedRepository: NewCachedRepository[entity.Film](repo, cache), films: repo}
+}
+
+// FindByTitle finds films by title, from the cache when possible
+func (r *CachedFilmRepository) FindByTitle(ctx context.Context, title string, opts ...QueryOption) ([]entity.Film, error) {
+	return r.list(ctx, listQuery(r.table, "title", title), opts, func() ([]entity.Film, error) {
+		return r.films.FindByTitle(ctx, title, opts...)
+	})
+}
+
+// FindByCategory finds films by category ID, from the cache when possible
+func (r *CachedFilmRepository) FindByCategory(ctx context.Context, categoryID uint, opts ...QueryOption) ([]entity.Film, error) {
+	return r.list(ctx, listQuery(r.table, "category", categoryID), opts, func() ([]entity.Film, error) {
+		return r.films.FindByCategory(ctx, categoryID, opts...)
+	})
+}
+
+// FindByActor finds films by actor ID, from the cache when possible
+func (r *CachedFilmRepository) FindByActor(ctx context.Context, actorID uint, opts ...QueryOption) ([]entity.Film, error) {
+	return r.list(ctx, listQuery(r.table, "actor", actorID), opts, func() ([]entity.Film, error) {
+		return r.films.FindByActor(ctx, actorID, opts...)
+	})
+}
+
+// FindByReleaseYear finds films by release year, from the cache when possible
+func (r *CachedFilmRepository) FindByReleaseYear(ctx context.Context, year int16, opts ...QueryOption) ([]entity.Film, error) {
+	return r.list(ctx, listQuery(r.table, "year", year), opts, func() ([]entity.Film, error) {
+		return r.films.FindByReleaseYear(ctx, year, opts...)
+	})
+}
+
+// AddActors adds actors to the cast of a film and invalidates the film
+func (r *CachedFilmRepository) AddActors(ctx context.Context, filmID, version uint, actorIDs ...uint) error {
+	err := r.films.AddActors(ctx, filmID, version, actorIDs...)
+	r.cache.invalidate(ctx, r.table, filmID)
+	return err
+}
+
+// RemoveActors removes actors from the cast of a film and invalidates the film
+func (r *CachedFilmRepository) RemoveActors(ctx context.Context, filmID, version uint, actorIDs ...uint) error {
+	err := r.films.RemoveActors(ctx, filmID, version, actorIDs...)
+	r.cache.invalidate(ctx, r.table, filmID)
+	return err
+}
+
+// ReplaceCast sets the cast of a film and invalidates the film
+func (r *CachedFilmRepository) ReplaceCast(ctx context.Context, filmID, version uint, actorIDs ...uint) error {
+	err := r.films.ReplaceCast(ctx, filmID, version, actorIDs...)
+	r.cache.invalidate(ctx, r.table, filmID)
+	return err
+}
+
+// CachedActorRepository is an ActorRepository invalidating the cached films whose cast it edits. Actors
+// themselves are not cached.
+type CachedActorRepository struct {
+	ActorRepository
+	cache *Cache
+}
+
+// NewCachedActorRepository creates an ActorRepository keeping the films cached in cache consistent with the
+// casts edited through repo
+func NewCachedActorRepository(repo ActorRepository, cache *Cache) ActorRepository {
+	return &CachedActorRepository{ActorRepository: repo, cache: cache}
+}
+
+// AddFilms adds an actor to the cast of films and invalidates the films
+func (r *CachedActorRepository) AddFilms(ctx context.Context, actorID, version uint, filmIDs ...uint) error {
+	err := r.ActorRepository.AddFilms(ctx, actorID, version, filmIDs...)
+	r.invalidateFilms(ctx, filmIDs)
+	return err
+}
+
+// RemoveFilms removes an actor from the cast of films and invalidates the films
+func (r *CachedActorRepository) RemoveFilms(ctx context.Context, actorID, version uint, filmIDs ...uint) error {
+	err := r.ActorRepository.RemoveFilms(ctx, actorID, version, filmIDs...)
+	r.invalidateFilms(ctx, filmIDs)
+	return err
+}
+
+// ReplaceFilms sets the films of an actor and invalidates every cached film, since the films the actor
+// leaves are not known
+func (r *CachedActorRepository) ReplaceFilms(ctx context.Context, actorID, version uint, filmIDs ...uint) error {
+	err := r.ActorRepository.ReplaceFilms(ctx, actorID, version, filmIDs...)
+	r.cache.invalidate(ctx, tableName[entity.Film]())
+	return err
+}
+
+// invalidateFilms invalidates the given films, and every cached list of films such as those by actor
+func (r *CachedActorRepository) invalidateFilms(ctx context.Context, filmIDs []uint) {
+	if len(filmIDs) > 0 {
+		r.cache.invalidate(ctx, tableName[entity.Film](), filmIDs...)
+	}
+}
+
+// CachedCategoryRepository is a CategoryRepository caching the results of its finders
+type CachedCategoryRepository struct {
+	*CachedRepository[entity.Category]
+	categories CategoryRepository
+}
+
+// NewCachedCategoryRepository creates a CategoryRepository caching the results of repo in cache
+func NewCachedCategoryRepository(repo CategoryRepository, cache *Cache) CategoryRepository {
+	return &CachedCategoryRepository{CachedRepository: NewCachedRepository[entity.Category](repo, cache), categories: repo}
+}
+
+// FindByName finds categories by name, from the cache when possible
+func (r *CachedCategoryRepository) FindByName(ctx context.Context, name string, opts ...QueryOption) ([]entity.Category, error) {
+	return r.list(ctx, listQuery(r.table, "name", name), opts, func() ([]entity.Category, error) {
+		return r.categories.FindByName(ctx, name, opts...)
+	})
+}
+
+// CachedStoreRepository is a StoreRepository caching the results of its finders
+type CachedStoreRepository struct {
+	*CachedRepository[entity.Store]
+	stores StoreRepository
+}
+
+// NewCachedStoreRepository creates a StoreRepository caching the results of repo in cache
+func NewCachedStoreRepository(repo StoreRepository, cache *Cache) StoreRepository {
+	return &CachedStoreRepository{CachedRepository: NewCachedRepository[entity.Store](repo, cache), stores: repo}
+}
+
+// FindByName finds stores by name, from the cache when possible
+func (r *CachedStoreRepository) FindByName(ctx context.Context, name string, opts ...QueryOption) ([]entity.Store, error) {
+	return r.list(ctx, listQuery(r.table, "name", name), opts, func() ([]entity.Store, error) {
+		return r.stores.FindByName(ctx, name, opts...)
+	})
+}
+
+// FindByCity finds stores by city, from the cache when possible
+func (r *CachedStoreRepository) FindByCity(ctx context.Context, city string, opts ...QueryOption) ([]entity.Store, error) {
+	return r.list(ctx, listQuery(r.table, "city", city), opts, func() ([]entity.Store, error) {
+		return r.stores.FindByCity(ctx, city, opts...)
+	})
+}
+
+// FindByCountry finds stores by country, from the cache when possible
+func (r *CachedStoreRepository) FindByCountry(ctx context.Context, country string, opts ...QueryOption) ([]entity.Store, error) {
+	return r.list(ctx, listQuery(r.table, "country", country), opts, func() ([]entity.Store, error) {
+		return r.stores.FindByCountry(ctx, country, opts...)
+	})
+}
+
+// tableName returns the table of entities of type T
+func tableName[T any]() string {
+	var model T
+	if tabler, ok := any(&model).(interface{ TableName() string }); ok {
+		return tabler.TableName()
+	}
+	return strings.ToLower(reflect.TypeOf(model).Name())
+}
+
+// entityIDs returns the non-zero IDs an entity may be found by: the gorm.Model ID and the entity's own
+// primary key
+func entityIDs[T any](entity *T) []uint {
+	value := reflect.Indirect(reflect.ValueOf(entity))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	var ids []uint
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.Anonymous && field.Name == "Model" {
+			if id := value.Field(i).FieldByName("ID"); id.CanUint() && id.Uint() != 0 {
+				ids = append(ids, uint(id.Uint()))
+			}
+			continue
+		}
+		if strings.Contains(field.Tag.Get("gorm"), "primaryKey") && value.Field(i).CanUint() && value.Field(i).Uint() != 0 {
+			ids = append(ids, uint(value.Field(i).Uint()))
+		}
+	}
+	return ids
+}
@@ -11,7 +11,7 @@ type CategoryRepository interface {
 	Repository[entity.Category]
 
 	// FindByName finds categories by name
-	FindByName(ctx context.Context, name string) ([]entity.Category, error)
+	FindByName(ctx context.Context, name string, opts ...QueryOption) ([]entity.Category, error)
 }
 
 // CategoryRepositoryImpl is an implementation of CategoryRepository
@@ -29,10 +29,11 @@ func NewCategoryRepository(db *gorm.DB) CategoryRepository {
 }
 
 // FindByName finds categories by name
-func (r *CategoryRepositoryImpl) FindByName(ctx context.Context, name string) ([]entity.Category, error) {
+func (r *CategoryRepositoryImpl) FindByName(ctx context.Context, name string, opts ...QueryOption) ([]entity.Category, error) {
 	var categories []entity.Category
-	if err := r.DB.WithContext(ctx).Where("name LIKE ?", "%"+name+"%").Find(&categories).Error; err != nil {
-		return nil, err
+	condition, pattern := containsCondition(r.DB, "name", name)
+	if err := r.query(ctx, opts).Where(condition, pattern).Find(&categories).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return categories, nil
 }
@@ -51,6 +51,7 @@ func TestCategoryRepository_Create(t *testing.T) {
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
 			sqlmock.AnyArg(), // DeletedAt
+			1,                // Version
 			category.Name,    // Name
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
@@ -168,15 +169,18 @@ func TestCategoryRepository_Update(t *testing.T) {
 		Name:       "Action",
 	}
 	category.ID = 1
+	category.Version = 1
 
 	// Expect the UPDATE query
 	mock.ExpectBegin()
-	mock.ExpectExec("UPDATE").
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `category` SET ")+".* WHERE version = \\?").
 		WithArgs(
 			sqlmock.AnyArg(),    // CreatedAt
 			sqlmock.AnyArg(),    // UpdatedAt
 			sqlmock.AnyArg(),    // DeletedAt
+			2,                   // Version
 			category.Name,       // Name
+			1,                   // expected Version
 			category.ID,         // ID
 			category.CategoryID, // CategoryID
 		).
@@ -270,7 +274,7 @@ func TestCategoryRepository_FindByName(t *testing.T) {
 	}
 
 	mock.ExpectQuery("SELECT").
-		WithArgs("%Action%").
+		WithArgs("%action%").
 		WillReturnRows(rows)
 
 	categories, err := repo.FindByName(context.Background(), "Action")
@@ -11,19 +11,19 @@ type CustomerRepository interface {
 	Repository[entity.Customer]
 
 	// FindByName finds customers by first or last name
-	FindByName(ctx context.Context, name string) ([]entity.Customer, error)
+	FindByName(ctx context.Context, name string, opts ...QueryOption) ([]entity.Customer, error)
 
 	// FindByEmail finds a customer by email
-	FindByEmail(ctx context.Context, email string) (*entity.Customer, error)
+	FindByEmail(ctx context.Context, email string, opts ...QueryOption) (*entity.Customer, error)
 
 	// FindByStore finds customers by store ID
-	FindByStore(ctx context.Context, storeID uint) ([]entity.Customer, error)
+	FindByStore(ctx context.Context, storeID uint, opts ...QueryOption) ([]entity.Customer, error)
 
 	// FindActive finds active customers
-	FindActive(ctx context.Context) ([]entity.Customer, error)
+	FindActive(ctx context.Context, opts ...QueryOption) ([]entity.Customer, error)
 
 	// FindInactive finds inactive customers
-	FindInactive(ctx context.Context) ([]entity.Customer, error)
+	FindInactive(ctx context.Context, opts ...QueryOption) ([]entity.Customer, error)
 }
 
 // CustomerRepositoryImpl is an implementation of CustomerRepository
@@ -35,52 +35,55 @@ type CustomerRepositoryImpl struct {
 func NewCustomerRepository(db *gorm.DB) CustomerRepository {
 	return &CustomerRepositoryImpl{
 		BaseRepository: BaseRepository[entity.Customer]{
-			DB: db,
+			DB:           db,
+			Associations: []string{"Store"},
 		},
 	}
 }
 
 // FindByName finds customers by first or last name
-func (r *CustomerRepositoryImpl) FindByName(ctx context.Context, name string) ([]entity.Customer, error) {
+func (r *CustomerRepositoryImpl) FindByName(ctx context.Context, name string, opts ...QueryOption) ([]entity.Customer, error) {
 	var customers []entity.Customer
-	if err := r.DB.WithContext(ctx).Where("first_name LIKE ? OR last_name LIKE ?", "%"+name+"%", "%"+name+"%").Find(&customers).Error; err != nil {
-		return nil, err
+	firstName, pattern := containsCondition(r.DB, "first_name", name)
+	lastName, _ := containsCondition(r.DB, "last_name", name)
+	if err := r.query(ctx, opts).Where(firstName+" OR "+lastName, pattern, pattern).Find(&customers).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return customers, nil
 }
 
 // FindByEmail finds a customer by email
-func (r *CustomerRepositoryImpl) FindByEmail(ctx context.Context, email string) (*entity.Customer, error) {
+func (r *CustomerRepositoryImpl) FindByEmail(ctx context.Context, email string, opts ...QueryOption) (*entity.Customer, error) {
 	var customer entity.Customer
-	if err := r.DB.WithContext(ctx).Where("email = ?", email).First(&customer).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("email = ?", email).First(&customer).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return &customer, nil
 }
 
 // FindByStore finds customers by store ID
-func (r *CustomerRepositoryImpl) FindByStore(ctx context.Context, storeID uint) ([]entity.Customer, error) {
+func (r *CustomerRepositoryImpl) FindByStore(ctx context.Context, storeID uint, opts ...QueryOption) ([]entity.Customer, error) {
 	var customers []entity.Customer
-	if err := r.DB.WithContext(ctx).Where("store_id = ?", storeID).Find(&customers).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("store_id = ?", storeID).Find(&customers).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return customers, nil
 }
 
 // FindActive finds active customers
-func (r *CustomerRepositoryImpl) FindActive(ctx context.Context) ([]entity.Customer, error) {
+func (r *CustomerRepositoryImpl) FindActive(ctx context.Context, opts ...QueryOption) ([]entity.Customer, error) {
 	var customers []entity.Customer
-	if err := r.DB.WithContext(ctx).Where("active = ?", true).Find(&customers).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("active = ?", true).Find(&customers).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return customers, nil
 }
 
 // FindInactive finds inactive customers
-func (r *CustomerRepositoryImpl) FindInactive(ctx context.Context) ([]entity.Customer, error) {
+func (r *CustomerRepositoryImpl) FindInactive(ctx context.Context, opts ...QueryOption) ([]entity.Customer, error) {
 	var customers []entity.Customer
-	if err := r.DB.WithContext(ctx).Where("active = ?", false).Find(&customers).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("active = ?", false).Find(&customers).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return customers, nil
 }
@@ -63,6 +63,7 @@ func TestCustomerRepository_Create(t *testing.T) {
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
 			sqlmock.AnyArg(), // DeletedAt
+			1,                // Version
 			customer.StoreID,
 			customer.FirstName,
 			customer.LastName,
@@ -274,14 +275,16 @@ func TestCustomerRepository_Update(t *testing.T) {
 		CreateDate: time.Now(),
 	}
 	customer.ID = 1
+	customer.Version = 1
 
 	// Expect the UPDATE query
 	mock.ExpectBegin()
-	mock.ExpectExec("UPDATE").
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `customer` SET ")+".* WHERE version = \\?").
 		WithArgs(
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
 			sqlmock.AnyArg(), // DeletedAt
+			2,                // Version
 			customer.StoreID,
 			customer.FirstName,
 			customer.LastName,
@@ -295,6 +298,7 @@ func TestCustomerRepository_Update(t *testing.T) {
 			customer.Phone,
 			customer.Active,
 			customer.CreateDate,
+			1, // expected Version
 			customer.ID,
 			customer.CustomerID,
 		).
@@ -412,8 +416,8 @@ func TestCustomerRepository_FindByName(t *testing.T) {
 		)
 	}
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer` WHERE (first_name LIKE ? OR last_name LIKE ?) AND `customer`.`deleted_at` IS NULL")).
-		WithArgs("%John%", "%John%").
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer` WHERE (LOWER(first_name) LIKE ? ESCAPE '!' OR LOWER(last_name) LIKE ? ESCAPE '!') AND `customer`.`deleted_at` IS NULL")).
+		WithArgs("%john%", "%john%").
 		WillReturnRows(rows)
 
 	customers, err := repo.FindByName(context.Background(), "John")
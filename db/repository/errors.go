@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"CortexMCP/db/entity"
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	mssql "github.com/microsoft/go-mssqldb"
+	"gorm.io/gorm"
+)
+
+// Domain errors returned by repositories. Use errors.Is to test for them;
+// the original GORM or driver error stays reachable through errors.As.
+// Database errors are classified into the first four; ErrStaleEntity and
+// ErrUnavailable are returned by the repository operations that detect them.
+var (
+	// ErrNotFound is returned when no record matches
+	ErrNotFound = errors.New("record not found")
+
+	// ErrConflict is returned when a unique constraint is violated
+	ErrConflict = errors.New("record conflicts with an existing record")
+
+	// ErrReferenced is returned when a foreign key constraint is violated, either because the record
+	// references a missing record or because other records still reference it
+	ErrReferenced = errors.New("record references a missing record or is still referenced")
+
+	// ErrValidation is returned when a record is rejected as malformed
+	ErrValidation = errors.New("validation failed")
+
+	// ErrStaleEntity is returned when a versioned record was changed by someone else since it was read
+	ErrStaleEntity = errors.New("record was changed since it was read")
+
+	// ErrUnavailable is returned when a copy is already rented or held, or a hold on it has expired
+	ErrUnavailable = errors.New("copy is not available")
+)
+
+// Error is a database error classified into one of the domain errors
+type Error struct {
+	// Kind is one of ErrNotFound, ErrConflict, ErrReferenced or ErrValidation. ErrStaleEntity and
+	// ErrUnavailable are not classified from database errors and are returned as they are.
+	Kind error
+
+	// Err is the underlying GORM or driver error
+	Err error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+// Unwrap exposes both the domain error and the underlying error to errors.Is and errors.As
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// FieldError describes a field that failed validation
+type FieldError struct {
+	// Field is the column name of the field
+	Field string `json:"field"`
+
+	// Rule is the validation rule that failed, e.g. "required" or "email"
+	Rule string `json:"rule"`
+
+	// Param is the parameter of the rule, e.g. "100" for "max=100"
+	Param string `json:"param,omitempty"`
+}
+
+// ValidationError is returned when an entity fails validation before it is persisted
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		parts[i] = field.Field + " failed " + field.Rule
+	}
+	return ErrValidation.Error() + ": " + strings.Join(parts, ", ")
+}
+
+// Is reports ValidationError as ErrValidation
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// validateEntity validates entity with entity.Validate and converts failures into a *ValidationError
+func validateEntity(e any) error {
+	err := entity.Validate(e)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+	fields := make([]FieldError, len(validationErrors))
+	for i, fieldErr := range validationErrors {
+		fields[i] = FieldError{Field: fieldErr.Field(), Rule: fieldErr.Tag(), Param: fieldErr.Param()}
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// Postgres SQLSTATE codes
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgNotNullViolation    = "23502"
+	pgCheckViolation      = "23514"
+	pgStringTooLong       = "22001"
+	pgInvalidText         = "22P02"
+)
+
+// MySQL error numbers
+const (
+	mysqlDuplicateEntry   = 1062
+	mysqlRowIsReferenced  = 1451
+	mysqlNoReferencedRow  = 1452
+	mysqlRowIsReferenced2 = 1217
+	mysqlNoReferencedRow2 = 1216
+	mysqlBadNull          = 1048
+	mysqlDataTooLong      = 1406
+	mysqlCheckViolated    = 3819
+)
+
+// MSSQL error numbers
+const (
+	mssqlUniqueConstraint = 2627
+	mssqlUniqueIndex      = 2601
+	mssqlConstraint       = 547
+	mssqlNotNull          = 515
+	mssqlTruncated        = 8152
+	mssqlTruncated2       = 2628
+)
+
+// translateError classifies err into a domain error. Errors that match no domain error are returned unchanged.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var classified *Error
+	if errors.As(err, &classified) {
+		return err
+	}
+
+	if kind := classify(err); kind != nil {
+		return &Error{Kind: kind, Err: err}
+	}
+	return err
+}
+
+func classify(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrConflict
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return ErrReferenced
+	case errors.Is(err, gorm.ErrCheckConstraintViolated):
+		return ErrValidation
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return ErrConflict
+		case pgForeignKeyViolation:
+			return ErrReferenced
+		case pgNotNullViolation, pgCheckViolation, pgStringTooLong, pgInvalidText:
+			return ErrValidation
+		}
+		return nil
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlDuplicateEntry:
+			return ErrConflict
+		case mysqlRowIsReferenced, mysqlNoReferencedRow, mysqlRowIsReferenced2, mysqlNoReferencedRow2:
+			return ErrReferenced
+		case mysqlBadNull, mysqlDataTooLong, mysqlCheckViolated:
+			return ErrValidation
+		}
+		return nil
+	}
+
+	var mssqlErr mssql.Error
+	if errors.As(err, &mssqlErr) {
+		switch mssqlErr.Number {
+		case mssqlUniqueConstraint, mssqlUniqueIndex:
+			return ErrConflict
+		case mssqlConstraint:
+			// 547 covers both foreign key and check constraint violations
+			if strings.Contains(mssqlErr.Message, "FOREIGN KEY") || strings.Contains(mssqlErr.Message, "REFERENCE") {
+				return ErrReferenced
+			}
+			return ErrValidation
+		case mssqlNotNull, mssqlTruncated, mssqlTruncated2:
+			return ErrValidation
+		}
+	}
+	return nil
+}
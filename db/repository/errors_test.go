@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"CortexMCP/db/entity"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	mssql "github.com/microsoft/go-mssqldb"
+	"gorm.io/gorm"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "gorm not found", err: gorm.ErrRecordNotFound, want: ErrNotFound},
+		{name: "gorm duplicated key", err: gorm.ErrDuplicatedKey, want: ErrConflict},
+		{name: "postgres unique", err: &pgconn.PgError{Code: "23505"}, want: ErrConflict},
+		{name: "postgres foreign key", err: &pgconn.PgError{Code: "23503"}, want: ErrReferenced},
+		{name: "postgres not null", err: &pgconn.PgError{Code: "23502"}, want: ErrValidation},
+		{name: "mysql duplicate entry", err: &mysql.MySQLError{Number: 1062}, want: ErrConflict},
+		{name: "mysql row is referenced", err: &mysql.MySQLError{Number: 1451}, want: ErrReferenced},
+		{name: "mysql data too long", err: &mysql.MySQLError{Number: 1406}, want: ErrValidation},
+		{name: "mssql unique", err: mssql.Error{Number: 2627}, want: ErrConflict},
+		{name: "mssql foreign key", err: mssql.Error{Number: 547, Message: `The DELETE statement conflicted with the REFERENCE constraint "FK_rental_customer".`}, want: ErrReferenced},
+		{name: "mssql check", err: mssql.Error{Number: 547, Message: `The INSERT statement conflicted with the CHECK constraint "CK_amount".`}, want: ErrValidation},
+		{name: "wrapped driver error", err: fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505"}), want: ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := translateError(tt.err)
+			if !errors.Is(got, tt.want) {
+				t.Errorf("translateError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+			var classified *Error
+			if !errors.As(got, &classified) || !reflect.DeepEqual(classified.Err, tt.err) {
+				t.Errorf("Expected the original error to remain reachable, got %v", got)
+			}
+		})
+	}
+}
+
+func TestTranslateError_Unclassified(t *testing.T) {
+	if translateError(nil) != nil {
+		t.Error("Expected nil for nil error")
+	}
+
+	original := errors.New("connection refused")
+	if got := translateError(original); got != original {
+		t.Errorf("Expected unclassified error to be returned unchanged, got %v", got)
+	}
+
+	if got := translateError(&pgconn.PgError{Code: "57014"}); errors.Is(got, ErrValidation) || errors.Is(got, ErrConflict) {
+		t.Errorf("Expected query_canceled to stay unclassified, got %v", got)
+	}
+
+	once := translateError(gorm.ErrRecordNotFound)
+	if twice := translateError(once); twice != once {
+		t.Errorf("Expected classified errors to be returned unchanged, got %v", twice)
+	}
+}
+
+func TestCustomerRepository_FindByEmail_NotFound(t *testing.T) {
+	_, mock, repo, cleanup := setupCustomerTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer` WHERE email = ?")).
+		WithArgs("nobody@example.com", 1).
+		WillReturnError(gorm.ErrRecordNotFound)
+
+	_, err := repo.FindByEmail(context.Background(), "nobody@example.com")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCustomerRepository_Create_Invalid(t *testing.T) {
+	_, mock, repo, cleanup := setupCustomerTest(t)
+	defer cleanup()
+
+	customer := &entity.Customer{StoreID: 1, FirstName: "John", Email: "not-an-email", Phone: "call me"}
+
+	err := repo.Create(context.Background(), customer)
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("Expected ErrValidation, got %v", err)
+	}
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected *ValidationError, got %T", err)
+	}
+	rules := map[string]string{}
+	for _, field := range validationErr.Fields {
+		rules[field.Field] = field.Rule
+	}
+	for field, rule := range map[string]string{"last_name": "required", "email": "email", "phone": "phone"} {
+		if rules[field] != rule {
+			t.Errorf("Expected %s to fail %s, got %v", field, rule, rules)
+		}
+	}
+
+	// No statement may reach the database
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
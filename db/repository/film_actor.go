@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"CortexMCP/db/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// filmActor is a row of the film_actors join table linking films to their cast
+type filmActor struct {
+	FilmID  uint `gorm:"column:film_id;primaryKey"`
+	ActorID uint `gorm:"column:actor_id;primaryKey"`
+}
+
+// TableName overrides the table name
+func (filmActor) TableName() string {
+	return "film_actors"
+}
+
+// castLinks describes one side of the film_actors table: the column of the record being edited,
+// the column of the records linked to it and the model of the edited record, whose version every edit checks
+type castLinks struct {
+	owner  string
+	linked string
+	model  func() any
+	row    func(ownerID, linkedID uint) filmActor
+}
+
+var (
+	filmCast = castLinks{owner: "film_id", linked: "actor_id", model: func() any { return &entity.Film{} }, row: func(filmID, actorID uint) filmActor {
+		return filmActor{FilmID: filmID, ActorID: actorID}
+	}}
+	actorFilms = castLinks{owner: "actor_id", linked: "film_id", model: func() any { return &entity.Actor{} }, row: func(actorID, filmID uint) filmActor {
+		return filmActor{FilmID: filmID, ActorID: actorID}
+	}}
+)
+
+// edit runs change in a transaction after incrementing the version of the owner, which must still be version
+func (c castLinks) edit(db *gorm.DB, ownerID, version uint, change func(tx *gorm.DB, ownerID uint, linkedIDs []uint) error, linkedIDs []uint) error {
+	return translateError(db.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, c.model(), version, c.owner+" = ?", ownerID); err != nil {
+			return err
+		}
+		return change(tx, ownerID, linkedIDs)
+	}))
+}
+
+// add links ownerID to linkedIDs, skipping links that already exist
+func (c castLinks) add(tx *gorm.DB, ownerID uint, linkedIDs []uint) error {
+	linkedIDs = uniqueIDs(linkedIDs)
+	if len(linkedIDs) == 0 {
+		return nil
+	}
+	rows := make([]filmActor, len(linkedIDs))
+	for i, linkedID := range linkedIDs {
+		rows[i] = c.row(ownerID, linkedID)
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// remove unlinks ownerID from linkedIDs, ignoring links that do not exist
+func (c castLinks) remove(tx *gorm.DB, ownerID uint, linkedIDs []uint) error {
+	if len(linkedIDs) == 0 {
+		return nil
+	}
+	return tx.Where(c.owner+" = ? AND "+c.linked+" IN ?", ownerID, uniqueIDs(linkedIDs)).Delete(&filmActor{}).Error
+}
+
+// replace links ownerID to exactly linkedIDs, keeping links that already exist
+func (c castLinks) replace(tx *gorm.DB, ownerID uint, linkedIDs []uint) error {
+	linkedIDs = uniqueIDs(linkedIDs)
+	stale := tx.Where(c.owner+" = ?", ownerID)
+	if len(linkedIDs) > 0 {
+		stale = stale.Where(c.linked+" NOT IN ?", linkedIDs)
+	}
+	if err := stale.Delete(&filmActor{}).Error; err != nil {
+		return err
+	}
+	return c.add(tx, ownerID, linkedIDs)
+}
+
+// uniqueIDs returns ids without duplicates, keeping their order
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]struct{}, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
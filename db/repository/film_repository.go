@@ -6,21 +6,31 @@ import (
 	"gorm.io/gorm"
 )
 
-// FilmRepository is an interface for film operations
+// FilmRepository is an interface for film operations. Cast edits take the expected version of the film: they fail
+// with ErrStaleEntity if the film has changed since and increment its version otherwise.
 type FilmRepository interface {
 	Repository[entity.Film]
 
 	// FindByTitle finds films by title
-	FindByTitle(ctx context.Context, title string) ([]entity.Film, error)
+	FindByTitle(ctx context.Context, title string, opts ...QueryOption) ([]entity.Film, error)
 
 	// FindByCategory finds films by category ID
-	FindByCategory(ctx context.Context, categoryID uint) ([]entity.Film, error)
+	FindByCategory(ctx context.Context, categoryID uint, opts ...QueryOption) ([]entity.Film, error)
 
 	// FindByActor finds films by actor ID
-	FindByActor(ctx context.Context, actorID uint) ([]entity.Film, error)
+	FindByActor(ctx context.Context, actorID uint, opts ...QueryOption) ([]entity.Film, error)
 
 	// FindByReleaseYear finds films by release year
-	FindByReleaseYear(ctx context.Context, year int16) ([]entity.Film, error)
+	FindByReleaseYear(ctx context.Context, year int16, opts ...QueryOption) ([]entity.Film, error)
+
+	// AddActors adds actors to the cast of a film, skipping actors already in it
+	AddActors(ctx context.Context, filmID, version uint, actorIDs ...uint) error
+
+	// RemoveActors removes actors from the cast of a film, ignoring actors not in it
+	RemoveActors(ctx context.Context, filmID, version uint, actorIDs ...uint) error
+
+	// ReplaceCast sets the cast of a film to exactly the given actors
+	ReplaceCast(ctx context.Context, filmID, version uint, actorIDs ...uint) error
 }
 
 // FilmRepositoryImpl is an implementation of FilmRepository
@@ -32,44 +42,61 @@ type FilmRepositoryImpl struct {
 func NewFilmRepository(db *gorm.DB) FilmRepository {
 	return &FilmRepositoryImpl{
 		BaseRepository: BaseRepository[entity.Film]{
-			DB: db,
+			DB:           db,
+			Associations: []string{"Category", "Actors"},
 		},
 	}
 }
 
 // FindByTitle finds films by title
-func (r *FilmRepositoryImpl) FindByTitle(ctx context.Context, title string) ([]entity.Film, error) {
+func (r *FilmRepositoryImpl) FindByTitle(ctx context.Context, title string, opts ...QueryOption) ([]entity.Film, error) {
 	var films []entity.Film
-	if err := r.DB.WithContext(ctx).Where("title LIKE ?", "%"+title+"%").Find(&films).Error; err != nil {
-		return nil, err
+	condition, pattern := containsCondition(r.DB, "title", title)
+	if err := r.query(ctx, opts).Where(condition, pattern).Find(&films).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return films, nil
 }
 
 // FindByCategory finds films by category ID
-func (r *FilmRepositoryImpl) FindByCategory(ctx context.Context, categoryID uint) ([]entity.Film, error) {
+func (r *FilmRepositoryImpl) FindByCategory(ctx context.Context, categoryID uint, opts ...QueryOption) ([]entity.Film, error) {
 	var films []entity.Film
-	if err := r.DB.WithContext(ctx).Where("category_id = ?", categoryID).Find(&films).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("category_id = ?", categoryID).Find(&films).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return films, nil
 }
 
 // FindByActor finds films by actor ID
-func (r *FilmRepositoryImpl) FindByActor(ctx context.Context, actorID uint) ([]entity.Film, error) {
+func (r *FilmRepositoryImpl) FindByActor(ctx context.Context, actorID uint, opts ...QueryOption) ([]entity.Film, error) {
 	var films []entity.Film
-	if err := r.DB.WithContext(ctx).Joins("JOIN film_actors ON film_actors.film_id = film.film_id").
+	if err := r.query(ctx, opts).Joins("JOIN film_actors ON film_actors.film_id = film.film_id").
 		Where("film_actors.actor_id = ?", actorID).Find(&films).Error; err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return films, nil
 }
 
 // FindByReleaseYear finds films by release year
-func (r *FilmRepositoryImpl) FindByReleaseYear(ctx context.Context, year int16) ([]entity.Film, error) {
+func (r *FilmRepositoryImpl) FindByReleaseYear(ctx context.Context, year int16, opts ...QueryOption) ([]entity.Film, error) {
 	var films []entity.Film
-	if err := r.DB.WithContext(ctx).Where("release_year = ?", year).Find(&films).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("release_year = ?", year).Find(&films).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return films, nil
 }
+
+// AddActors adds actors to the cast of a film, skipping actors already in it
+func (r *FilmRepositoryImpl) AddActors(ctx context.Context, filmID, version uint, actorIDs ...uint) error {
+	return filmCast.edit(r.DB.WithContext(ctx), filmID, version, filmCast.add, actorIDs)
+}
+
+// RemoveActors removes actors from the cast of a film, ignoring actors not in it
+func (r *FilmRepositoryImpl) RemoveActors(ctx context.Context, filmID, version uint, actorIDs ...uint) error {
+	return filmCast.edit(r.DB.WithContext(ctx), filmID, version, filmCast.remove, actorIDs)
+}
+
+// ReplaceCast sets the cast of a film to exactly the given actors
+func (r *FilmRepositoryImpl) ReplaceCast(ctx context.Context, filmID, version uint, actorIDs ...uint) error {
+	return filmCast.edit(r.DB.WithContext(ctx), filmID, version, filmCast.replace, actorIDs)
+}
@@ -4,11 +4,13 @@ import (
 	"CortexMCP/db/entity"
 	"context"
 	"database/sql"
+	"errors"
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	mysqlDriver "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -54,6 +56,7 @@ func TestFilmRepository_Create(t *testing.T) {
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
 			sqlmock.AnyArg(), // DeletedAt
+			1,                // Version
 			film.Title,       // Title
 			film.ReleaseYear, // ReleaseYear
 			film.Length,      // Length
@@ -207,18 +210,21 @@ func TestFilmRepository_Update(t *testing.T) {
 		CategoryID:  1,
 	}
 	film.ID = 1
+	film.Version = 1
 
 	// Expect the UPDATE query
 	mock.ExpectBegin()
-	mock.ExpectExec("UPDATE").
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `film` SET ")+".* WHERE version = \\?").
 		WithArgs(
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
 			sqlmock.AnyArg(), // DeletedAt
+			2,                // Version
 			film.Title,       // Title
 			film.ReleaseYear, // ReleaseYear
 			film.Length,      // Length
 			film.CategoryID,  // CategoryID
+			1,                // expected Version
 			film.ID,          // ID
 			film.FilmID,      // FilmID
 		).
@@ -229,6 +235,36 @@ func TestFilmRepository_Update(t *testing.T) {
 	if err != nil {
 		t.Errorf("Error updating film: %v", err)
 	}
+	if film.Version != 2 {
+		t.Errorf("Expected the version to be incremented to 2, got %d", film.Version)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestFilmRepository_Update_Stale(t *testing.T) {
+	_, mock, repo, cleanup := setupFilmTest(t)
+	defer cleanup()
+
+	film := &entity.Film{FilmID: 1, Title: "The Matrix", ReleaseYear: 1999, Length: 136, CategoryID: 1}
+	film.ID = 1
+	film.Version = 4
+
+	// Someone else updated the film since version 4 was read
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `film` SET ") + ".* WHERE version = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := repo.Update(context.Background(), film)
+	if !errors.Is(err, ErrStaleEntity) {
+		t.Errorf("Expected ErrStaleEntity, got %v", err)
+	}
+	if film.Version != 4 {
+		t.Errorf("Expected the version to be left at 4, got %d", film.Version)
+	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("Unfulfilled expectations: %v", err)
@@ -318,7 +354,7 @@ func TestFilmRepository_FindByTitle(t *testing.T) {
 	}
 
 	mock.ExpectQuery("SELECT").
-		WithArgs("%Matrix%").
+		WithArgs("%matrix%").
 		WillReturnRows(rows)
 
 	films, err := repo.FindByTitle(context.Background(), "Matrix")
@@ -571,3 +607,176 @@ func TestFilmRepository_FindByID_NotFound(t *testing.T) {
 		t.Errorf("Unfulfilled expectations: %v", err)
 	}
 }
+
+func TestFilmRepository_FindByCategory_WithActors(t *testing.T) {
+	_, mock, repo, cleanup := setupFilmTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `film` WHERE category_id = ?")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "film_id", "title", "category_id"}).
+			AddRow(1, 1, "The Matrix", 1).
+			AddRow(2, 2, "The Matrix Reloaded", 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `film_actors` WHERE `film_actors`.`film_id` IN (?,?)")).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"film_id", "actor_id"}).AddRow(1, 5).AddRow(2, 5))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `actor` WHERE `actor`.`actor_id` = ?")).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "actor_id", "first_name", "last_name"}).AddRow(5, 5, "Keanu", "Reeves"))
+
+	films, err := repo.FindByCategory(context.Background(), 1, With("actors"))
+	if err != nil {
+		t.Fatalf("Error finding films by category: %v", err)
+	}
+	for _, film := range films {
+		if len(film.Actors) != 1 || film.Actors[0].LastName != "Reeves" {
+			t.Errorf("Expected actors to be preloaded for film %d, got %+v", film.FilmID, film.Actors)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestFilmRepository_FindAll_WithUnknownAssociation(t *testing.T) {
+	_, mock, repo, cleanup := setupFilmTest(t)
+	defer cleanup()
+
+	_, err := repo.FindAll(context.Background(), With("Inventory"))
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Fields[0].Field != "include" {
+		t.Fatalf("Expected include validation error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestFilmRepository_AddActors(t *testing.T) {
+	_, mock, repo, cleanup := setupFilmTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `film` SET `version`=version + 1,`updated_at`=? WHERE film_id = ? AND version = ? AND `film`.`deleted_at` IS NULL")).
+		WithArgs(sqlmock.AnyArg(), 1, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `film_actors` (`film_id`,`actor_id`) VALUES (?,?),(?,?) ON DUPLICATE KEY UPDATE `film_id`=`film_id`")).
+		WithArgs(1, 5, 1, 6).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// The duplicate actor is only inserted once; existing links are left untouched
+	if err := repo.AddActors(context.Background(), 1, 3, 5, 6, 5); err != nil {
+		t.Errorf("Error adding actors: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestFilmRepository_AddActors_Stale(t *testing.T) {
+	_, mock, repo, cleanup := setupFilmTest(t)
+	defer cleanup()
+
+	for _, tt := range []struct {
+		count int
+		want  error
+	}{
+		{count: 1, want: ErrStaleEntity},
+		{count: 0, want: ErrNotFound},
+	} {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `film` SET `version`=version + 1,`updated_at`=? WHERE film_id = ? AND version = ?")).
+			WithArgs(sqlmock.AnyArg(), 1, 3).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `film` WHERE film_id = ? AND `film`.`deleted_at` IS NULL")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.count))
+		mock.ExpectRollback()
+
+		// The cast is left untouched
+		if err := repo.AddActors(context.Background(), 1, 3, 5); !errors.Is(err, tt.want) {
+			t.Errorf("Expected %v with %d matching films, got %v", tt.want, tt.count, err)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestFilmRepository_RemoveActors(t *testing.T) {
+	_, mock, repo, cleanup := setupFilmTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `film` SET `version`=version + 1,`updated_at`=? WHERE film_id = ? AND version = ? AND `film`.`deleted_at` IS NULL")).
+		WithArgs(sqlmock.AnyArg(), 1, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `film_actors` WHERE film_id = ? AND actor_id IN (?,?)")).
+		WithArgs(1, 5, 6).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	if err := repo.RemoveActors(context.Background(), 1, 3, 5, 6); err != nil {
+		t.Errorf("Error removing actors: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestFilmRepository_ReplaceCast(t *testing.T) {
+	_, mock, repo, cleanup := setupFilmTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `film` SET `version`=version + 1,`updated_at`=? WHERE film_id = ? AND version = ? AND `film`.`deleted_at` IS NULL")).
+		WithArgs(sqlmock.AnyArg(), 1, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `film_actors` WHERE film_id = ? AND actor_id NOT IN (?)")).
+		WithArgs(1, 5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `film_actors`")).
+		WithArgs(1, 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	if err := repo.ReplaceCast(context.Background(), 1, 3, 5); err != nil {
+		t.Errorf("Error replacing cast: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestFilmRepository_ReplaceCast_RollsBack(t *testing.T) {
+	_, mock, repo, cleanup := setupFilmTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `film` SET `version`=version + 1,`updated_at`=? WHERE film_id = ? AND version = ? AND `film`.`deleted_at` IS NULL")).
+		WithArgs(sqlmock.AnyArg(), 1, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `film_actors` WHERE film_id = ? AND actor_id NOT IN (?)")).
+		WithArgs(1, 99).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `film_actors`")).
+		WithArgs(1, 99).
+		WillReturnError(&mysqlDriver.MySQLError{Number: mysqlNoReferencedRow, Message: "Cannot add or update a child row"})
+	mock.ExpectRollback()
+
+	err := repo.ReplaceCast(context.Background(), 1, 3, 99)
+	if !errors.Is(err, ErrReferenced) {
+		t.Errorf("Expected ErrReferenced, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"CortexMCP/db/entity"
 	"context"
 	"gorm.io/gorm"
+	"time"
 )
 
 // InventoryRepository is an interface for inventory operations
@@ -11,22 +12,22 @@ type InventoryRepository interface {
 	Repository[entity.Inventory]
 
 	// FindByFilm finds inventory items by film ID
-	FindByFilm(ctx context.Context, filmID uint) ([]entity.Inventory, error)
+	FindByFilm(ctx context.Context, filmID uint, opts ...QueryOption) ([]entity.Inventory, error)
 
 	// FindByStore finds inventory items by store ID
-	FindByStore(ctx context.Context, storeID uint) ([]entity.Inventory, error)
+	FindByStore(ctx context.Context, storeID uint, opts ...QueryOption) ([]entity.Inventory, error)
 
 	// FindByFilmAndStore finds inventory items by film ID and store ID
-	FindByFilmAndStore(ctx context.Context, filmID, storeID uint) ([]entity.Inventory, error)
+	FindByFilmAndStore(ctx context.Context, filmID, storeID uint, opts ...QueryOption) ([]entity.Inventory, error)
 
-	// FindAvailable finds inventory items that are not currently rented
-	FindAvailable(ctx context.Context) ([]entity.Inventory, error)
+	// FindAvailable finds inventory items that are not currently held, rented or lost
+	FindAvailable(ctx context.Context, opts ...QueryOption) ([]entity.Inventory, error)
 
 	// FindAvailableByFilm finds available inventory items by film ID
-	FindAvailableByFilm(ctx context.Context, filmID uint) ([]entity.Inventory, error)
+	FindAvailableByFilm(ctx context.Context, filmID uint, opts ...QueryOption) ([]entity.Inventory, error)
 
 	// FindAvailableByStore finds available inventory items by store ID
-	FindAvailableByStore(ctx context.Context, storeID uint) ([]entity.Inventory, error)
+	FindAvailableByStore(ctx context.Context, storeID uint, opts ...QueryOption) ([]entity.Inventory, error)
 }
 
 // InventoryRepositoryImpl is an implementation of InventoryRepository
@@ -38,70 +39,79 @@ type InventoryRepositoryImpl struct {
 func NewInventoryRepository(db *gorm.DB) InventoryRepository {
 	return &InventoryRepositoryImpl{
 		BaseRepository: BaseRepository[entity.Inventory]{
-			DB: db,
+			DB:           db,
+			Associations: []string{"Film", "Store"},
 		},
 	}
 }
 
 // FindByFilm finds inventory items by film ID
-func (r *InventoryRepositoryImpl) FindByFilm(ctx context.Context, filmID uint) ([]entity.Inventory, error) {
+func (r *InventoryRepositoryImpl) FindByFilm(ctx context.Context, filmID uint, opts ...QueryOption) ([]entity.Inventory, error) {
 	var inventory []entity.Inventory
-	if err := r.DB.WithContext(ctx).Where("film_id = ?", filmID).Find(&inventory).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("film_id = ?", filmID).Find(&inventory).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return inventory, nil
 }
 
 // FindByStore finds inventory items by store ID
-func (r *InventoryRepositoryImpl) FindByStore(ctx context.Context, storeID uint) ([]entity.Inventory, error) {
+func (r *InventoryRepositoryImpl) FindByStore(ctx context.Context, storeID uint, opts ...QueryOption) ([]entity.Inventory, error) {
 	var inventory []entity.Inventory
-	if err := r.DB.WithContext(ctx).Where("store_id = ?", storeID).Find(&inventory).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("store_id = ?", storeID).Find(&inventory).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return inventory, nil
 }
 
 // FindByFilmAndStore finds inventory items by film ID and store ID
-func (r *InventoryRepositoryImpl) FindByFilmAndStore(ctx context.Context, filmID, storeID uint) ([]entity.Inventory, error) {
+func (r *InventoryRepositoryImpl) FindByFilmAndStore(ctx context.Context, filmID, storeID uint, opts ...QueryOption) ([]entity.Inventory, error) {
 	var inventory []entity.Inventory
-	if err := r.DB.WithContext(ctx).Where("film_id = ? AND store_id = ?", filmID, storeID).Find(&inventory).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("film_id = ? AND store_id = ?", filmID, storeID).Find(&inventory).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return inventory, nil
 }
 
-// FindAvailable finds inventory items that are not currently rented
-func (r *InventoryRepositoryImpl) FindAvailable(ctx context.Context) ([]entity.Inventory, error) {
-	var inventory []entity.Inventory
-	if err := r.DB.WithContext(ctx).
-		Joins("LEFT JOIN rental ON rental.inventory_id = inventory.inventory_id AND rental.return_date IS NULL").
+// available restricts a query of inventory to the copies that neither a live reserved, out or lost rental nor an
+// active reservation holds
+func available(db *gorm.DB) *gorm.DB {
+	return db.Joins("LEFT JOIN rental ON rental.inventory_id = inventory.inventory_id AND rental.status IN ? AND rental.deleted_at IS NULL", entity.HoldingRentalStatuses).
 		Where("rental.rental_id IS NULL").
+		Where("NOT EXISTS (SELECT 1 FROM reservation WHERE reservation.inventory_id = inventory.inventory_id "+
+			"AND reservation.status = ? AND reservation.expires_at > ? AND reservation.deleted_at IS NULL)", entity.ReservationActive, time.Now())
+}
+
+// FindAvailable finds inventory items that are not currently held, rented or lost
+func (r *InventoryRepositoryImpl) FindAvailable(ctx context.Context, opts ...QueryOption) ([]entity.Inventory, error) {
+	var inventory []entity.Inventory
+	if err := r.query(ctx, opts).
+		Scopes(available).
 		Find(&inventory).Error; err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return inventory, nil
 }
 
 // FindAvailableByFilm finds available inventory items by film ID
-func (r *InventoryRepositoryImpl) FindAvailableByFilm(ctx context.Context, filmID uint) ([]entity.Inventory, error) {
+func (r *InventoryRepositoryImpl) FindAvailableByFilm(ctx context.Context, filmID uint, opts ...QueryOption) ([]entity.Inventory, error) {
 	var inventory []entity.Inventory
-	if err := r.DB.WithContext(ctx).
-		Joins("LEFT JOIN rental ON rental.inventory_id = inventory.inventory_id AND rental.return_date IS NULL").
-		Where("rental.rental_id IS NULL AND inventory.film_id = ?", filmID).
+	if err := r.query(ctx, opts).
+		Scopes(available).
+		Where("inventory.film_id = ?", filmID).
 		Find(&inventory).Error; err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return inventory, nil
 }
 
 // FindAvailableByStore finds available inventory items by store ID
-func (r *InventoryRepositoryImpl) FindAvailableByStore(ctx context.Context, storeID uint) ([]entity.Inventory, error) {
+func (r *InventoryRepositoryImpl) FindAvailableByStore(ctx context.Context, storeID uint, opts ...QueryOption) ([]entity.Inventory, error) {
 	var inventory []entity.Inventory
-	if err := r.DB.WithContext(ctx).
-		Joins("LEFT JOIN rental ON rental.inventory_id = inventory.inventory_id AND rental.return_date IS NULL").
-		Where("rental.rental_id IS NULL AND inventory.store_id = ?", storeID).
+	if err := r.query(ctx, opts).
+		Scopes(available).
+		Where("inventory.store_id = ?", storeID).
 		Find(&inventory).Error; err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return inventory, nil
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -52,6 +53,7 @@ func TestInventoryRepository_Create(t *testing.T) {
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
 			sqlmock.AnyArg(), // DeletedAt
+			1,                // Version
 			inventory.FilmID,
 			inventory.StoreID,
 		).
@@ -193,16 +195,19 @@ func TestInventoryRepository_Update(t *testing.T) {
 		StoreID:     1,
 	}
 	inventory.ID = 1
+	inventory.Version = 1
 
 	// Expect the UPDATE query
 	mock.ExpectBegin()
-	mock.ExpectExec("UPDATE").
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `inventory` SET ")+".* WHERE version = \\?").
 		WithArgs(
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
 			sqlmock.AnyArg(), // DeletedAt
+			2,                // Version
 			inventory.FilmID,
 			inventory.StoreID,
+			1, // expected Version
 			inventory.ID,
 			inventory.InventoryID,
 		).
@@ -486,7 +491,8 @@ func TestInventoryRepository_FindAvailable(t *testing.T) {
 		)
 	}
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT `inventory`.`id`,`inventory`.`created_at`,`inventory`.`updated_at`,`inventory`.`deleted_at`,`inventory`.`inventory_id`,`inventory`.`film_id`,`inventory`.`store_id` FROM `inventory` LEFT JOIN rental ON rental.inventory_id = inventory.inventory_id AND rental.return_date IS NULL WHERE rental.rental_id IS NULL AND `inventory`.`deleted_at` IS NULL")).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `inventory`.`id`,`inventory`.`created_at`,`inventory`.`updated_at`,`inventory`.`deleted_at`,`inventory`.`version`,`inventory`.`inventory_id`,`inventory`.`film_id`,`inventory`.`store_id` FROM `inventory` LEFT JOIN rental ON rental.inventory_id = inventory.inventory_id AND rental.status IN (?,?,?) AND rental.deleted_at IS NULL WHERE rental.rental_id IS NULL AND (NOT EXISTS (SELECT 1 FROM reservation WHERE reservation.inventory_id = inventory.inventory_id AND reservation.status = ? AND reservation.expires_at > ? AND reservation.deleted_at IS NULL)) AND `inventory`.`deleted_at` IS NULL")).
+		WithArgs(entity.RentalReserved, entity.RentalOut, entity.RentalLost, entity.ReservationActive, sqlmock.AnyArg()).
 		WillReturnRows(rows)
 
 	inventories, err := repo.FindAvailable(context.Background())
@@ -503,6 +509,23 @@ func TestInventoryRepository_FindAvailable(t *testing.T) {
 	}
 }
 
+func TestAvailable_IgnoresSoftDeletedRentals(t *testing.T) {
+	sqlDB, _, _, cleanup := setupInventoryTest(t)
+	defer cleanup()
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatalf("Failed to open gorm database: %v", err)
+	}
+
+	// A soft-deleted rental still out must not keep its copy unavailable
+	query := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(available).Find(&[]entity.Inventory{})
+	})
+	if !strings.Contains(query, "LEFT JOIN rental ON rental.inventory_id = inventory.inventory_id AND rental.status IN ('reserved','out','lost') AND rental.deleted_at IS NULL") {
+		t.Errorf("Expected the join to skip soft-deleted rentals, got %s", query)
+	}
+}
+
 func TestInventoryRepository_FindAvailableByFilm(t *testing.T) {
 	_, mock, repo, cleanup := setupInventoryTest(t)
 	defer cleanup()
@@ -531,8 +554,8 @@ func TestInventoryRepository_FindAvailableByFilm(t *testing.T) {
 		)
 	}
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT `inventory`.`id`,`inventory`.`created_at`,`inventory`.`updated_at`,`inventory`.`deleted_at`,`inventory`.`inventory_id`,`inventory`.`film_id`,`inventory`.`store_id` FROM `inventory` LEFT JOIN rental ON rental.inventory_id = inventory.inventory_id AND rental.return_date IS NULL WHERE (rental.rental_id IS NULL AND inventory.film_id = ?) AND `inventory`.`deleted_at` IS NULL")).
-		WithArgs(uint(1)).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `inventory`.`id`,`inventory`.`created_at`,`inventory`.`updated_at`,`inventory`.`deleted_at`,`inventory`.`version`,`inventory`.`inventory_id`,`inventory`.`film_id`,`inventory`.`store_id` FROM `inventory` LEFT JOIN rental ON rental.inventory_id = inventory.inventory_id AND rental.status IN (?,?,?) AND rental.deleted_at IS NULL WHERE inventory.film_id = ? AND rental.rental_id IS NULL AND (NOT EXISTS (SELECT 1 FROM reservation WHERE reservation.inventory_id = inventory.inventory_id AND reservation.status = ? AND reservation.expires_at > ? AND reservation.deleted_at IS NULL)) AND `inventory`.`deleted_at` IS NULL")).
+		WithArgs(entity.RentalReserved, entity.RentalOut, entity.RentalLost, uint(1), entity.ReservationActive, sqlmock.AnyArg()).
 		WillReturnRows(rows)
 
 	inventories, err := repo.FindAvailableByFilm(context.Background(), 1)
@@ -583,8 +606,8 @@ func TestInventoryRepository_FindAvailableByStore(t *testing.T) {
 		)
 	}
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT `inventory`.`id`,`inventory`.`created_at`,`inventory`.`updated_at`,`inventory`.`deleted_at`,`inventory`.`inventory_id`,`inventory`.`film_id`,`inventory`.`store_id` FROM `inventory` LEFT JOIN rental ON rental.inventory_id = inventory.inventory_id AND rental.return_date IS NULL WHERE (rental.rental_id IS NULL AND inventory.store_id = ?) AND `inventory`.`deleted_at` IS NULL")).
-		WithArgs(uint(1)).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `inventory`.`id`,`inventory`.`created_at`,`inventory`.`updated_at`,`inventory`.`deleted_at`,`inventory`.`version`,`inventory`.`inventory_id`,`inventory`.`film_id`,`inventory`.`store_id` FROM `inventory` LEFT JOIN rental ON rental.inventory_id = inventory.inventory_id AND rental.status IN (?,?,?) AND rental.deleted_at IS NULL WHERE inventory.store_id = ? AND rental.rental_id IS NULL AND (NOT EXISTS (SELECT 1 FROM reservation WHERE reservation.inventory_id = inventory.inventory_id AND reservation.status = ? AND reservation.expires_at > ? AND reservation.deleted_at IS NULL)) AND `inventory`.`deleted_at` IS NULL")).
+		WithArgs(entity.RentalReserved, entity.RentalOut, entity.RentalLost, uint(1), entity.ReservationActive, sqlmock.AnyArg()).
 		WillReturnRows(rows)
 
 	inventories, err := repo.FindAvailableByStore(context.Background(), 1)
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// forUpdate locks the rows of table read by the query built on tx until the transaction ends. With skipLocked,
+// rows locked by other transactions are skipped instead of waited for. SQL Server has no FOR UPDATE clause and
+// takes the equivalent table hints instead.
+func forUpdate(tx *gorm.DB, table string, skipLocked bool) *gorm.DB {
+	if tx.Dialector.Name() == "sqlserver" {
+		hints := "UPDLOCK, ROWLOCK"
+		if skipLocked {
+			hints += ", READPAST"
+		}
+		return tx.Clauses(clause.From{Tables: []clause.Table{{Name: table + " WITH (" + hints + ")", Raw: true}}})
+	}
+	locking := clause.Locking{Strength: clause.LockingStrengthUpdate}
+	if skipLocked {
+		locking.Options = clause.LockingOptionsSkipLocked
+	}
+	return tx.Clauses(locking)
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"CortexMCP/db/entity"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestForUpdate(t *testing.T) {
+	tests := []struct {
+		dialect    string
+		skipLocked bool
+		sql        string
+	}{
+		{dialect: "mysql", sql: "SELECT \\* FROM `rental` WHERE rental_id = \\? .* FOR UPDATE$"},
+		{dialect: "mysql", skipLocked: true, sql: "SELECT \\* FROM `rental` WHERE rental_id = \\? .* FOR UPDATE SKIP LOCKED$"},
+		{dialect: "postgres", skipLocked: true, sql: `SELECT \* FROM "rental" WHERE rental_id = \$1 .* FOR UPDATE SKIP LOCKED$`},
+		{dialect: "sqlserver", skipLocked: true, sql: `SELECT \* FROM rental WITH \(UPDLOCK, ROWLOCK, READPAST\) WHERE rental_id = @p1 `},
+	}
+	for _, test := range tests {
+		t.Run(test.dialect, func(t *testing.T) {
+			mock, db := setupBulkTest(t, test.dialect)
+			mock.ExpectQuery(test.sql).WillReturnRows(sqlmock.NewRows([]string{"rental_id"}).AddRow(1))
+
+			var rental entity.Rental
+			if err := forUpdate(db, "rental", test.skipLocked).Where("rental_id = ?", 1).Take(&rental).Error; err != nil {
+				t.Fatalf("Error locking rental: %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
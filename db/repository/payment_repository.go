@@ -7,30 +7,54 @@ import (
 	"time"
 )
 
-// PaymentRepository is an interface for payment operations
+// netAmount is the amount a payment line adds to what the customer paid, negative for a refund. Its argument is
+// entity.PaymentRefund.
+const netAmount = "CASE WHEN payment.kind = ? THEN -payment.amount ELSE payment.amount END"
+
+// RentalBalance is what a customer paid for a rental: the charges, late fees and replacements, the refunds,
+// and the net amount paid
+type RentalBalance struct {
+	RentalID uint         `json:"rentalId"`
+	Paid     entity.Money `json:"paid"`
+	Refunded entity.Money `json:"refunded"`
+	Net      entity.Money `json:"net"`
+}
+
+// PaymentRepository is an interface for payment operations. A rental may have several payment lines, of
+// which refunds count against the others.
 type PaymentRepository interface {
 	Repository[entity.Payment]
 
 	// FindByCustomer finds payments by customer ID
-	FindByCustomer(ctx context.Context, customerID uint) ([]entity.Payment, error)
+	FindByCustomer(ctx context.Context, customerID uint, opts ...QueryOption) ([]entity.Payment, error)
 
 	// FindByStaff finds payments by staff ID
-	FindByStaff(ctx context.Context, staffID uint) ([]entity.Payment, error)
+	FindByStaff(ctx context.Context, staffID uint, opts ...QueryOption) ([]entity.Payment, error)
 
-	// FindByRental finds a payment by rental ID
-	FindByRental(ctx context.Context, rentalID uint) (*entity.Payment, error)
+	// FindByRental finds the payment lines of a rental
+	FindByRental(ctx context.Context, rentalID uint, opts ...QueryOption) ([]entity.Payment, error)
 
 	// FindByDateRange finds payments within a date range
-	FindByDateRange(ctx context.Context, startDate, endDate time.Time) ([]entity.Payment, error)
+	FindByDateRange(ctx context.Context, startDate, endDate time.Time, opts ...QueryOption) ([]entity.Payment, error)
 
 	// FindByAmountRange finds payments within an amount range
-	FindByAmountRange(ctx context.Context, minAmount, maxAmount float64) ([]entity.Payment, error)
+	FindByAmountRange(ctx context.Context, minAmount, maxAmount entity.Money, opts ...QueryOption) ([]entity.Payment, error)
+
+	// GetTotalPaymentsByCustomer gets the total amount of payments by customer ID, net of refunds
+	GetTotalPaymentsByCustomer(ctx context.Context, customerID uint) (entity.Money, error)
+
+	// GetTotalPaymentsByStore gets the total amount of payments by store ID, net of refunds
+	GetTotalPaymentsByStore(ctx context.Context, storeID uint) (entity.Money, error)
 
-	// GetTotalPaymentsByCustomer gets the total amount of payments by customer ID
-	GetTotalPaymentsByCustomer(ctx context.Context, customerID uint) (float64, error)
+	// GetRentalBalance gets what was paid for a rental
+	GetRentalBalance(ctx context.Context, rentalID uint) (*RentalBalance, error)
 
-	// GetTotalPaymentsByStore gets the total amount of payments by store ID
-	GetTotalPaymentsByStore(ctx context.Context, storeID uint) (float64, error)
+	// GetRentalBalancesByCustomer gets what a customer paid for each of their rentals with payments
+	GetRentalBalancesByCustomer(ctx context.Context, customerID uint) ([]RentalBalance, error)
+
+	// Refund validates and creates a refund line for a rental. It fails with a *ValidationError when the amount
+	// exceeds the net amount paid for the rental.
+	Refund(ctx context.Context, refund *entity.Payment) error
 }
 
 // PaymentRepositoryImpl is an implementation of PaymentRepository
@@ -42,77 +66,144 @@ type PaymentRepositoryImpl struct {
 func NewPaymentRepository(db *gorm.DB) PaymentRepository {
 	return &PaymentRepositoryImpl{
 		BaseRepository: BaseRepository[entity.Payment]{
-			DB: db,
+			DB:           db,
+			Associations: []string{"Customer", "Staff", "Rental"},
 		},
 	}
 }
 
 // FindByCustomer finds payments by customer ID
-func (r *PaymentRepositoryImpl) FindByCustomer(ctx context.Context, customerID uint) ([]entity.Payment, error) {
+func (r *PaymentRepositoryImpl) FindByCustomer(ctx context.Context, customerID uint, opts ...QueryOption) ([]entity.Payment, error) {
 	var payments []entity.Payment
-	if err := r.DB.WithContext(ctx).Where("customer_id = ?", customerID).Find(&payments).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("customer_id = ?", customerID).Find(&payments).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return payments, nil
 }
 
 // FindByStaff finds payments by staff ID
-func (r *PaymentRepositoryImpl) FindByStaff(ctx context.Context, staffID uint) ([]entity.Payment, error) {
+func (r *PaymentRepositoryImpl) FindByStaff(ctx context.Context, staffID uint, opts ...QueryOption) ([]entity.Payment, error) {
 	var payments []entity.Payment
-	if err := r.DB.WithContext(ctx).Where("staff_id = ?", staffID).Find(&payments).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("staff_id = ?", staffID).Find(&payments).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return payments, nil
 }
 
-// FindByRental finds a payment by rental ID
-func (r *PaymentRepositoryImpl) FindByRental(ctx context.Context, rentalID uint) (*entity.Payment, error) {
-	var payment entity.Payment
-	if err := r.DB.WithContext(ctx).Where("rental_id = ?", rentalID).First(&payment).Error; err != nil {
-		return nil, err
+// FindByRental finds the payment lines of a rental in the order they were made
+func (r *PaymentRepositoryImpl) FindByRental(ctx context.Context, rentalID uint, opts ...QueryOption) ([]entity.Payment, error) {
+	var payments []entity.Payment
+	if err := r.query(ctx, opts).Where("rental_id = ?", rentalID).Order("payment_date, payment_id").Find(&payments).Error; err != nil {
+		return nil, translateError(err)
 	}
-	return &payment, nil
+	return payments, nil
 }
 
 // FindByDateRange finds payments within a date range
-func (r *PaymentRepositoryImpl) FindByDateRange(ctx context.Context, startDate, endDate time.Time) ([]entity.Payment, error) {
+func (r *PaymentRepositoryImpl) FindByDateRange(ctx context.Context, startDate, endDate time.Time, opts ...QueryOption) ([]entity.Payment, error) {
 	var payments []entity.Payment
-	if err := r.DB.WithContext(ctx).Where("payment_date BETWEEN ? AND ?", startDate, endDate).Find(&payments).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("payment_date BETWEEN ? AND ?", startDate, endDate).Find(&payments).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return payments, nil
 }
 
 // FindByAmountRange finds payments within an amount range
-func (r *PaymentRepositoryImpl) FindByAmountRange(ctx context.Context, minAmount, maxAmount float64) ([]entity.Payment, error) {
+func (r *PaymentRepositoryImpl) FindByAmountRange(ctx context.Context, minAmount, maxAmount entity.Money, opts ...QueryOption) ([]entity.Payment, error) {
 	var payments []entity.Payment
-	if err := r.DB.WithContext(ctx).Where("amount BETWEEN ? AND ?", minAmount, maxAmount).Find(&payments).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("amount BETWEEN ? AND ?", minAmount, maxAmount).Find(&payments).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return payments, nil
 }
 
-// GetTotalPaymentsByCustomer gets the total amount of payments by customer ID
-func (r *PaymentRepositoryImpl) GetTotalPaymentsByCustomer(ctx context.Context, customerID uint) (float64, error) {
-	var total float64
+// GetTotalPaymentsByCustomer gets the total amount of payments by customer ID, net of refunds
+func (r *PaymentRepositoryImpl) GetTotalPaymentsByCustomer(ctx context.Context, customerID uint) (entity.Money, error) {
+	var total entity.Money
 	if err := r.DB.WithContext(ctx).Model(&entity.Payment{}).
-		Select("SUM(amount)").
+		Select("COALESCE(SUM("+netAmount+"), 0)", entity.PaymentRefund).
 		Where("customer_id = ?", customerID).
 		Scan(&total).Error; err != nil {
-		return 0, err
+		return entity.Money{}, translateError(err)
 	}
 	return total, nil
 }
 
-// GetTotalPaymentsByStore gets the total amount of payments by store ID
-func (r *PaymentRepositoryImpl) GetTotalPaymentsByStore(ctx context.Context, storeID uint) (float64, error) {
-	var total float64
+// GetTotalPaymentsByStore gets the total amount of payments by store ID, net of refunds
+func (r *PaymentRepositoryImpl) GetTotalPaymentsByStore(ctx context.Context, storeID uint) (entity.Money, error) {
+	var total entity.Money
 	if err := r.DB.WithContext(ctx).Model(&entity.Payment{}).
-		Select("SUM(payment.amount)").
+		Select("COALESCE(SUM("+netAmount+"), 0)", entity.PaymentRefund).
 		Joins("JOIN staff ON payment.staff_id = staff.staff_id").
 		Where("staff.store_id = ?", storeID).
 		Scan(&total).Error; err != nil {
-		return 0, err
+		return entity.Money{}, translateError(err)
 	}
 	return total, nil
 }
+
+// GetRentalBalance gets what was paid for a rental, zero for a rental without payments
+func (r *PaymentRepositoryImpl) GetRentalBalance(ctx context.Context, rentalID uint) (*RentalBalance, error) {
+	balance, err := rentalBalance(r.DB.WithContext(ctx), rentalID)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return balance, nil
+}
+
+// GetRentalBalancesByCustomer gets what a customer paid for each of their rentals with payments, by rental ID
+func (r *PaymentRepositoryImpl) GetRentalBalancesByCustomer(ctx context.Context, customerID uint) ([]RentalBalance, error) {
+	var balances []RentalBalance
+	if err := balanceQuery(r.DB.WithContext(ctx)).
+		Where("payment.customer_id = ?", customerID).
+		Group("payment.rental_id").
+		Order("payment.rental_id").
+		Scan(&balances).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return balances, nil
+}
+
+// Refund validates and creates a refund line for a rental, in a transaction checking that it does not exceed
+// the net amount paid for the rental
+func (r *PaymentRepositoryImpl) Refund(ctx context.Context, refund *entity.Payment) error {
+	refund.Kind = entity.PaymentRefund
+	if err := validateEntity(refund); err != nil {
+		return err
+	}
+	return translateError(r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the rental so that concurrent refunds see each other's payment lines
+		var rental entity.Rental
+		if err := forUpdate(tx, "rental", false).Select("rental_id").
+			Where("rental_id = ?", refund.RentalID).Take(&rental).Error; err != nil {
+			return err
+		}
+		balance, err := rentalBalance(tx, refund.RentalID)
+		if err != nil {
+			return err
+		}
+		if refund.Amount.Minor() > balance.Net.Minor() {
+			return &ValidationError{Fields: []FieldError{{Field: "amount", Rule: "lte", Param: balance.Net.Decimal()}}}
+		}
+		return tx.Create(refund).Error
+	}))
+}
+
+// balanceQuery selects the balance of each rental from the payment lines matched by the query built on db
+func balanceQuery(db *gorm.DB) *gorm.DB {
+	return db.Model(&entity.Payment{}).
+		Select("payment.rental_id, "+
+			"COALESCE(SUM(CASE WHEN payment.kind <> ? THEN payment.amount ELSE 0 END), 0) AS paid, "+
+			"COALESCE(SUM(CASE WHEN payment.kind = ? THEN payment.amount ELSE 0 END), 0) AS refunded, "+
+			"COALESCE(SUM("+netAmount+"), 0) AS net",
+			entity.PaymentRefund, entity.PaymentRefund, entity.PaymentRefund)
+}
+
+// rentalBalance gets the balance of a rental within db
+func rentalBalance(db *gorm.DB, rentalID uint) (*RentalBalance, error) {
+	balance := RentalBalance{RentalID: rentalID}
+	if err := balanceQuery(db).Where("payment.rental_id = ?", rentalID).Group("payment.rental_id").Scan(&balance).Error; err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
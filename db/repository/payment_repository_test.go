@@ -4,6 +4,7 @@ import (
 	"CortexMCP/db/entity"
 	"context"
 	"database/sql"
+	"errors"
 	"regexp"
 	"testing"
 	"time"
@@ -45,7 +46,7 @@ func TestPaymentRepository_Create(t *testing.T) {
 		CustomerID:  1,
 		StaffID:     1,
 		RentalID:    1,
-		Amount:      9.99,
+		Amount:      entity.Cents(999),
 		PaymentDate: paymentDate,
 	}
 
@@ -56,9 +57,11 @@ func TestPaymentRepository_Create(t *testing.T) {
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
 			sqlmock.AnyArg(), // DeletedAt
+			1,                // Version
 			payment.CustomerID,
 			payment.StaffID,
 			payment.RentalID,
+			entity.PaymentCharge,
 			payment.Amount,
 			payment.PaymentDate,
 		).
@@ -86,7 +89,7 @@ func TestPaymentRepository_FindByID(t *testing.T) {
 		CustomerID:  1,
 		StaffID:     1,
 		RentalID:    1,
-		Amount:      9.99,
+		Amount:      entity.Cents(999),
 		PaymentDate: paymentDate,
 	}
 	expectedPayment.ID = 1
@@ -101,7 +104,7 @@ func TestPaymentRepository_FindByID(t *testing.T) {
 		AddRow(
 			expectedPayment.ID, expectedPayment.CreatedAt, expectedPayment.UpdatedAt, nil,
 			expectedPayment.PaymentID, expectedPayment.CustomerID, expectedPayment.StaffID,
-			expectedPayment.RentalID, expectedPayment.Amount, expectedPayment.PaymentDate,
+			expectedPayment.RentalID, expectedPayment.Amount.Decimal(), expectedPayment.PaymentDate,
 		)
 
 	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `payment` WHERE `payment`.`id` = ? AND `payment`.`deleted_at` IS NULL ORDER BY `payment`.`id` LIMIT ?")).
@@ -130,7 +133,7 @@ func TestPaymentRepository_FindByID(t *testing.T) {
 	}
 
 	if payment.Amount != expectedPayment.Amount {
-		t.Errorf("Expected Amount %f, got %f", expectedPayment.Amount, payment.Amount)
+		t.Errorf("Expected Amount %s, got %s", expectedPayment.Amount.Decimal(), payment.Amount)
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -151,7 +154,7 @@ func TestPaymentRepository_FindAll(t *testing.T) {
 			CustomerID:  1,
 			StaffID:     1,
 			RentalID:    1,
-			Amount:      9.99,
+			Amount:      entity.Cents(999),
 			PaymentDate: paymentDate1,
 		},
 		{
@@ -159,7 +162,7 @@ func TestPaymentRepository_FindAll(t *testing.T) {
 			CustomerID:  2,
 			StaffID:     1,
 			RentalID:    2,
-			Amount:      5.99,
+			Amount:      entity.Cents(599),
 			PaymentDate: paymentDate2,
 		},
 	}
@@ -179,7 +182,7 @@ func TestPaymentRepository_FindAll(t *testing.T) {
 		rows.AddRow(
 			payment.ID, payment.CreatedAt, payment.UpdatedAt, nil,
 			payment.PaymentID, payment.CustomerID, payment.StaffID,
-			payment.RentalID, payment.Amount, payment.PaymentDate,
+			payment.RentalID, payment.Amount.Decimal(), payment.PaymentDate,
 		)
 	}
 
@@ -209,7 +212,7 @@ func TestPaymentRepository_FindAll(t *testing.T) {
 			t.Errorf("Expected RentalID %d, got %d", expectedPayments[i].RentalID, payment.RentalID)
 		}
 		if payment.Amount != expectedPayments[i].Amount {
-			t.Errorf("Expected Amount %f, got %f", expectedPayments[i].Amount, payment.Amount)
+			t.Errorf("Expected Amount %s, got %s", expectedPayments[i].Amount.Decimal(), payment.Amount)
 		}
 	}
 
@@ -228,23 +231,27 @@ func TestPaymentRepository_Update(t *testing.T) {
 		CustomerID:  1,
 		StaffID:     1,
 		RentalID:    1,
-		Amount:      9.99,
+		Amount:      entity.Cents(999),
 		PaymentDate: paymentDate,
 	}
 	payment.ID = 1
+	payment.Version = 1
 
 	// Expect the UPDATE query
 	mock.ExpectBegin()
-	mock.ExpectExec("UPDATE").
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `payment` SET ")+".* WHERE version = \\?").
 		WithArgs(
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
 			sqlmock.AnyArg(), // DeletedAt
+			2,                // Version
 			payment.CustomerID,
 			payment.StaffID,
 			payment.RentalID,
+			entity.PaymentCharge,
 			payment.Amount,
 			payment.PaymentDate,
+			1, // expected Version
 			payment.ID,
 			payment.PaymentID,
 		).
@@ -331,7 +338,7 @@ func TestPaymentRepository_FindByCustomer(t *testing.T) {
 			CustomerID:  1,
 			StaffID:     1,
 			RentalID:    1,
-			Amount:      9.99,
+			Amount:      entity.Cents(999),
 			PaymentDate: paymentDate1,
 		},
 		{
@@ -339,7 +346,7 @@ func TestPaymentRepository_FindByCustomer(t *testing.T) {
 			CustomerID:  1,
 			StaffID:     2,
 			RentalID:    2,
-			Amount:      5.99,
+			Amount:      entity.Cents(599),
 			PaymentDate: paymentDate2,
 		},
 	}
@@ -359,7 +366,7 @@ func TestPaymentRepository_FindByCustomer(t *testing.T) {
 		rows.AddRow(
 			payment.ID, payment.CreatedAt, payment.UpdatedAt, nil,
 			payment.PaymentID, payment.CustomerID, payment.StaffID,
-			payment.RentalID, payment.Amount, payment.PaymentDate,
+			payment.RentalID, payment.Amount.Decimal(), payment.PaymentDate,
 		)
 	}
 
@@ -400,7 +407,7 @@ func TestPaymentRepository_FindByStaff(t *testing.T) {
 			CustomerID:  1,
 			StaffID:     1,
 			RentalID:    1,
-			Amount:      9.99,
+			Amount:      entity.Cents(999),
 			PaymentDate: paymentDate1,
 		},
 		{
@@ -408,7 +415,7 @@ func TestPaymentRepository_FindByStaff(t *testing.T) {
 			CustomerID:  2,
 			StaffID:     1,
 			RentalID:    3,
-			Amount:      7.99,
+			Amount:      entity.Cents(799),
 			PaymentDate: paymentDate2,
 		},
 	}
@@ -428,7 +435,7 @@ func TestPaymentRepository_FindByStaff(t *testing.T) {
 		rows.AddRow(
 			payment.ID, payment.CreatedAt, payment.UpdatedAt, nil,
 			payment.PaymentID, payment.CustomerID, payment.StaffID,
-			payment.RentalID, payment.Amount, payment.PaymentDate,
+			payment.RentalID, payment.Amount.Decimal(), payment.PaymentDate,
 		)
 	}
 
@@ -467,35 +474,40 @@ func TestPaymentRepository_FindByRental(t *testing.T) {
 		CustomerID:  1,
 		StaffID:     1,
 		RentalID:    1,
-		Amount:      9.99,
+		Amount:      entity.Cents(999),
 		PaymentDate: paymentDate,
 	}
 	expectedPayment.ID = 1
 	expectedPayment.CreatedAt = time.Now()
 	expectedPayment.UpdatedAt = time.Now()
 
-	// Expect the SELECT query
+	// Expect the SELECT query returning the charge and a late fee
 	rows := sqlmock.NewRows([]string{
 		"id", "created_at", "updated_at", "deleted_at",
-		"payment_id", "customer_id", "staff_id", "rental_id", "amount", "payment_date",
+		"payment_id", "customer_id", "staff_id", "rental_id", "kind", "amount", "payment_date",
 	}).
 		AddRow(
 			expectedPayment.ID, expectedPayment.CreatedAt, expectedPayment.UpdatedAt, nil,
 			expectedPayment.PaymentID, expectedPayment.CustomerID, expectedPayment.StaffID,
-			expectedPayment.RentalID, expectedPayment.Amount, expectedPayment.PaymentDate,
+			expectedPayment.RentalID, "charge", expectedPayment.Amount.Decimal(), expectedPayment.PaymentDate,
+		).
+		AddRow(
+			2, expectedPayment.CreatedAt, expectedPayment.UpdatedAt, nil,
+			2, expectedPayment.CustomerID, expectedPayment.StaffID,
+			expectedPayment.RentalID, "late_fee", 3.0, expectedPayment.PaymentDate,
 		)
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `payment` WHERE rental_id = ? AND `payment`.`deleted_at` IS NULL ORDER BY `payment`.`id` LIMIT ?")).
-		WithArgs(uint(1), 1).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `payment` WHERE rental_id = ? AND `payment`.`deleted_at` IS NULL ORDER BY payment_date, payment_id")).
+		WithArgs(uint(1)).
 		WillReturnRows(rows)
 
-	payment, err := repo.FindByRental(context.Background(), 1)
+	payments, err := repo.FindByRental(context.Background(), 1)
 	if err != nil {
-		t.Errorf("Error finding payment by rental: %v", err)
+		t.Errorf("Error finding payments by rental: %v", err)
 	}
 
-	if payment.RentalID != 1 {
-		t.Errorf("Expected RentalID 1, got %d", payment.RentalID)
+	if len(payments) != 2 || payments[1].Kind != entity.PaymentLateFee {
+		t.Errorf("Expected the charge and a late fee, got %+v", payments)
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -520,7 +532,7 @@ func TestPaymentRepository_FindByDateRange(t *testing.T) {
 			CustomerID:  1,
 			StaffID:     1,
 			RentalID:    1,
-			Amount:      9.99,
+			Amount:      entity.Cents(999),
 			PaymentDate: paymentDate1,
 		},
 		{
@@ -528,7 +540,7 @@ func TestPaymentRepository_FindByDateRange(t *testing.T) {
 			CustomerID:  2,
 			StaffID:     1,
 			RentalID:    2,
-			Amount:      5.99,
+			Amount:      entity.Cents(599),
 			PaymentDate: paymentDate2,
 		},
 	}
@@ -548,7 +560,7 @@ func TestPaymentRepository_FindByDateRange(t *testing.T) {
 		rows.AddRow(
 			payment.ID, payment.CreatedAt, payment.UpdatedAt, nil,
 			payment.PaymentID, payment.CustomerID, payment.StaffID,
-			payment.RentalID, payment.Amount, payment.PaymentDate,
+			payment.RentalID, payment.Amount.Decimal(), payment.PaymentDate,
 		)
 	}
 
@@ -575,8 +587,8 @@ func TestPaymentRepository_FindByAmountRange(t *testing.T) {
 	defer cleanup()
 
 	// Define amount range
-	minAmount := 5.0
-	maxAmount := 10.0
+	minAmount := entity.Cents(500)
+	maxAmount := entity.Cents(1000)
 
 	// Define expected payments
 	paymentDate1 := time.Now()
@@ -587,7 +599,7 @@ func TestPaymentRepository_FindByAmountRange(t *testing.T) {
 			CustomerID:  1,
 			StaffID:     1,
 			RentalID:    1,
-			Amount:      9.99,
+			Amount:      entity.Cents(999),
 			PaymentDate: paymentDate1,
 		},
 		{
@@ -595,7 +607,7 @@ func TestPaymentRepository_FindByAmountRange(t *testing.T) {
 			CustomerID:  2,
 			StaffID:     1,
 			RentalID:    2,
-			Amount:      5.99,
+			Amount:      entity.Cents(599),
 			PaymentDate: paymentDate2,
 		},
 	}
@@ -615,12 +627,12 @@ func TestPaymentRepository_FindByAmountRange(t *testing.T) {
 		rows.AddRow(
 			payment.ID, payment.CreatedAt, payment.UpdatedAt, nil,
 			payment.PaymentID, payment.CustomerID, payment.StaffID,
-			payment.RentalID, payment.Amount, payment.PaymentDate,
+			payment.RentalID, payment.Amount.Decimal(), payment.PaymentDate,
 		)
 	}
 
 	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `payment` WHERE (amount BETWEEN ? AND ?) AND `payment`.`deleted_at` IS NULL")).
-		WithArgs(minAmount, maxAmount).
+		WithArgs(minAmount.Decimal(), maxAmount).
 		WillReturnRows(rows)
 
 	payments, err := repo.FindByAmountRange(context.Background(), minAmount, maxAmount)
@@ -642,14 +654,14 @@ func TestPaymentRepository_GetTotalPaymentsByCustomer(t *testing.T) {
 	defer cleanup()
 
 	// Define expected total
-	expectedTotal := 15.98
+	expectedTotal := entity.Cents(1598)
 
 	// Expect the SELECT query
-	rows := sqlmock.NewRows([]string{"SUM(amount)"}).
-		AddRow(expectedTotal)
+	rows := sqlmock.NewRows([]string{"total"}).
+		AddRow(expectedTotal.Decimal())
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT SUM(amount) FROM `payment` WHERE customer_id = ? AND `payment`.`deleted_at` IS NULL")).
-		WithArgs(uint(1)).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(CASE WHEN payment.kind = ? THEN -payment.amount ELSE payment.amount END), 0) FROM `payment` WHERE customer_id = ? AND `payment`.`deleted_at` IS NULL")).
+		WithArgs(entity.PaymentRefund, uint(1)).
 		WillReturnRows(rows)
 
 	total, err := repo.GetTotalPaymentsByCustomer(context.Background(), 1)
@@ -658,7 +670,7 @@ func TestPaymentRepository_GetTotalPaymentsByCustomer(t *testing.T) {
 	}
 
 	if total != expectedTotal {
-		t.Errorf("Expected total %f, got %f", expectedTotal, total)
+		t.Errorf("Expected total %s, got %s", expectedTotal, total)
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -671,14 +683,14 @@ func TestPaymentRepository_GetTotalPaymentsByStore(t *testing.T) {
 	defer cleanup()
 
 	// Define expected total
-	expectedTotal := 25.97
+	expectedTotal := entity.Cents(2597)
 
 	// Expect the SELECT query
-	rows := sqlmock.NewRows([]string{"SUM(payment.amount)"}).
-		AddRow(expectedTotal)
+	rows := sqlmock.NewRows([]string{"total"}).
+		AddRow(expectedTotal.Decimal())
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT SUM(payment.amount) FROM `payment` JOIN staff ON payment.staff_id = staff.staff_id WHERE staff.store_id = ? AND `payment`.`deleted_at` IS NULL")).
-		WithArgs(uint(1)).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(CASE WHEN payment.kind = ? THEN -payment.amount ELSE payment.amount END), 0) FROM `payment` JOIN staff ON payment.staff_id = staff.staff_id WHERE staff.store_id = ? AND `payment`.`deleted_at` IS NULL")).
+		WithArgs(entity.PaymentRefund, uint(1)).
 		WillReturnRows(rows)
 
 	total, err := repo.GetTotalPaymentsByStore(context.Background(), 1)
@@ -687,7 +699,7 @@ func TestPaymentRepository_GetTotalPaymentsByStore(t *testing.T) {
 	}
 
 	if total != expectedTotal {
-		t.Errorf("Expected total %f, got %f", expectedTotal, total)
+		t.Errorf("Expected total %s, got %s", expectedTotal, total)
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -713,3 +725,101 @@ func TestPaymentRepository_FindByID_NotFound(t *testing.T) {
 		t.Errorf("Unfulfilled expectations: %v", err)
 	}
 }
+
+var balanceColumns = []string{"rental_id", "paid", "refunded", "net"}
+
+func TestPaymentRepository_GetRentalBalance(t *testing.T) {
+	_, mock, repo, cleanup := setupPaymentTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT payment.rental_id, COALESCE(SUM(CASE WHEN payment.kind <> ? THEN payment.amount ELSE 0 END), 0) AS paid, COALESCE(SUM(CASE WHEN payment.kind = ? THEN payment.amount ELSE 0 END), 0) AS refunded, COALESCE(SUM(CASE WHEN payment.kind = ? THEN -payment.amount ELSE payment.amount END), 0) AS net FROM `payment` WHERE payment.rental_id = ? AND `payment`.`deleted_at` IS NULL GROUP BY `payment`.`rental_id`")).
+		WithArgs(entity.PaymentRefund, entity.PaymentRefund, entity.PaymentRefund, 1).
+		WillReturnRows(sqlmock.NewRows(balanceColumns).AddRow(1, "12.50", "2.50", "10.00"))
+
+	balance, err := repo.GetRentalBalance(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Error getting rental balance: %v", err)
+	}
+	if *balance != (RentalBalance{RentalID: 1, Paid: entity.Cents(1250), Refunded: entity.Cents(250), Net: entity.Cents(1000)}) {
+		t.Errorf("Unexpected balance %+v", balance)
+	}
+
+	// A rental without payments has nothing paid
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT payment.rental_id")).
+		WithArgs(entity.PaymentRefund, entity.PaymentRefund, entity.PaymentRefund, 2).
+		WillReturnRows(sqlmock.NewRows(balanceColumns))
+
+	balance, err = repo.GetRentalBalance(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Error getting rental balance: %v", err)
+	}
+	if *balance != (RentalBalance{RentalID: 2}) {
+		t.Errorf("Expected an empty balance, got %+v", balance)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPaymentRepository_GetRentalBalancesByCustomer(t *testing.T) {
+	_, mock, repo, cleanup := setupPaymentTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT payment.rental_id")+".*"+regexp.QuoteMeta("FROM `payment` WHERE payment.customer_id = ? AND `payment`.`deleted_at` IS NULL GROUP BY `payment`.`rental_id` ORDER BY payment.rental_id")).
+		WithArgs(entity.PaymentRefund, entity.PaymentRefund, entity.PaymentRefund, 1).
+		WillReturnRows(sqlmock.NewRows(balanceColumns).AddRow(1, 4.99, 0, 4.99).AddRow(3, 2.99, 2.99, 0))
+
+	balances, err := repo.GetRentalBalancesByCustomer(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Error getting rental balances: %v", err)
+	}
+	if len(balances) != 2 || balances[1].RentalID != 3 || balances[1].Net != entity.Cents(0) {
+		t.Errorf("Unexpected balances %+v", balances)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPaymentRepository_Refund(t *testing.T) {
+	_, mock, repo, cleanup := setupPaymentTest(t)
+	defer cleanup()
+
+	refund := &entity.Payment{CustomerID: 1, StaffID: 1, RentalID: 1, Amount: entity.Cents(250), PaymentDate: time.Now()}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `rental_id` FROM `rental` WHERE rental_id = ? AND `rental`.`deleted_at` IS NULL LIMIT ? FOR UPDATE")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"rental_id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT payment.rental_id")).
+		WithArgs(entity.PaymentRefund, entity.PaymentRefund, entity.PaymentRefund, 1).
+		WillReturnRows(sqlmock.NewRows(balanceColumns).AddRow(1, 12.5, 0, 12.5))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `payment`")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 1, 1, 1, entity.PaymentRefund, entity.Cents(250), refund.PaymentDate).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Refund(context.Background(), refund); err != nil {
+		t.Fatalf("Error refunding: %v", err)
+	}
+
+	// Refunding more than was paid is rejected
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).
+		WillReturnRows(sqlmock.NewRows([]string{"rental_id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT payment.rental_id")).
+		WillReturnRows(sqlmock.NewRows(balanceColumns).AddRow(1, "12.50", "2.50", "10.00"))
+	mock.ExpectRollback()
+
+	var validationErr *ValidationError
+	overcharge := &entity.Payment{CustomerID: 1, StaffID: 1, RentalID: 1, Amount: entity.Cents(1001), PaymentDate: time.Now()}
+	if err := repo.Refund(context.Background(), overcharge); !errors.As(err, &validationErr) || validationErr.Fields[0].Param != "10.00" {
+		t.Errorf("Expected the refund to be capped at 10.00, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
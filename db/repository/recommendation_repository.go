@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"CortexMCP/db/entity"
+	"context"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// defaultRecommendationLimit is used when recommendations are requested without a positive limit
+const defaultRecommendationLimit = 10
+
+// FilmRecommendation is a recommended film with its similarity score and the number of copies
+// available at the customer's store
+type FilmRecommendation struct {
+	Film            entity.Film `json:"film"`
+	Score           float64     `json:"score"`
+	AvailableCopies int         `json:"availableCopies"`
+}
+
+// RecommendationWeights controls how much each similarity signal contributes to a film's score.
+// Every signal is normalized to [0, 1] before weighting.
+type RecommendationWeights struct {
+	// CoRental weighs how many other customers rented the film alongside the customer's films
+	CoRental float64
+
+	// SharedActors weighs how many actors the film shares with the customer's films
+	SharedActors float64
+
+	// SharedCategory weighs how many of the customer's films are in the film's category
+	SharedCategory float64
+}
+
+// DefaultRecommendationWeights favours co-rental behaviour over catalog metadata
+var DefaultRecommendationWeights = RecommendationWeights{
+	CoRental:       1.0,
+	SharedActors:   0.5,
+	SharedCategory: 0.25,
+}
+
+// RecommendationRepository is an interface for film recommendations
+type RecommendationRepository interface {
+	// RecommendForCustomer returns the top films the customer has not rented yet, ranked by similarity
+	// to the films they have rented and limited to films with copies available at their store
+	RecommendForCustomer(ctx context.Context, customerID uint, limit int) ([]FilmRecommendation, error)
+}
+
+// RecommendationRepositoryImpl is an implementation of RecommendationRepository
+type RecommendationRepositoryImpl struct {
+	DB        *gorm.DB
+	Inventory InventoryRepository
+	Weights   RecommendationWeights
+}
+
+// NewRecommendationRepository creates a new RecommendationRepository
+func NewRecommendationRepository(db *gorm.DB) RecommendationRepository {
+	return &RecommendationRepositoryImpl{
+		DB:        db,
+		Inventory: NewInventoryRepository(db),
+		Weights:   DefaultRecommendationWeights,
+	}
+}
+
+// filmWeight is a per-film aggregate of a similarity signal
+type filmWeight struct {
+	FilmID uint
+	Weight float64
+}
+
+const coRentalQuery = `SELECT i2.film_id AS film_id, COUNT(DISTINCT r2.customer_id) AS weight
+FROM rental r1
+JOIN inventory i1 ON i1.inventory_id = r1.inventory_id
+JOIN rental r2 ON r2.customer_id = r1.customer_id
+JOIN inventory i2 ON i2.inventory_id = r2.inventory_id
+WHERE i1.film_id IN ? AND r1.customer_id <> ? AND i2.film_id NOT IN ?
+AND r1.deleted_at IS NULL AND i1.deleted_at IS NULL AND r2.deleted_at IS NULL AND i2.deleted_at IS NULL
+GROUP BY i2.film_id`
+
+const sharedActorsQuery = `SELECT fa2.film_id AS film_id, COUNT(*) AS weight
+FROM film_actors fa1
+JOIN film_actors fa2 ON fa2.actor_id = fa1.actor_id
+WHERE fa1.film_id IN ? AND fa2.film_id NOT IN ?
+GROUP BY fa2.film_id`
+
+const sharedCategoryQuery = `SELECT f2.film_id AS film_id, COUNT(*) AS weight
+FROM film f1
+JOIN film f2 ON f2.category_id = f1.category_id
+WHERE f1.film_id IN ? AND f2.film_id NOT IN ?
+GROUP BY f2.film_id`
+
+const popularityQuery = `SELECT inventory.film_id AS film_id, COUNT(*) AS weight
+FROM rental
+JOIN inventory ON inventory.inventory_id = rental.inventory_id
+WHERE rental.deleted_at IS NULL AND inventory.deleted_at IS NULL
+GROUP BY inventory.film_id`
+
+// RecommendForCustomer returns the top films the customer has not rented yet, ranked by similarity
+// to the films they have rented and limited to films with copies available at their store.
+// Customers without rentals get the most rented films instead.
+func (r *RecommendationRepositoryImpl) RecommendForCustomer(ctx context.Context, customerID uint, limit int) ([]FilmRecommendation, error) {
+	if limit <= 0 {
+		limit = defaultRecommendationLimit
+	}
+	db := r.DB.WithContext(ctx)
+
+	var customer entity.Customer
+	if err := db.Where("customer_id = ?", customerID).First(&customer).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	var seen []uint
+	if err := db.Model(&entity.Rental{}).
+		Joins("JOIN inventory ON inventory.inventory_id = rental.inventory_id").
+		Where("rental.customer_id = ?", customerID).
+		Distinct().
+		Pluck("inventory.film_id", &seen).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	scores := make(map[uint]float64)
+	if len(seen) == 0 {
+		if err := r.addSignal(db, scores, 1, popularityQuery); err != nil {
+			return nil, err
+		}
+	} else {
+		signals := []struct {
+			weight float64
+			query  string
+			args   []interface{}
+		}{
+			{r.Weights.CoRental, coRentalQuery, []interface{}{seen, customerID, seen}},
+			{r.Weights.SharedActors, sharedActorsQuery, []interface{}{seen, seen}},
+			{r.Weights.SharedCategory, sharedCategoryQuery, []interface{}{seen, seen}},
+		}
+		for _, signal := range signals {
+			if err := r.addSignal(db, scores, signal.weight, signal.query, signal.args...); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	available, err := r.Inventory.FindAvailableByStore(ctx, customer.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	copies := make(map[uint]int)
+	for _, item := range available {
+		copies[item.FilmID]++
+	}
+
+	excluded := make(map[uint]bool, len(seen))
+	for _, filmID := range seen {
+		excluded[filmID] = true
+	}
+	candidates := make([]filmWeight, 0, len(scores))
+	for filmID, score := range scores {
+		if score > 0 && copies[filmID] > 0 && !excluded[filmID] {
+			candidates = append(candidates, filmWeight{FilmID: filmID, Weight: score})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Weight != candidates[j].Weight {
+			return candidates[i].Weight > candidates[j].Weight
+		}
+		return candidates[i].FilmID < candidates[j].FilmID
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	if len(candidates) == 0 {
+		return []FilmRecommendation{}, nil
+	}
+
+	ids := make([]uint, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.FilmID
+	}
+	var films []entity.Film
+	if err := db.Where("film_id IN ?", ids).Find(&films).Error; err != nil {
+		return nil, translateError(err)
+	}
+	byID := make(map[uint]entity.Film, len(films))
+	for _, film := range films {
+		byID[film.FilmID] = film
+	}
+
+	recommendations := make([]FilmRecommendation, 0, len(candidates))
+	for _, candidate := range candidates {
+		if film, ok := byID[candidate.FilmID]; ok {
+			recommendations = append(recommendations, FilmRecommendation{
+				Film:            film,
+				Score:           candidate.Weight,
+				AvailableCopies: copies[candidate.FilmID],
+			})
+		}
+	}
+	return recommendations, nil
+}
+
+// addSignal runs a per-film aggregate query and adds its weights, normalized by the largest weight, to scores
+func (r *RecommendationRepositoryImpl) addSignal(db *gorm.DB, scores map[uint]float64, weight float64, query string, args ...interface{}) error {
+	if weight == 0 {
+		return nil
+	}
+
+	var rows []filmWeight
+	if err := db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		return translateError(err)
+	}
+
+	var highest float64
+	for _, row := range rows {
+		if row.Weight > highest {
+			highest = row.Weight
+		}
+	}
+	if highest == 0 {
+		return nil
+	}
+	for _, row := range rows {
+		scores[row.FilmID] += weight * row.Weight / highest
+	}
+	return nil
+}
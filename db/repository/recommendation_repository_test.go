@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupRecommendationTest(t *testing.T) (*sql.DB, sqlmock.Sqlmock, RecommendationRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+
+	dialector := mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	})
+
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open gorm database: %v", err)
+	}
+
+	repo := NewRecommendationRepository(gormDB)
+
+	return db, mock, repo, func() {
+		db.Close()
+	}
+}
+
+func expectRecommendationCustomer(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer` WHERE customer_id = ?")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id", "store_id"}).AddRow(1, 1, 1))
+}
+
+func TestRecommendationRepository_RecommendForCustomer(t *testing.T) {
+	_, mock, repo, cleanup := setupRecommendationTest(t)
+	defer cleanup()
+
+	expectRecommendationCustomer(mock)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `inventory`.`film_id` FROM `rental` JOIN inventory ON inventory.inventory_id = rental.inventory_id WHERE rental.customer_id = ?")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"film_id"}).AddRow(1))
+
+	mock.ExpectQuery(regexp.QuoteMeta("COUNT(DISTINCT r2.customer_id)")+".*"+
+		regexp.QuoteMeta("AND r1.deleted_at IS NULL AND i1.deleted_at IS NULL AND r2.deleted_at IS NULL AND i2.deleted_at IS NULL")).
+		WithArgs(1, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"film_id", "weight"}).AddRow(2, 3).AddRow(3, 1))
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM film_actors fa1")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"film_id", "weight"}).AddRow(3, 2).AddRow(4, 1))
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM film f1")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"film_id", "weight"}).AddRow(2, 1).AddRow(5, 1))
+
+	mock.ExpectQuery(regexp.QuoteMeta("LEFT JOIN rental ON rental.inventory_id = inventory.inventory_id")).
+		WithArgs("reserved", "out", "lost", 1, "active", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"inventory_id", "film_id", "store_id"}).
+			AddRow(10, 1, 1).
+			AddRow(11, 2, 1).
+			AddRow(12, 3, 1).
+			AddRow(13, 3, 1).
+			AddRow(14, 5, 1))
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `film` WHERE film_id IN (?,?)")).
+		WithArgs(2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"film_id", "title"}).
+			AddRow(2, "Film Two").
+			AddRow(3, "Film Three"))
+
+	recommendations, err := repo.RecommendForCustomer(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatalf("Error recommending films: %v", err)
+	}
+
+	if len(recommendations) != 2 {
+		t.Fatalf("Expected 2 recommendations, got %d", len(recommendations))
+	}
+
+	// Film 2: 1.0*3/3 (co-rental) + 0.25*1/1 (category); film 3: 1.0*1/3 (co-rental) + 0.5*2/2 (actors).
+	// Film 4 has no available copy and film 1 was already rented.
+	if recommendations[0].Film.FilmID != 2 || recommendations[1].Film.FilmID != 3 {
+		t.Errorf("Expected films 2 then 3, got %d then %d", recommendations[0].Film.FilmID, recommendations[1].Film.FilmID)
+	}
+
+	if recommendations[1].AvailableCopies != 2 {
+		t.Errorf("Expected 2 available copies of film 3, got %d", recommendations[1].AvailableCopies)
+	}
+
+	if recommendations[0].Score <= recommendations[1].Score {
+		t.Errorf("Expected descending scores, got %f then %f", recommendations[0].Score, recommendations[1].Score)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestRecommendationRepository_RecommendForCustomer_ColdStart(t *testing.T) {
+	_, mock, repo, cleanup := setupRecommendationTest(t)
+	defer cleanup()
+
+	expectRecommendationCustomer(mock)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `inventory`.`film_id`")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"film_id"}))
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE rental.deleted_at IS NULL AND inventory.deleted_at IS NULL GROUP BY inventory.film_id")).
+		WillReturnRows(sqlmock.NewRows([]string{"film_id", "weight"}).AddRow(7, 10).AddRow(8, 4))
+
+	mock.ExpectQuery(regexp.QuoteMeta("LEFT JOIN rental ON rental.inventory_id = inventory.inventory_id")).
+		WithArgs("reserved", "out", "lost", 1, "active", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"inventory_id", "film_id", "store_id"}).AddRow(20, 8, 1))
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `film` WHERE film_id IN (?)")).
+		WithArgs(8).
+		WillReturnRows(sqlmock.NewRows([]string{"film_id", "title"}).AddRow(8, "Film Eight"))
+
+	recommendations, err := repo.RecommendForCustomer(context.Background(), 1, 0)
+	if err != nil {
+		t.Fatalf("Error recommending films: %v", err)
+	}
+
+	if len(recommendations) != 1 || recommendations[0].Film.FilmID != 8 {
+		t.Errorf("Expected only the available popular film 8, got %+v", recommendations)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -12,25 +12,37 @@ type RentalRepository interface {
 	Repository[entity.Rental]
 
 	// FindByCustomer finds rentals by customer ID
-	FindByCustomer(ctx context.Context, customerID uint) ([]entity.Rental, error)
+	FindByCustomer(ctx context.Context, customerID uint, opts ...QueryOption) ([]entity.Rental, error)
 
 	// FindByStaff finds rentals by staff ID
-	FindByStaff(ctx context.Context, staffID uint) ([]entity.Rental, error)
+	FindByStaff(ctx context.Context, staffID uint, opts ...QueryOption) ([]entity.Rental, error)
 
 	// FindByInventory finds rentals by inventory ID
-	FindByInventory(ctx context.Context, inventoryID uint) ([]entity.Rental, error)
+	FindByInventory(ctx context.Context, inventoryID uint, opts ...QueryOption) ([]entity.Rental, error)
 
 	// FindByDateRange finds rentals within a date range
-	FindByDateRange(ctx context.Context, startDate, endDate time.Time) ([]entity.Rental, error)
+	FindByDateRange(ctx context.Context, startDate, endDate time.Time, opts ...QueryOption) ([]entity.Rental, error)
 
-	// FindOverdue finds overdue rentals (no return date and rental date is older than specified days)
-	FindOverdue(ctx context.Context, daysOverdue int) ([]entity.Rental, error)
+	// FindOverdue finds overdue rentals (still out and rental date is older than specified days)
+	FindOverdue(ctx context.Context, daysOverdue int, opts ...QueryOption) ([]entity.Rental, error)
 
-	// FindReturned finds rentals that have been returned
-	FindReturned(ctx context.Context) ([]entity.Rental, error)
+	// FindReturned finds rentals whose copy has been returned, damaged or not
+	FindReturned(ctx context.Context, opts ...QueryOption) ([]entity.Rental, error)
 
-	// FindNotReturned finds rentals that have not been returned
-	FindNotReturned(ctx context.Context) ([]entity.Rental, error)
+	// FindNotReturned finds rentals whose copy is still out or lost
+	FindNotReturned(ctx context.Context, opts ...QueryOption) ([]entity.Rental, error)
+
+	// FindByStatus finds rentals in any of the given states
+	FindByStatus(ctx context.Context, statuses []entity.RentalStatus, opts ...QueryOption) ([]entity.Rental, error)
+
+	// Transition moves a rental to another state, recording the time, the principal in ctx and an optional note.
+	// It fails with ErrStaleEntity unless the rental still has the given version, and with a *ValidationError
+	// when the state machine does not allow the transition.
+	Transition(ctx context.Context, rentalID, version uint, to entity.RentalStatus, note string) (*entity.Rental, error)
+
+	// MarkLost declares the copy of a rental that is still out lost, takes the copy out of circulation and
+	// charges the customer fee for it in one transaction. It returns the rental with its replacement payment.
+	MarkLost(ctx context.Context, rentalID uint, fee entity.Money, note string) (*entity.Rental, error)
 }
 
 // RentalRepositoryImpl is an implementation of RentalRepository
@@ -42,73 +54,173 @@ type RentalRepositoryImpl struct {
 func NewRentalRepository(db *gorm.DB) RentalRepository {
 	return &RentalRepositoryImpl{
 		BaseRepository: BaseRepository[entity.Rental]{
-			DB: db,
+			DB:           db,
+			Associations: []string{"Inventory", "Inventory.Film", "Customer", "Staff", "Payments", "Transitions"},
 		},
 	}
 }
 
 // FindByCustomer finds rentals by customer ID
-func (r *RentalRepositoryImpl) FindByCustomer(ctx context.Context, customerID uint) ([]entity.Rental, error) {
+func (r *RentalRepositoryImpl) FindByCustomer(ctx context.Context, customerID uint, opts ...QueryOption) ([]entity.Rental, error) {
 	var rentals []entity.Rental
-	if err := r.DB.WithContext(ctx).Where("customer_id = ?", customerID).Find(&rentals).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("customer_id = ?", customerID).Find(&rentals).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return rentals, nil
 }
 
 // FindByStaff finds rentals by staff ID
-func (r *RentalRepositoryImpl) FindByStaff(ctx context.Context, staffID uint) ([]entity.Rental, error) {
+func (r *RentalRepositoryImpl) FindByStaff(ctx context.Context, staffID uint, opts ...QueryOption) ([]entity.Rental, error) {
 	var rentals []entity.Rental
-	if err := r.DB.WithContext(ctx).Where("staff_id = ?", staffID).Find(&rentals).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("staff_id = ?", staffID).Find(&rentals).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return rentals, nil
 }
 
 // FindByInventory finds rentals by inventory ID
-func (r *RentalRepositoryImpl) FindByInventory(ctx context.Context, inventoryID uint) ([]entity.Rental, error) {
+func (r *RentalRepositoryImpl) FindByInventory(ctx context.Context, inventoryID uint, opts ...QueryOption) ([]entity.Rental, error) {
 	var rentals []entity.Rental
-	if err := r.DB.WithContext(ctx).Where("inventory_id = ?", inventoryID).Find(&rentals).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("inventory_id = ?", inventoryID).Find(&rentals).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return rentals, nil
 }
 
 // FindByDateRange finds rentals within a date range
-func (r *RentalRepositoryImpl) FindByDateRange(ctx context.Context, startDate, endDate time.Time) ([]entity.Rental, error) {
+func (r *RentalRepositoryImpl) FindByDateRange(ctx context.Context, startDate, endDate time.Time, opts ...QueryOption) ([]entity.Rental, error) {
 	var rentals []entity.Rental
-	if err := r.DB.WithContext(ctx).Where("rental_date BETWEEN ? AND ?", startDate, endDate).Find(&rentals).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("rental_date BETWEEN ? AND ?", startDate, endDate).Find(&rentals).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return rentals, nil
 }
 
-// FindOverdue finds overdue rentals (no return date and rental date is older than specified days)
-func (r *RentalRepositoryImpl) FindOverdue(ctx context.Context, daysOverdue int) ([]entity.Rental, error) {
+// FindOverdue finds overdue rentals (still out and rental date is older than specified days)
+func (r *RentalRepositoryImpl) FindOverdue(ctx context.Context, daysOverdue int, opts ...QueryOption) ([]entity.Rental, error) {
 	var rentals []entity.Rental
 	overdueCutoff := time.Now().AddDate(0, 0, -daysOverdue)
-	if err := r.DB.WithContext(ctx).
-		Where("return_date IS NULL AND rental_date < ?", overdueCutoff).
+	if err := r.query(ctx, opts).
+		Where("status = ? AND rental_date < ?", entity.RentalOut, overdueCutoff).
 		Find(&rentals).Error; err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return rentals, nil
 }
 
-// FindReturned finds rentals that have been returned
-func (r *RentalRepositoryImpl) FindReturned(ctx context.Context) ([]entity.Rental, error) {
+// FindReturned finds rentals whose copy has been returned, damaged or not
+func (r *RentalRepositoryImpl) FindReturned(ctx context.Context, opts ...QueryOption) ([]entity.Rental, error) {
+	return r.FindByStatus(ctx, []entity.RentalStatus{entity.RentalReturned, entity.RentalDamaged}, opts...)
+}
+
+// FindNotReturned finds rentals whose copy is still out or lost
+func (r *RentalRepositoryImpl) FindNotReturned(ctx context.Context, opts ...QueryOption) ([]entity.Rental, error) {
+	return r.FindByStatus(ctx, []entity.RentalStatus{entity.RentalOut, entity.RentalLost}, opts...)
+}
+
+// FindByStatus finds rentals in any of the given states
+func (r *RentalRepositoryImpl) FindByStatus(ctx context.Context, statuses []entity.RentalStatus, opts ...QueryOption) ([]entity.Rental, error) {
 	var rentals []entity.Rental
-	if err := r.DB.WithContext(ctx).Where("return_date IS NOT NULL").Find(&rentals).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("status IN ?", statuses).Find(&rentals).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return rentals, nil
 }
 
-// FindNotReturned finds rentals that have not been returned
-func (r *RentalRepositoryImpl) FindNotReturned(ctx context.Context) ([]entity.Rental, error) {
-	var rentals []entity.Rental
-	if err := r.DB.WithContext(ctx).Where("return_date IS NULL").Find(&rentals).Error; err != nil {
-		return nil, err
+// Transition moves a rental to another state. Checking a reserved rental out sets its rental date, and
+// returning its copy, damaged or not, sets its return date unless it already has one. A copy returned
+// undamaged goes back into circulation if it was lost, and is held for the next customer on the waitlist of
+// its film and store.
+func (r *RentalRepositoryImpl) Transition(ctx context.Context, rentalID, version uint, to entity.RentalStatus, note string) (*entity.Rental, error) {
+	var rental entity.Rental
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("rental_id = ?", rentalID).First(&rental).Error; err != nil {
+			return err
+		}
+		if rental.Version != version {
+			return ErrStaleEntity
+		}
+		return transition(tx, &rental, to, note, time.Now())
+	})
+	if err != nil {
+		return nil, translateError(err)
 	}
-	return rentals, nil
+	return &rental, nil
+}
+
+// MarkLost declares the copy of a rental that is still out lost: the rental moves to lost, the copy is taken
+// out of circulation by soft-deleting it, and the customer is charged fee as a replacement payment line, all in
+// one transaction.
+func (r *RentalRepositoryImpl) MarkLost(ctx context.Context, rentalID uint, fee entity.Money, note string) (*entity.Rental, error) {
+	var rental entity.Rental
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("rental_id = ?", rentalID).First(&rental).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := transition(tx, &rental, entity.RentalLost, note, now); err != nil {
+			return err
+		}
+		if err := tx.Where("inventory_id = ?", rental.InventoryID).Delete(&entity.Inventory{}).Error; err != nil {
+			return err
+		}
+
+		payment := entity.Payment{
+			CustomerID:  rental.CustomerID,
+			StaffID:     rental.StaffID,
+			RentalID:    rentalID,
+			Kind:        entity.PaymentReplacement,
+			Amount:      fee,
+			PaymentDate: now,
+		}
+		if err := validateEntity(&payment); err != nil {
+			return err
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+		rental.Payments = append(rental.Payments, payment)
+		return nil
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &rental, nil
+}
+
+// transition moves rental to state to within tx and records the transition made at the given time
+func transition(tx *gorm.DB, rental *entity.Rental, to entity.RentalStatus, note string, now time.Time) error {
+	from := rental.Status
+	if !from.CanTransition(to) {
+		return &ValidationError{Fields: []FieldError{{Field: "status", Rule: "transition", Param: string(from) + " to " + string(to)}}}
+	}
+
+	rental.Status = to
+	if to == entity.RentalOut {
+		rental.RentalDate = now
+	}
+	if to.Returned() && rental.ReturnDate == nil {
+		rental.ReturnDate = &now
+	}
+	if err := validateEntity(rental); err != nil {
+		return err
+	}
+	if err := updateVersioned(tx, rental, &rental.Versioned); err != nil {
+		return err
+	}
+	if err := tx.Create(entity.NewRentalTransition(tx.Statement.Context, rental.RentalID, from, to, note, now)).Error; err != nil {
+		return err
+	}
+	if to != entity.RentalReturned {
+		return nil
+	}
+	if from == entity.RentalLost {
+		err := tx.Unscoped().Model(&entity.Inventory{}).
+			Where("inventory_id = ? AND deleted_at IS NOT NULL", rental.InventoryID).
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+	}
+	return holdForNextInLine(tx, rental.InventoryID, now)
 }
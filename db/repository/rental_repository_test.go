@@ -4,6 +4,7 @@ import (
 	"CortexMCP/db/entity"
 	"context"
 	"database/sql"
+	"errors"
 	"regexp"
 	"testing"
 	"time"
@@ -57,14 +58,18 @@ func TestRentalRepository_Create(t *testing.T) {
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
 			sqlmock.AnyArg(), // DeletedAt
-			rental.RentalID,
+			1,                // Version
 			rental.RentalDate,
 			rental.InventoryID,
 			rental.CustomerID,
 			rental.ReturnDate,
 			rental.StaffID,
+			entity.RentalReturned,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `rental_transition`")).
+		WithArgs(sqlmock.AnyArg(), "", entity.RentalReturned, "", "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
 	err := repo.Create(context.Background(), rental)
@@ -72,6 +77,10 @@ func TestRentalRepository_Create(t *testing.T) {
 		t.Errorf("Error creating rental: %v", err)
 	}
 
+	if rental.Status != entity.RentalReturned {
+		t.Errorf("Expected a rental with a return date to start out returned, got %q", rental.Status)
+	}
+
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("Unfulfilled expectations: %v", err)
 	}
@@ -107,8 +116,8 @@ func TestRentalRepository_FindByID(t *testing.T) {
 			expectedRental.CustomerID, expectedRental.ReturnDate, expectedRental.StaffID,
 		)
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE `rental`.`id` = ? AND `rental`.`deleted_at` IS NULL ORDER BY `rental`.`id` LIMIT 1")).
-		WithArgs(1).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE `rental`.`id` = ? AND `rental`.`deleted_at` IS NULL ORDER BY `rental`.`id` LIMIT ?")).
+		WithArgs(1, 1).
 		WillReturnRows(rows)
 
 	rental, err := repo.FindByID(context.Background(), 1)
@@ -231,21 +240,25 @@ func TestRentalRepository_Update(t *testing.T) {
 		StaffID:     1,
 	}
 	rental.ID = 1
+	rental.Version = 1
 
 	// Expect the UPDATE query
 	mock.ExpectBegin()
-	mock.ExpectExec("UPDATE").
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `rental` SET ")+".* WHERE version = \\?").
 		WithArgs(
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
 			sqlmock.AnyArg(), // DeletedAt
-			rental.RentalID,
+			2,                // Version
 			rental.RentalDate,
 			rental.InventoryID,
 			rental.CustomerID,
 			rental.ReturnDate,
 			rental.StaffID,
+			entity.RentalReturned,
+			1, // expected Version
 			rental.ID,
+			rental.RentalID,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
@@ -278,6 +291,7 @@ func TestRentalRepository_Delete(t *testing.T) {
 		WithArgs(
 			sqlmock.AnyArg(), // DeletedAt
 			rental.ID,
+			rental.RentalID,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
@@ -578,7 +592,7 @@ func TestRentalRepository_FindByDateRange(t *testing.T) {
 		)
 	}
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE rental_date BETWEEN ? AND ? AND `rental`.`deleted_at` IS NULL")).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE (rental_date BETWEEN ? AND ?) AND `rental`.`deleted_at` IS NULL")).
 		WithArgs(startDate, endDate).
 		WillReturnRows(rows)
 
@@ -641,8 +655,8 @@ func TestRentalRepository_FindOverdue(t *testing.T) {
 		)
 	}
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE return_date IS NULL AND rental_date < ? AND `rental`.`deleted_at` IS NULL")).
-		WithArgs(sqlmock.AnyArg()).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE (status = ? AND rental_date < ?) AND `rental`.`deleted_at` IS NULL")).
+		WithArgs(entity.RentalOut, sqlmock.AnyArg()).
 		WillReturnRows(rows)
 
 	rentals, err := repo.FindOverdue(context.Background(), 7) // Overdue if rented more than 7 days ago
@@ -712,7 +726,8 @@ func TestRentalRepository_FindReturned(t *testing.T) {
 		)
 	}
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE return_date IS NOT NULL AND `rental`.`deleted_at` IS NULL")).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE status IN (?,?) AND `rental`.`deleted_at` IS NULL")).
+		WithArgs(entity.RentalReturned, entity.RentalDamaged).
 		WillReturnRows(rows)
 
 	rentals, err := repo.FindReturned(context.Background())
@@ -780,7 +795,8 @@ func TestRentalRepository_FindNotReturned(t *testing.T) {
 		)
 	}
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE return_date IS NULL AND `rental`.`deleted_at` IS NULL")).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE status IN (?,?) AND `rental`.`deleted_at` IS NULL")).
+		WithArgs(entity.RentalOut, entity.RentalLost).
 		WillReturnRows(rows)
 
 	rentals, err := repo.FindNotReturned(context.Background())
@@ -802,3 +818,181 @@ func TestRentalRepository_FindNotReturned(t *testing.T) {
 		t.Errorf("Unfulfilled expectations: %v", err)
 	}
 }
+
+func TestRentalRepository_Transition(t *testing.T) {
+	_, mock, repo, cleanup := setupRentalTest(t)
+	defer cleanup()
+
+	rentalDate := time.Now().Add(-time.Hour * 24 * 3)
+	rows := sqlmock.NewRows([]string{"id", "version", "rental_id", "rental_date", "inventory_id", "customer_id", "return_date", "staff_id", "status"}).
+		AddRow(1, 2, 1, rentalDate, 1, 1, nil, 1, "out")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE rental_id = ? AND `rental`.`deleted_at` IS NULL ORDER BY `rental`.`id` LIMIT ?")).
+		WithArgs(1, 1).
+		WillReturnRows(rows)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `rental` SET ")+".*`status`=\\? WHERE version = \\?").
+		WithArgs(
+			sqlmock.AnyArg(), // CreatedAt
+			sqlmock.AnyArg(), // UpdatedAt
+			sqlmock.AnyArg(), // DeletedAt
+			3,                // Version
+			rentalDate,
+			1,                // InventoryID
+			1,                // CustomerID
+			sqlmock.AnyArg(), // ReturnDate
+			1,                // StaffID
+			entity.RentalReturned,
+			2, // expected Version
+			1, // ID
+			1, // RentalID
+		).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `rental_transition`")).
+		WithArgs(1, entity.RentalOut, entity.RentalReturned, "", "dropped off", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `inventory` WHERE inventory_id = ? AND `inventory`.`deleted_at` IS NULL LIMIT ?")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"inventory_id", "film_id", "store_id"}).AddRow(1, 3, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `waitlist` WHERE (film_id = ? AND store_id = ? AND status = ?) AND `waitlist`.`deleted_at` IS NULL ORDER BY created_at, waitlist_id LIMIT ?")).
+		WithArgs(3, 1, entity.WaitlistWaiting, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"waitlist_id"}))
+	mock.ExpectCommit()
+
+	rental, err := repo.Transition(context.Background(), 1, 2, entity.RentalReturned, "dropped off")
+	if err != nil {
+		t.Fatalf("Error returning rental: %v", err)
+	}
+	if rental.Status != entity.RentalReturned || rental.ReturnDate == nil || rental.Version != 3 {
+		t.Errorf("Expected a returned rental with a return date at version 3, got %+v", rental)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestRentalRepository_Transition_Rejected(t *testing.T) {
+	_, mock, repo, cleanup := setupRentalTest(t)
+	defer cleanup()
+
+	rentalDate := time.Now().Add(-time.Hour * 24 * 3)
+	returnDate := time.Now()
+	for range 2 {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE rental_id = ?")).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "version", "rental_id", "rental_date", "inventory_id", "customer_id", "return_date", "staff_id", "status"}).
+				AddRow(1, 2, 1, rentalDate, 1, 1, returnDate, 1, "returned"))
+		mock.ExpectRollback()
+	}
+
+	// A returned copy cannot go back out
+	_, err := repo.Transition(context.Background(), 1, 2, entity.RentalOut, "")
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Fields[0].Rule != "transition" || validationErr.Fields[0].Param != "returned to out" {
+		t.Errorf("Expected a transition validation error, got %v", err)
+	}
+
+	// The rental was changed since version 1 was read
+	if _, err := repo.Transition(context.Background(), 1, 1, entity.RentalDamaged, ""); !errors.Is(err, ErrStaleEntity) {
+		t.Errorf("Expected ErrStaleEntity, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestRentalRepository_MarkLost(t *testing.T) {
+	_, mock, repo, cleanup := setupRentalTest(t)
+	defer cleanup()
+
+	rentalDate := time.Now().Add(-time.Hour * 24 * 120)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE rental_id = ?")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "rental_id", "rental_date", "inventory_id", "customer_id", "return_date", "staff_id", "status"}).
+			AddRow(1, 1, 1, rentalDate, 7, 3, nil, 2, "out"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `rental` SET ") + ".*`status`=\\? WHERE version = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `rental_transition`")).
+		WithArgs(1, entity.RentalOut, entity.RentalLost, "", "overdue", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `inventory` SET `deleted_at`=? WHERE inventory_id = ? AND `inventory`.`deleted_at` IS NULL")).
+		WithArgs(sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `payment`")).
+		WithArgs(
+			sqlmock.AnyArg(), // CreatedAt
+			sqlmock.AnyArg(), // UpdatedAt
+			sqlmock.AnyArg(), // DeletedAt
+			1,                // Version
+			3,                // CustomerID
+			2,                // StaffID
+			1,                // RentalID
+			entity.PaymentReplacement,
+			entity.Cents(1999),
+			sqlmock.AnyArg(), // PaymentDate
+		).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectCommit()
+
+	rental, err := repo.MarkLost(context.Background(), 1, entity.Cents(1999), "overdue")
+	if err != nil {
+		t.Fatalf("Error marking rental lost: %v", err)
+	}
+	if rental.Status != entity.RentalLost || rental.ReturnDate != nil {
+		t.Errorf("Expected a lost rental without a return date, got %+v", rental)
+	}
+	if len(rental.Payments) != 1 || rental.Payments[0].Kind != entity.PaymentReplacement || rental.Payments[0].Amount != entity.Cents(1999) {
+		t.Errorf("Expected a replacement fee of 19.99, got %+v", rental.Payments)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestRentalRepository_Transition_HoldsForWaitlist(t *testing.T) {
+	_, mock, repo, cleanup := setupRentalTest(t)
+	defer cleanup()
+
+	rentalDate := time.Now().Add(-time.Hour * 24 * 100)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE rental_id = ?")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "rental_id", "rental_date", "inventory_id", "customer_id", "return_date", "staff_id", "status"}).
+			AddRow(1, 2, 1, rentalDate, 7, 1, nil, 1, "lost"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `rental` SET ")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `rental_transition`")).
+		WithArgs(1, entity.RentalLost, entity.RentalReturned, "", "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// The lost copy goes back into circulation and is held for the first customer waiting for its film
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `inventory` SET `deleted_at`=?,`updated_at`=? WHERE inventory_id = ? AND deleted_at IS NOT NULL")).
+		WithArgs(nil, sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `inventory` WHERE inventory_id = ?")).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"inventory_id", "film_id", "store_id"}).AddRow(7, 3, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `waitlist` WHERE (film_id = ? AND store_id = ? AND status = ?) AND `waitlist`.`deleted_at` IS NULL ORDER BY created_at, waitlist_id LIMIT ? FOR UPDATE SKIP LOCKED")).
+		WithArgs(3, 1, entity.WaitlistWaiting, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "waitlist_id", "film_id", "store_id", "customer_id", "status"}).
+			AddRow(1, 4, 3, 1, 9, "waiting"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `reservation`")).
+		WithArgs(1, 7, 9, entity.ReservationActive, sqlmock.AnyArg(), nil, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `waitlist` SET ") + ".*`status`=\\?,`reservation_id`=\\?,.* WHERE version = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if _, err := repo.Transition(context.Background(), 1, 2, entity.RentalReturned, ""); err != nil {
+		t.Fatalf("Error returning lost rental: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -3,69 +3,158 @@ package repository
 import (
 	"context"
 	"gorm.io/gorm"
+	"strings"
 )
 
-// Repository is a generic interface for database operations
+// Repository is a generic interface for database operations.
+// Errors are classified into ErrNotFound, ErrConflict, ErrReferenced and ErrValidation where possible;
+// versioned updates fail with ErrStaleEntity and operations on copies that are rented or held with ErrUnavailable.
 type Repository[T any] interface {
-	// Create creates a new entity
+	// Create validates and creates a new entity; invalid entities fail with a *ValidationError
 	Create(ctx context.Context, entity *T) error
 
 	// FindByID finds an entity by its ID
-	FindByID(ctx context.Context, id uint) (*T, error)
+	FindByID(ctx context.Context, id uint, opts ...QueryOption) (*T, error)
 
 	// FindAll returns all entities
-	FindAll(ctx context.Context) ([]T, error)
+	FindAll(ctx context.Context, opts ...QueryOption) ([]T, error)
 
-	// Update updates an entity
+	// Update validates and updates an entity; invalid entities fail with a *ValidationError. Versioned entities
+	// are only updated if their version is unchanged, failing with ErrStaleEntity otherwise.
 	Update(ctx context.Context, entity *T) error
 
-	// Delete deletes an entity
+	// Delete deletes an entity; soft-deleted entities are only marked deleted
 	Delete(ctx context.Context, entity *T) error
 
-	// DeleteByID deletes an entity by its ID
+	// DeleteByID deletes an entity by its ID; soft-deleted entities are only marked deleted
 	DeleteByID(ctx context.Context, id uint) error
+
+	// FindDeleted returns the soft-deleted entities
+	FindDeleted(ctx context.Context, opts ...QueryOption) ([]T, error)
+
+	// Restore undeletes a soft-deleted entity by its ID; it fails with ErrNotFound unless the entity is soft-deleted
+	Restore(ctx context.Context, id uint) error
+
+	// Purge permanently deletes a soft-deleted entity by its ID; it fails with ErrNotFound unless the entity is
+	// soft-deleted
+	Purge(ctx context.Context, id uint) error
+
+	// CreateMany validates and creates entities in batches of batchSize rows, reporting the outcome of every row
+	CreateMany(ctx context.Context, entities []*T, batchSize int) ([]RowResult, error)
+
+	// Upsert validates and inserts entities, updating existing rows that conflict on the conflict key columns
+	Upsert(ctx context.Context, entities []*T, conflictKey ...string) ([]RowResult, error)
+
+	// DeleteWhere deletes the entities matching the condition and returns how many were deleted
+	DeleteWhere(ctx context.Context, query any, args ...any) (int64, error)
+}
+
+// QueryOption configures a finder query
+type QueryOption func(*queryOptions)
+
+type queryOptions struct {
+	preload        []string
+	includeDeleted bool
+}
+
+// With preloads the named associations, e.g. With("Category", "Actors"). Names are matched case-insensitively
+// against the associations the repository allows; unknown names fail the query with a *ValidationError.
+func With(associations ...string) QueryOption {
+	return func(o *queryOptions) {
+		o.preload = append(o.preload, associations...)
+	}
 }
 
 // BaseRepository is a base implementation of the Repository interface
 type BaseRepository[T any] struct {
 	DB *gorm.DB
+
+	// Associations lists the associations that With may preload
+	Associations []string
 }
 
-// Create creates a new entity
+// query starts a query bound to ctx with opts applied. Option errors are added to the returned query.
+func (r *BaseRepository[T]) query(ctx context.Context, opts []QueryOption) *gorm.DB {
+	db := r.DB.WithContext(ctx)
+
+	var o queryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.includeDeleted {
+		db = db.Unscoped()
+	}
+	for _, name := range o.preload {
+		association, ok := r.association(name)
+		if !ok {
+			_ = db.AddError(&ValidationError{Fields: []FieldError{{
+				Field: "include",
+				Rule:  "oneof",
+				Param: strings.Join(r.Associations, " "),
+			}}})
+			return db
+		}
+		db = db.Preload(association)
+	}
+	return db
+}
+
+// association returns the allowed association matching name case-insensitively
+func (r *BaseRepository[T]) association(name string) (string, bool) {
+	for _, association := range r.Associations {
+		if strings.EqualFold(association, strings.TrimSpace(name)) {
+			return association, true
+		}
+	}
+	return "", false
+}
+
+// Create validates and creates a new entity
 func (r *BaseRepository[T]) Create(ctx context.Context, entity *T) error {
-	return r.DB.WithContext(ctx).Create(entity).Error
+	if err := validateEntity(entity); err != nil {
+		return err
+	}
+	return translateError(r.DB.WithContext(ctx).Create(entity).Error)
 }
 
 // FindByID finds an entity by its ID
-func (r *BaseRepository[T]) FindByID(ctx context.Context, id uint) (*T, error) {
+func (r *BaseRepository[T]) FindByID(ctx context.Context, id uint, opts ...QueryOption) (*T, error) {
 	var entity T
-	if err := r.DB.WithContext(ctx).First(&entity, id).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).First(&entity, id).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return &entity, nil
 }
 
 // FindAll returns all entities
-func (r *BaseRepository[T]) FindAll(ctx context.Context) ([]T, error) {
+func (r *BaseRepository[T]) FindAll(ctx context.Context, opts ...QueryOption) ([]T, error) {
 	var entities []T
-	if err := r.DB.WithContext(ctx).Find(&entities).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Find(&entities).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return entities, nil
 }
 
-// Update updates an entity
+// Update validates and updates an entity. A versioned entity is only updated if the row still has the
+// entity's version, which is incremented on success.
 func (r *BaseRepository[T]) Update(ctx context.Context, entity *T) error {
-	return r.DB.WithContext(ctx).Save(entity).Error
+	if err := validateEntity(entity); err != nil {
+		return err
+	}
+	versioned, ok := any(entity).(entityVersion)
+	if !ok {
+		return translateError(r.DB.WithContext(ctx).Save(entity).Error)
+	}
+	return updateVersioned(r.DB.WithContext(ctx), entity, versioned.Versioning())
 }
 
 // Delete deletes an entity
 func (r *BaseRepository[T]) Delete(ctx context.Context, entity *T) error {
-	return r.DB.WithContext(ctx).Delete(entity).Error
+	return translateError(r.DB.WithContext(ctx).Delete(entity).Error)
 }
 
 // DeleteByID deletes an entity by its ID
 func (r *BaseRepository[T]) DeleteByID(ctx context.Context, id uint) error {
 	var entity T
-	return r.DB.WithContext(ctx).Delete(&entity, id).Error
+	return translateError(r.DB.WithContext(ctx).Delete(&entity, id).Error)
 }
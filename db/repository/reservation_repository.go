@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"CortexMCP/db/entity"
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ReservationRepository is an interface for reservation operations. A reservation holds its copy while it is
+// active and has not expired, keeping it out of the available inventory.
+type ReservationRepository interface {
+	Repository[entity.Reservation]
+
+	// FindByCustomer finds reservations by customer ID
+	FindByCustomer(ctx context.Context, customerID uint, opts ...QueryOption) ([]entity.Reservation, error)
+
+	// FindActive finds the reservations that currently hold their copy
+	FindActive(ctx context.Context, opts ...QueryOption) ([]entity.Reservation, error)
+
+	// Reserve validates and creates an active reservation, failing with ErrUnavailable unless its copy is
+	// available
+	Reserve(ctx context.Context, reservation *entity.Reservation) error
+
+	// Cancel cancels an active reservation, releasing its copy
+	Cancel(ctx context.Context, reservationID uint) (*entity.Reservation, error)
+
+	// Expire marks the active reservations that expired before now expired and returns how many there were
+	Expire(ctx context.Context, now time.Time) (int64, error)
+
+	// Checkout converts a reservation that still holds its copy into a rental by the given staff member,
+	// failing with ErrUnavailable once it has expired
+	Checkout(ctx context.Context, reservationID, staffID uint) (*entity.Rental, error)
+}
+
+// ReservationRepositoryImpl is an implementation of ReservationRepository
+type ReservationRepositoryImpl struct {
+	BaseRepository[entity.Reservation]
+}
+
+// NewReservationRepository creates a new ReservationRepository
+func NewReservationRepository(db *gorm.DB) ReservationRepository {
+	return &ReservationRepositoryImpl{
+		BaseRepository: BaseRepository[entity.Reservation]{
+			DB:           db,
+			Associations: []string{"Inventory", "Inventory.Film", "Customer", "Rental"},
+		},
+	}
+}
+
+// FindByCustomer finds reservations by customer ID
+func (r *ReservationRepositoryImpl) FindByCustomer(ctx context.Context, customerID uint, opts ...QueryOption) ([]entity.Reservation, error) {
+	var reservations []entity.Reservation
+	if err := r.query(ctx, opts).Where("customer_id = ?", customerID).Find(&reservations).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return reservations, nil
+}
+
+// FindActive finds the reservations that currently hold their copy
+func (r *ReservationRepositoryImpl) FindActive(ctx context.Context, opts ...QueryOption) ([]entity.Reservation, error) {
+	var reservations []entity.Reservation
+	if err := r.query(ctx, opts).
+		Where("status = ? AND expires_at > ?", entity.ReservationActive, time.Now()).
+		Find(&reservations).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return reservations, nil
+}
+
+// Reserve validates and creates an active reservation. Expired reservations of the copy are marked expired
+// first, so that they no longer count against it.
+func (r *ReservationRepositoryImpl) Reserve(ctx context.Context, reservation *entity.Reservation) error {
+	now := time.Now()
+	reservation.Status = entity.ReservationActive
+	if err := validateEntity(reservation); err != nil {
+		return err
+	}
+	if !reservation.ExpiresAt.After(now) {
+		return &ValidationError{Fields: []FieldError{{Field: "expires_at", Rule: "gt", Param: "now"}}}
+	}
+	return translateError(r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := expire(tx.Where("inventory_id = ?", reservation.InventoryID), now); err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&entity.Inventory{}).Scopes(available).
+			Where("inventory.inventory_id = ?", reservation.InventoryID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrUnavailable
+		}
+		return tx.Create(reservation).Error
+	}))
+}
+
+// Cancel cancels an active reservation
+func (r *ReservationRepositoryImpl) Cancel(ctx context.Context, reservationID uint) (*entity.Reservation, error) {
+	var reservation entity.Reservation
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("reservation_id = ?", reservationID).First(&reservation).Error; err != nil {
+			return err
+		}
+		if reservation.Status != entity.ReservationActive {
+			return reservationStateError(reservation.Status, entity.ReservationCancelled)
+		}
+		reservation.Status = entity.ReservationCancelled
+		return updateVersioned(tx, &reservation, &reservation.Versioned)
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &reservation, nil
+}
+
+// Expire marks the active reservations that expired before now expired
+func (r *ReservationRepositoryImpl) Expire(ctx context.Context, now time.Time) (int64, error) {
+	expired, err := expire(r.DB.WithContext(ctx), now)
+	return expired, translateError(err)
+}
+
+// Checkout converts a reservation into a rental of its copy starting now, in one transaction
+func (r *ReservationRepositoryImpl) Checkout(ctx context.Context, reservationID, staffID uint) (*entity.Rental, error) {
+	var rental entity.Rental
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var reservation entity.Reservation
+		if err := tx.Where("reservation_id = ?", reservationID).First(&reservation).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		if reservation.Status != entity.ReservationActive {
+			return reservationStateError(reservation.Status, entity.ReservationFulfilled)
+		}
+		if !reservation.Holds(now) {
+			return ErrUnavailable
+		}
+
+		rental = entity.Rental{
+			RentalDate:  now,
+			InventoryID: reservation.InventoryID,
+			CustomerID:  reservation.CustomerID,
+			StaffID:     staffID,
+			Status:      entity.RentalOut,
+		}
+		if err := validateEntity(&rental); err != nil {
+			return err
+		}
+		if err := tx.Create(&rental).Error; err != nil {
+			return err
+		}
+
+		reservation.Status = entity.ReservationFulfilled
+		reservation.RentalID = &rental.RentalID
+		return updateVersioned(tx, &reservation, &reservation.Versioned)
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &rental, nil
+}
+
+// expire marks the active reservations matched by db that expired before now expired and returns how many
+// there were
+func expire(db *gorm.DB, now time.Time) (int64, error) {
+	result := db.Model(&entity.Reservation{}).
+		Where("status = ? AND expires_at <= ?", entity.ReservationActive, now).
+		Updates(map[string]any{"status": entity.ReservationExpired, "version": gorm.Expr("version + 1")})
+	return result.RowsAffected, result.Error
+}
+
+// reservationStateError rejects moving a reservation that is no longer active to another state
+func reservationStateError(from, to entity.ReservationStatus) error {
+	return &ValidationError{Fields: []FieldError{{Field: "status", Rule: "transition", Param: string(from) + " to " + string(to)}}}
+}
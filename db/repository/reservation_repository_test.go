@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"CortexMCP/db/entity"
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupReservationTest(t *testing.T) (*sql.DB, sqlmock.Sqlmock, ReservationRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+
+	dialector := mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	})
+
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open gorm database: %v", err)
+	}
+
+	repo := NewReservationRepository(gormDB)
+
+	return db, mock, repo, func() {
+		db.Close()
+	}
+}
+
+var reservationColumns = []string{"version", "reservation_id", "inventory_id", "customer_id", "status", "expires_at", "rental_id"}
+
+func TestReservationRepository_Reserve(t *testing.T) {
+	_, mock, repo, cleanup := setupReservationTest(t)
+	defer cleanup()
+
+	reservation := &entity.Reservation{InventoryID: 7, CustomerID: 3, ExpiresAt: time.Now().Add(48 * time.Hour)}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `reservation` SET `status`=?,`version`=version + 1,`updated_at`=? WHERE inventory_id = ? AND (status = ? AND expires_at <= ?) AND `reservation`.`deleted_at` IS NULL")).
+		WithArgs(entity.ReservationExpired, sqlmock.AnyArg(), 7, entity.ReservationActive, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `inventory` LEFT JOIN rental ON rental.inventory_id = inventory.inventory_id AND rental.status IN (?,?,?) AND rental.deleted_at IS NULL WHERE inventory.inventory_id = ? AND rental.rental_id IS NULL AND (NOT EXISTS (SELECT 1 FROM reservation")).
+		WithArgs(entity.RentalReserved, entity.RentalOut, entity.RentalLost, 7, entity.ReservationActive, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `reservation`")).
+		WithArgs(
+			1, // Version
+			7, // InventoryID
+			3, // CustomerID
+			entity.ReservationActive,
+			reservation.ExpiresAt,
+			nil,              // RentalID
+			sqlmock.AnyArg(), // CreatedAt
+			sqlmock.AnyArg(), // UpdatedAt
+			sqlmock.AnyArg(), // DeletedAt
+		).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Reserve(context.Background(), reservation); err != nil {
+		t.Fatalf("Error reserving copy: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestReservationRepository_Reserve_Unavailable(t *testing.T) {
+	_, mock, repo, cleanup := setupReservationTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `reservation` SET `status`=?")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `inventory`")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+
+	reservation := &entity.Reservation{InventoryID: 7, CustomerID: 3, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repo.Reserve(context.Background(), reservation); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable, got %v", err)
+	}
+
+	// Holds that expire right away are rejected before touching the database
+	reservation.ExpiresAt = time.Now().Add(-time.Hour)
+	var validationErr *ValidationError
+	if err := repo.Reserve(context.Background(), reservation); !errors.As(err, &validationErr) || validationErr.Fields[0].Field != "expires_at" {
+		t.Errorf("Expected an expires_at validation error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestReservationRepository_Cancel(t *testing.T) {
+	_, mock, repo, cleanup := setupReservationTest(t)
+	defer cleanup()
+
+	expiresAt := time.Now().Add(time.Hour)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `reservation` WHERE reservation_id = ? AND `reservation`.`deleted_at` IS NULL ORDER BY `reservation`.`reservation_id` LIMIT ?")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows(reservationColumns).AddRow(1, 1, 7, 3, "active", expiresAt, nil))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `reservation` SET ") + ".*`status`=\\?.* WHERE version = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	reservation, err := repo.Cancel(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Error cancelling reservation: %v", err)
+	}
+	if reservation.Status != entity.ReservationCancelled || reservation.Version != 2 {
+		t.Errorf("Expected a cancelled reservation at version 2, got %+v", reservation)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestReservationRepository_Expire(t *testing.T) {
+	_, mock, repo, cleanup := setupReservationTest(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `reservation` SET `status`=?,`version`=version + 1,`updated_at`=? WHERE (status = ? AND expires_at <= ?) AND `reservation`.`deleted_at` IS NULL")).
+		WithArgs(entity.ReservationExpired, sqlmock.AnyArg(), entity.ReservationActive, now).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	expired, err := repo.Expire(context.Background(), now)
+	if err != nil {
+		t.Fatalf("Error expiring reservations: %v", err)
+	}
+	if expired != 3 {
+		t.Errorf("Expected 3 expired reservations, got %d", expired)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestReservationRepository_Checkout(t *testing.T) {
+	_, mock, repo, cleanup := setupReservationTest(t)
+	defer cleanup()
+
+	expiresAt := time.Now().Add(time.Hour)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `reservation` WHERE reservation_id = ?")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows(reservationColumns).AddRow(1, 1, 7, 3, "active", expiresAt, nil))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `rental`")).
+		WithArgs(
+			sqlmock.AnyArg(), // CreatedAt
+			sqlmock.AnyArg(), // UpdatedAt
+			sqlmock.AnyArg(), // DeletedAt
+			1,                // Version
+			sqlmock.AnyArg(), // RentalDate
+			7,                // InventoryID
+			3,                // CustomerID
+			nil,              // ReturnDate
+			2,                // StaffID
+			entity.RentalOut,
+		).
+		WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `rental_transition`")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `reservation` SET ") + ".*`status`=\\?,`expires_at`=\\?,`rental_id`=\\?,.* WHERE version = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rental, err := repo.Checkout(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatalf("Error checking out reservation: %v", err)
+	}
+	if rental.Status != entity.RentalOut || rental.InventoryID != 7 || rental.CustomerID != 3 {
+		t.Errorf("Expected copy 7 to be out with customer 3, got %+v", rental)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestReservationRepository_Checkout_Expired(t *testing.T) {
+	_, mock, repo, cleanup := setupReservationTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `reservation` WHERE reservation_id = ?")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows(reservationColumns).AddRow(1, 1, 7, 3, "active", time.Now().Add(-time.Minute), nil))
+	mock.ExpectRollback()
+
+	if _, err := repo.Checkout(context.Background(), 1, 2); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
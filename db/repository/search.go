@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"CortexMCP/db/entity"
+	"context"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// defaultSearchLimit is used when a search is called without a positive limit
+	defaultSearchLimit = 20
+
+	// likeEscape is the escape character used in every LIKE pattern built by this package.
+	// It is passed explicitly because MSSQL has no default escape character.
+	likeEscape = "!"
+)
+
+// SearchResult is an entity matched by a search together with its relevance score
+type SearchResult[T any] struct {
+	Entity T       `json:"entity"`
+	Score  float64 `json:"score"`
+}
+
+// SearchRepository is an interface for ranked, case-insensitive search
+type SearchRepository interface {
+	// SearchFilms searches films by title
+	SearchFilms(ctx context.Context, query string, limit int) ([]SearchResult[entity.Film], error)
+
+	// SearchActors searches actors by full name
+	SearchActors(ctx context.Context, query string, limit int) ([]SearchResult[entity.Actor], error)
+
+	// SearchCustomers searches customers by full name
+	SearchCustomers(ctx context.Context, query string, limit int) ([]SearchResult[entity.Customer], error)
+}
+
+// SearchRepositoryImpl is an implementation of SearchRepository
+type SearchRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// NewSearchRepository creates a new SearchRepository
+func NewSearchRepository(db *gorm.DB) SearchRepository {
+	return &SearchRepositoryImpl{
+		DB: db,
+	}
+}
+
+// searchTarget describes the searchable text of an entity
+type searchTarget[T any] struct {
+	// key is the primary key column
+	key string
+
+	// columns are concatenated (space separated) into the searchable document
+	columns []string
+
+	// tsConfig is the Postgres text search configuration
+	tsConfig string
+
+	// id returns the primary key of a loaded entity
+	id func(*T) uint
+
+	// text returns the searchable document of a loaded entity
+	text func(*T) string
+}
+
+var filmSearch = searchTarget[entity.Film]{
+	key:      "film_id",
+	columns:  []string{"title"},
+	tsConfig: "english",
+	id:       func(f *entity.Film) uint { return f.FilmID },
+	text:     func(f *entity.Film) string { return f.Title },
+}
+
+var actorSearch = searchTarget[entity.Actor]{
+	key:      "actor_id",
+	columns:  []string{"first_name", "last_name"},
+	tsConfig: "simple",
+	id:       func(a *entity.Actor) uint { return a.ActorID },
+	text:     func(a *entity.Actor) string { return a.FirstName + " " + a.LastName },
+}
+
+var customerSearch = searchTarget[entity.Customer]{
+	key:      "customer_id",
+	columns:  []string{"first_name", "last_name"},
+	tsConfig: "simple",
+	id:       func(c *entity.Customer) uint { return c.CustomerID },
+	text:     func(c *entity.Customer) string { return c.FirstName + " " + c.LastName },
+}
+
+// SearchFilms searches films by title
+func (r *SearchRepositoryImpl) SearchFilms(ctx context.Context, query string, limit int) ([]SearchResult[entity.Film], error) {
+	return search(r.DB.WithContext(ctx), filmSearch, query, limit)
+}
+
+// SearchActors searches actors by full name
+func (r *SearchRepositoryImpl) SearchActors(ctx context.Context, query string, limit int) ([]SearchResult[entity.Actor], error) {
+	return search(r.DB.WithContext(ctx), actorSearch, query, limit)
+}
+
+// SearchCustomers searches customers by full name
+func (r *SearchRepositoryImpl) SearchCustomers(ctx context.Context, query string, limit int) ([]SearchResult[entity.Customer], error) {
+	return search(r.DB.WithContext(ctx), customerSearch, query, limit)
+}
+
+// search runs a ranked search for target. Postgres ranks with full-text search and pg_trgm similarity,
+// which also tolerates typos; other dialects fall back to a case-insensitive substring match
+// scored by trigram similarity computed in Go.
+func search[T any](db *gorm.DB, target searchTarget[T], query string, limit int) ([]SearchResult[T], error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return []SearchResult[T]{}, nil
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	if db.Dialector.Name() == "postgres" {
+		return searchPostgres(db, target, query, limit)
+	}
+	return searchFallback(db, target, query, limit)
+}
+
+func searchPostgres[T any](db *gorm.DB, target searchTarget[T], query string, limit int) ([]SearchResult[T], error) {
+	doc := strings.Join(target.columns, " || ' ' || ")
+	vector := "to_tsvector('" + target.tsConfig + "', " + doc + ")"
+	tsQuery := "plainto_tsquery('" + target.tsConfig + "', ?)"
+
+	var hits []struct {
+		ID    uint
+		Score float64
+	}
+	if err := db.Model(new(T)).
+		Select(target.key+" AS id, GREATEST(ts_rank("+vector+", "+tsQuery+"), similarity("+doc+", ?)) AS score", query, query).
+		Where(vector+" @@ "+tsQuery+" OR ("+doc+") % ? OR ("+doc+") ILIKE ? ESCAPE '"+likeEscape+"'",
+			query, query, "%"+escapeLike(query)+"%").
+		Order("score DESC, " + target.key).
+		Limit(limit).
+		Scan(&hits).Error; err != nil {
+		return nil, translateError(err)
+	}
+	if len(hits) == 0 {
+		return []SearchResult[T]{}, nil
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	var entities []T
+	if err := db.Where(target.key+" IN ?", ids).Find(&entities).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	byID := make(map[uint]T, len(entities))
+	for i := range entities {
+		byID[target.id(&entities[i])] = entities[i]
+	}
+	results := make([]SearchResult[T], 0, len(hits))
+	for _, hit := range hits {
+		if e, ok := byID[hit.ID]; ok {
+			results = append(results, SearchResult[T]{Entity: e, Score: hit.Score})
+		}
+	}
+	return results, nil
+}
+
+func searchFallback[T any](db *gorm.DB, target searchTarget[T], query string, limit int) ([]SearchResult[T], error) {
+	doc := "LOWER(CONCAT(" + strings.Join(target.columns, ", ' ', ") + "))"
+	lowered := strings.ToLower(query)
+	escaped := escapeLike(lowered)
+
+	var entities []T
+	if err := db.
+		Where(doc+" LIKE ? ESCAPE '"+likeEscape+"'", "%"+escaped+"%").
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "CASE WHEN " + doc + " = ? THEN 0 WHEN " + doc + " LIKE ? ESCAPE '" + likeEscape + "' THEN 1 ELSE 2 END",
+			Vars:               []interface{}{lowered, escaped + "%"},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Find(&entities).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	results := make([]SearchResult[T], len(entities))
+	for i := range entities {
+		results[i] = SearchResult[T]{Entity: entities[i], Score: trigramSimilarity(target.text(&entities[i]), query)}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results, nil
+}
+
+// escapeLike escapes LIKE wildcards in s so that they match literally when used with ESCAPE '!'.
+// '[' is escaped as well because MSSQL treats it as the start of a character class.
+func escapeLike(s string) string {
+	return strings.NewReplacer(
+		likeEscape, likeEscape+likeEscape,
+		"%", likeEscape+"%",
+		"_", likeEscape+"_",
+		"[", likeEscape+"[",
+	).Replace(s)
+}
+
+// containsCondition builds a case-insensitive condition matching column values that contain term.
+// Wildcards in term are matched literally. It returns the condition and its single argument.
+func containsCondition(db *gorm.DB, column, term string) (string, string) {
+	pattern := "%" + escapeLike(term) + "%"
+	if db.Dialector.Name() == "postgres" {
+		return column + " ILIKE ? ESCAPE '" + likeEscape + "'", pattern
+	}
+	return "LOWER(" + column + ") LIKE ? ESCAPE '" + likeEscape + "'", strings.ToLower(pattern)
+}
+
+// trigramSimilarity mirrors pg_trgm's similarity(): the number of shared trigrams divided by the
+// number of distinct trigrams in both strings
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// trigrams returns the set of trigrams of s the way pg_trgm extracts them: lower-cased,
+// split into alphanumeric words, each padded with two leading spaces and one trailing space
+func trigrams(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+	return set
+}
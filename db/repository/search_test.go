@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupSearchTest(t *testing.T, dialect string) (*sql.DB, sqlmock.Sqlmock, SearchRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+
+	var dialector gorm.Dialector
+	switch dialect {
+	case "postgres":
+		dialector = postgres.New(postgres.Config{Conn: db})
+	default:
+		dialector = mysql.New(mysql.Config{
+			Conn:                      db,
+			SkipInitializeWithVersion: true,
+		})
+	}
+
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open gorm database: %v", err)
+	}
+
+	repo := NewSearchRepository(gormDB)
+
+	return db, mock, repo, func() {
+		db.Close()
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "Matrix", want: "Matrix"},
+		{input: "100%", want: "100!%"},
+		{input: "a_b", want: "a!_b"},
+		{input: "[x]!", want: "![x]!!"},
+	}
+
+	for _, tt := range tests {
+		if got := escapeLike(tt.input); got != tt.want {
+			t.Errorf("escapeLike(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestTrigramSimilarity(t *testing.T) {
+	if got := trigramSimilarity("Matrix", "matrix"); got != 1 {
+		t.Errorf("Expected identical strings to have similarity 1, got %f", got)
+	}
+
+	if got := trigramSimilarity("Matrix", "Titanic"); got >= trigramSimilarity("Matrix", "Matrx") {
+		t.Errorf("Expected a typo to score higher than an unrelated title, got %f", got)
+	}
+
+	if got := trigramSimilarity("", "Matrix"); got != 0 {
+		t.Errorf("Expected empty string to have similarity 0, got %f", got)
+	}
+}
+
+func TestSearchRepository_SearchFilms_Fallback(t *testing.T) {
+	_, mock, repo, cleanup := setupSearchTest(t, "mysql")
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "deleted_at", "film_id", "title", "release_year", "length", "category_id"}).
+		AddRow(2, time.Now(), time.Now(), nil, 2, "The Matrix Reloaded", 2003, 138, 1).
+		AddRow(1, time.Now(), time.Now(), nil, 1, "The Matrix", 1999, 136, 1)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `film` WHERE LOWER(CONCAT(title)) LIKE ? ESCAPE '!' AND `film`.`deleted_at` IS NULL ORDER BY CASE WHEN LOWER(CONCAT(title)) = ? THEN 0 WHEN LOWER(CONCAT(title)) LIKE ? ESCAPE '!' THEN 1 ELSE 2 END LIMIT ?")).
+		WithArgs("%the matrix%", "the matrix", "the matrix%", 20).
+		WillReturnRows(rows)
+
+	results, err := repo.SearchFilms(context.Background(), "The Matrix", 0)
+	if err != nil {
+		t.Errorf("Error searching films: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	if results[0].Entity.FilmID != 1 {
+		t.Errorf("Expected the exact match first, got FilmID %d", results[0].Entity.FilmID)
+	}
+
+	if results[0].Score <= results[1].Score {
+		t.Errorf("Expected descending scores, got %f then %f", results[0].Score, results[1].Score)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestSearchRepository_SearchActors_Postgres(t *testing.T) {
+	_, mock, repo, cleanup := setupSearchTest(t, "postgres")
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT actor_id AS id, GREATEST(ts_rank(to_tsvector('simple', first_name || ' ' || last_name), plainto_tsquery('simple', $1)), similarity(first_name || ' ' || last_name, $2)) AS score FROM "actor" WHERE (to_tsvector('simple', first_name || ' ' || last_name) @@ plainto_tsquery('simple', $3) OR (first_name || ' ' || last_name) % $4 OR (first_name || ' ' || last_name) ILIKE $5 ESCAPE '!') AND "actor"."deleted_at" IS NULL ORDER BY score DESC, actor_id LIMIT $6`)).
+		WithArgs("penelope", "penelope", "penelope", "penelope", "%penelope%", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "score"}).
+			AddRow(7, 0.9).
+			AddRow(3, 0.4))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "actor" WHERE actor_id IN ($1,$2) AND "actor"."deleted_at" IS NULL`)).
+		WithArgs(7, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "actor_id", "first_name", "last_name"}).
+			AddRow(3, 3, "Penelope", "Monroe").
+			AddRow(7, 7, "Penelope", "Guiness"))
+
+	results, err := repo.SearchActors(context.Background(), "penelope", 5)
+	if err != nil {
+		t.Errorf("Error searching actors: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	if results[0].Entity.ActorID != 7 || results[0].Score != 0.9 {
+		t.Errorf("Expected actor 7 ranked first with score 0.9, got actor %d with score %f", results[0].Entity.ActorID, results[0].Score)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestSearchRepository_EmptyQuery(t *testing.T) {
+	_, mock, repo, cleanup := setupSearchTest(t, "mysql")
+	defer cleanup()
+
+	results, err := repo.SearchCustomers(context.Background(), "   ", 10)
+	if err != nil {
+		t.Errorf("Error searching customers: %v", err)
+	}
+
+	if len(results) != 0 {
+		t.Errorf("Expected no results, got %d", len(results))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
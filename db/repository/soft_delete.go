@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Entities embedding gorm.Model are soft-deleted: Delete, DeleteByID and DeleteWhere set their deleted_at
+// column, which hides them from every query unless IncludeDeleted is given. They stay recoverable with
+// Restore until Purge removes them for good; the schema refuses to hard-delete a row that was not soft-deleted
+// first. Entities without a deleted_at column, such as the film_actors links, are deleted permanently.
+
+// IncludeDeleted includes soft-deleted entities in the results of a finder
+func IncludeDeleted() QueryOption {
+	return func(o *queryOptions) {
+		o.includeDeleted = true
+	}
+}
+
+// FindDeleted returns the soft-deleted entities
+func (r *BaseRepository[T]) FindDeleted(ctx context.Context, opts ...QueryOption) ([]T, error) {
+	softDeleted, err := r.softDeleted()
+	if err != nil || !softDeleted {
+		return nil, err
+	}
+
+	var entities []T
+	if err := r.query(ctx, opts).Unscoped().Where("deleted_at IS NOT NULL").Find(&entities).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return entities, nil
+}
+
+// Restore undeletes a soft-deleted entity by its ID, incrementing its version if it is versioned
+func (r *BaseRepository[T]) Restore(ctx context.Context, id uint) error {
+	softDeleted, err := r.softDeleted()
+	if err != nil {
+		return err
+	}
+	if !softDeleted {
+		return translateError(gorm.ErrRecordNotFound)
+	}
+
+	model := new(T)
+	updates := map[string]any{"deleted_at": nil}
+	if _, ok := any(model).(entityVersion); ok {
+		updates["version"] = gorm.Expr("version + 1")
+	}
+	result := r.DB.WithContext(ctx).Unscoped().Model(model).
+		Where(clause.Eq{Column: clause.PrimaryColumn, Value: id}).
+		Where("deleted_at IS NOT NULL").
+		Updates(updates)
+	return deletedRowAffected(result)
+}
+
+// Purge permanently deletes a soft-deleted entity by its ID
+func (r *BaseRepository[T]) Purge(ctx context.Context, id uint) error {
+	softDeleted, err := r.softDeleted()
+	if err != nil {
+		return err
+	}
+	if !softDeleted {
+		return translateError(gorm.ErrRecordNotFound)
+	}
+
+	result := r.DB.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").Delete(new(T), id)
+	return deletedRowAffected(result)
+}
+
+// softDeleted reports whether T is soft-deleted, i.e. has a deleted_at column
+func (r *BaseRepository[T]) softDeleted() (bool, error) {
+	stmt := &gorm.Statement{DB: r.DB}
+	if err := stmt.Parse(new(T)); err != nil {
+		return false, err
+	}
+	return stmt.Schema.LookUpField("deleted_at") != nil, nil
+}
+
+// deletedRowAffected translates the error of a write to a soft-deleted row, failing with ErrNotFound when there
+// was no such row
+func deletedRowAffected(result *gorm.DB) error {
+	if result.Error == nil && result.RowsAffected == 0 {
+		return translateError(gorm.ErrRecordNotFound)
+	}
+	return translateError(result.Error)
+}
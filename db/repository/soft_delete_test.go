@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestBaseRepository_FindDeleted(t *testing.T) {
+	mock, db := setupBulkTest(t, "mysql")
+	repo := NewCustomerRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer` WHERE deleted_at IS NOT NULL")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id", "deleted_at"}).AddRow(1, 7, time.Now()))
+
+	customers, err := repo.FindDeleted(context.Background())
+	if err != nil {
+		t.Fatalf("Error finding deleted customers: %v", err)
+	}
+	if len(customers) != 1 || customers[0].CustomerID != 7 || !customers[0].DeletedAt.Valid {
+		t.Errorf("Expected the deleted customer, got %+v", customers)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestBaseRepository_IncludeDeleted(t *testing.T) {
+	mock, db := setupBulkTest(t, "mysql")
+	repo := NewCustomerRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer` WHERE store_id = ?")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id"}).AddRow(1, 7).AddRow(2, 8))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer` WHERE `customer`.`id` = ? ORDER BY")).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id"}).AddRow(1, 7))
+
+	if _, err := repo.FindByStore(context.Background(), 1, IncludeDeleted()); err != nil {
+		t.Errorf("Error finding customers: %v", err)
+	}
+	if _, err := repo.FindByID(context.Background(), 1, IncludeDeleted()); err != nil {
+		t.Errorf("Error finding customer: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestBaseRepository_Restore(t *testing.T) {
+	mock, db := setupBulkTest(t, "mysql")
+	repo := NewCustomerRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `customer` SET `deleted_at`=?,`version`=version + 1,`updated_at`=? WHERE `customer`.`id` = ? AND deleted_at IS NOT NULL")).
+		WithArgs(nil, sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `customer` SET")).
+		WithArgs(nil, sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	if err := repo.Restore(context.Background(), 1); err != nil {
+		t.Errorf("Error restoring customer: %v", err)
+	}
+	// Customer 2 is not deleted, or does not exist
+	if err := repo.Restore(context.Background(), 2); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestBaseRepository_Purge(t *testing.T) {
+	mock, db := setupBulkTest(t, "mysql")
+	repo := NewCustomerRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `customer` WHERE deleted_at IS NOT NULL AND `customer`.`id` = ?")).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `customer` WHERE deleted_at IS NOT NULL AND `customer`.`id` = ?")).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	if err := repo.Purge(context.Background(), 1); err != nil {
+		t.Errorf("Error purging customer: %v", err)
+	}
+	// A live customer must be deleted before it can be purged
+	if err := repo.Purge(context.Background(), 2); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -11,22 +11,22 @@ type StaffRepository interface {
 	Repository[entity.Staff]
 
 	// FindByName finds staff by first or last name
-	FindByName(ctx context.Context, name string) ([]entity.Staff, error)
+	FindByName(ctx context.Context, name string, opts ...QueryOption) ([]entity.Staff, error)
 
 	// FindByEmail finds a staff member by email
-	FindByEmail(ctx context.Context, email string) (*entity.Staff, error)
+	FindByEmail(ctx context.Context, email string, opts ...QueryOption) (*entity.Staff, error)
 
 	// FindByUsername finds a staff member by username
-	FindByUsername(ctx context.Context, username string) (*entity.Staff, error)
+	FindByUsername(ctx context.Context, username string, opts ...QueryOption) (*entity.Staff, error)
 
 	// FindByStore finds staff by store ID
-	FindByStore(ctx context.Context, storeID uint) ([]entity.Staff, error)
+	FindByStore(ctx context.Context, storeID uint, opts ...QueryOption) ([]entity.Staff, error)
 
 	// FindActive finds active staff
-	FindActive(ctx context.Context) ([]entity.Staff, error)
+	FindActive(ctx context.Context, opts ...QueryOption) ([]entity.Staff, error)
 
 	// FindInactive finds inactive staff
-	FindInactive(ctx context.Context) ([]entity.Staff, error)
+	FindInactive(ctx context.Context, opts ...QueryOption) ([]entity.Staff, error)
 }
 
 // StaffRepositoryImpl is an implementation of StaffRepository
@@ -38,61 +38,64 @@ type StaffRepositoryImpl struct {
 func NewStaffRepository(db *gorm.DB) StaffRepository {
 	return &StaffRepositoryImpl{
 		BaseRepository: BaseRepository[entity.Staff]{
-			DB: db,
+			DB:           db,
+			Associations: []string{"Store"},
 		},
 	}
 }
 
 // FindByName finds staff by first or last name
-func (r *StaffRepositoryImpl) FindByName(ctx context.Context, name string) ([]entity.Staff, error) {
+func (r *StaffRepositoryImpl) FindByName(ctx context.Context, name string, opts ...QueryOption) ([]entity.Staff, error) {
 	var staff []entity.Staff
-	if err := r.DB.WithContext(ctx).Where("first_name LIKE ? OR last_name LIKE ?", "%"+name+"%", "%"+name+"%").Find(&staff).Error; err != nil {
-		return nil, err
+	firstName, pattern := containsCondition(r.DB, "first_name", name)
+	lastName, _ := containsCondition(r.DB, "last_name", name)
+	if err := r.query(ctx, opts).Where(firstName+" OR "+lastName, pattern, pattern).Find(&staff).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return staff, nil
 }
 
 // FindByEmail finds a staff member by email
-func (r *StaffRepositoryImpl) FindByEmail(ctx context.Context, email string) (*entity.Staff, error) {
+func (r *StaffRepositoryImpl) FindByEmail(ctx context.Context, email string, opts ...QueryOption) (*entity.Staff, error) {
 	var staff entity.Staff
-	if err := r.DB.WithContext(ctx).Where("email = ?", email).First(&staff).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("email = ?", email).First(&staff).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return &staff, nil
 }
 
 // FindByUsername finds a staff member by username
-func (r *StaffRepositoryImpl) FindByUsername(ctx context.Context, username string) (*entity.Staff, error) {
+func (r *StaffRepositoryImpl) FindByUsername(ctx context.Context, username string, opts ...QueryOption) (*entity.Staff, error) {
 	var staff entity.Staff
-	if err := r.DB.WithContext(ctx).Where("username = ?", username).First(&staff).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("username = ?", username).First(&staff).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return &staff, nil
 }
 
 // FindByStore finds staff by store ID
-func (r *StaffRepositoryImpl) FindByStore(ctx context.Context, storeID uint) ([]entity.Staff, error) {
+func (r *StaffRepositoryImpl) FindByStore(ctx context.Context, storeID uint, opts ...QueryOption) ([]entity.Staff, error) {
 	var staff []entity.Staff
-	if err := r.DB.WithContext(ctx).Where("store_id = ?", storeID).Find(&staff).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("store_id = ?", storeID).Find(&staff).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return staff, nil
 }
 
 // FindActive finds active staff
-func (r *StaffRepositoryImpl) FindActive(ctx context.Context) ([]entity.Staff, error) {
+func (r *StaffRepositoryImpl) FindActive(ctx context.Context, opts ...QueryOption) ([]entity.Staff, error) {
 	var staff []entity.Staff
-	if err := r.DB.WithContext(ctx).Where("active = ?", true).Find(&staff).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("active = ?", true).Find(&staff).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return staff, nil
 }
 
 // FindInactive finds inactive staff
-func (r *StaffRepositoryImpl) FindInactive(ctx context.Context) ([]entity.Staff, error) {
+func (r *StaffRepositoryImpl) FindInactive(ctx context.Context, opts ...QueryOption) ([]entity.Staff, error) {
 	var staff []entity.Staff
-	if err := r.DB.WithContext(ctx).Where("active = ?", false).Find(&staff).Error; err != nil {
-		return nil, err
+	if err := r.query(ctx, opts).Where("active = ?", false).Find(&staff).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return staff, nil
 }
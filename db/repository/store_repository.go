@@ -11,13 +11,13 @@ type StoreRepository interface {
 	Repository[entity.Store]
 
 	// FindByName finds stores by name
-	FindByName(ctx context.Context, name string) ([]entity.Store, error)
+	FindByName(ctx context.Context, name string, opts ...QueryOption) ([]entity.Store, error)
 
 	// FindByCity finds stores by city
-	FindByCity(ctx context.Context, city string) ([]entity.Store, error)
+	FindByCity(ctx context.Context, city string, opts ...QueryOption) ([]entity.Store, error)
 
 	// FindByCountry finds stores by country
-	FindByCountry(ctx context.Context, country string) ([]entity.Store, error)
+	FindByCountry(ctx context.Context, country string, opts ...QueryOption) ([]entity.Store, error)
 }
 
 // StoreRepositoryImpl is an implementation of StoreRepository
@@ -35,28 +35,31 @@ func NewStoreRepository(db *gorm.DB) StoreRepository {
 }
 
 // FindByName finds stores by name
-func (r *StoreRepositoryImpl) FindByName(ctx context.Context, name string) ([]entity.Store, error) {
+func (r *StoreRepositoryImpl) FindByName(ctx context.Context, name string, opts ...QueryOption) ([]entity.Store, error) {
 	var stores []entity.Store
-	if err := r.DB.WithContext(ctx).Where("store_name LIKE ?", "%"+name+"%").Find(&stores).Error; err != nil {
-		return nil, err
+	condition, pattern := containsCondition(r.DB, "store_name", name)
+	if err := r.query(ctx, opts).Where(condition, pattern).Find(&stores).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return stores, nil
 }
 
 // FindByCity finds stores by city
-func (r *StoreRepositoryImpl) FindByCity(ctx context.Context, city string) ([]entity.Store, error) {
+func (r *StoreRepositoryImpl) FindByCity(ctx context.Context, city string, opts ...QueryOption) ([]entity.Store, error) {
 	var stores []entity.Store
-	if err := r.DB.WithContext(ctx).Where("city LIKE ?", "%"+city+"%").Find(&stores).Error; err != nil {
-		return nil, err
+	condition, pattern := containsCondition(r.DB, "city", city)
+	if err := r.query(ctx, opts).Where(condition, pattern).Find(&stores).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return stores, nil
 }
 
 // FindByCountry finds stores by country
-func (r *StoreRepositoryImpl) FindByCountry(ctx context.Context, country string) ([]entity.Store, error) {
+func (r *StoreRepositoryImpl) FindByCountry(ctx context.Context, country string, opts ...QueryOption) ([]entity.Store, error) {
 	var stores []entity.Store
-	if err := r.DB.WithContext(ctx).Where("country LIKE ?", "%"+country+"%").Find(&stores).Error; err != nil {
-		return nil, err
+	condition, pattern := containsCondition(r.DB, "country", country)
+	if err := r.query(ctx, opts).Where(condition, pattern).Find(&stores).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return stores, nil
 }
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"CortexMCP/db/entity"
+
+	"gorm.io/gorm"
+)
+
+// entityVersion is implemented by entities embedding entity.Versioned. The alias lets methods whose entity
+// parameter shadows the entity package refer to it.
+type entityVersion = entity.VersionedEntity
+
+// updateVersioned updates all fields of model if its row still has the version in v, and increments v.
+// Unlike Save it never falls back to an insert, so a stale or deleted row fails with ErrStaleEntity.
+func updateVersioned(db *gorm.DB, model any, v *entity.Versioned) error {
+	expected := v.Version
+	v.Version = expected + 1
+	result := db.Model(model).Select("*").Where("version = ?", expected).Updates(model)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrStaleEntity
+	}
+	if result.Error != nil {
+		v.Version = expected
+		return translateError(result.Error)
+	}
+	return nil
+}
+
+// bumpVersion increments the version of the row of model matching query if it is still version. It fails
+// with ErrNotFound when no row matches and ErrStaleEntity when the row has another version.
+func bumpVersion(tx *gorm.DB, model any, version uint, query string, args ...any) error {
+	result := tx.Model(model).Where(query, args...).Where("version = ?", version).
+		Updates(map[string]any{"version": gorm.Expr("version + 1")})
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+
+	var count int64
+	if err := tx.Model(model).Where(query, args...).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return ErrStaleEntity
+}
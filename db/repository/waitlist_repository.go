@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"CortexMCP/db/entity"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"strconv"
+	"time"
+)
+
+// WaitlistHold is how long the reservation made for the next customer on a waitlist holds the returned copy
+const WaitlistHold = 48 * time.Hour
+
+// WaitlistRepository is an interface for waitlist operations. Customers wait for a film at a store when no copy
+// of it is available there; returning a copy holds it for the customer who has waited longest.
+type WaitlistRepository interface {
+	Repository[entity.WaitlistEntry]
+
+	// FindQueue finds the customers waiting for a film at a store, first come first
+	FindQueue(ctx context.Context, filmID, storeID uint, opts ...QueryOption) ([]entity.WaitlistEntry, error)
+
+	// FindByCustomer finds waitlist entries by customer ID
+	FindByCustomer(ctx context.Context, customerID uint, opts ...QueryOption) ([]entity.WaitlistEntry, error)
+
+	// Join validates and creates a waiting entry. It fails with a *ValidationError while a copy of the film is
+	// available at the store.
+	Join(ctx context.Context, entry *entity.WaitlistEntry) error
+
+	// Leave cancels a waiting entry
+	Leave(ctx context.Context, waitlistID uint) (*entity.WaitlistEntry, error)
+}
+
+// WaitlistRepositoryImpl is an implementation of WaitlistRepository
+type WaitlistRepositoryImpl struct {
+	BaseRepository[entity.WaitlistEntry]
+}
+
+// NewWaitlistRepository creates a new WaitlistRepository
+func NewWaitlistRepository(db *gorm.DB) WaitlistRepository {
+	return &WaitlistRepositoryImpl{
+		BaseRepository: BaseRepository[entity.WaitlistEntry]{
+			DB:           db,
+			Associations: []string{"Film", "Store", "Customer", "Reservation"},
+		},
+	}
+}
+
+// FindQueue finds the customers waiting for a film at a store, first come first
+func (r *WaitlistRepositoryImpl) FindQueue(ctx context.Context, filmID, storeID uint, opts ...QueryOption) ([]entity.WaitlistEntry, error) {
+	var entries []entity.WaitlistEntry
+	if err := r.query(ctx, opts).Scopes(waiting(filmID, storeID)).Find(&entries).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return entries, nil
+}
+
+// FindByCustomer finds waitlist entries by customer ID
+func (r *WaitlistRepositoryImpl) FindByCustomer(ctx context.Context, customerID uint, opts ...QueryOption) ([]entity.WaitlistEntry, error) {
+	var entries []entity.WaitlistEntry
+	if err := r.query(ctx, opts).Where("customer_id = ?", customerID).Find(&entries).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return entries, nil
+}
+
+// Join validates and creates a waiting entry unless a copy of the film is available at the store
+func (r *WaitlistRepositoryImpl) Join(ctx context.Context, entry *entity.WaitlistEntry) error {
+	entry.Status = entity.WaitlistWaiting
+	if err := validateEntity(entry); err != nil {
+		return err
+	}
+	return translateError(r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&entity.Inventory{}).Scopes(available).
+			Where("inventory.film_id = ? AND inventory.store_id = ?", entry.FilmID, entry.StoreID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return &ValidationError{Fields: []FieldError{{Field: "film_id", Rule: "unavailable", Param: strconv.FormatUint(uint64(entry.StoreID), 10)}}}
+		}
+		return tx.Create(entry).Error
+	}))
+}
+
+// Leave cancels a waiting entry
+func (r *WaitlistRepositoryImpl) Leave(ctx context.Context, waitlistID uint) (*entity.WaitlistEntry, error) {
+	var entry entity.WaitlistEntry
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("waitlist_id = ?", waitlistID).First(&entry).Error; err != nil {
+			return err
+		}
+		if entry.Status != entity.WaitlistWaiting {
+			return &ValidationError{Fields: []FieldError{{Field: "status", Rule: "transition", Param: string(entry.Status) + " to " + string(entity.WaitlistCancelled)}}}
+		}
+		entry.Status = entity.WaitlistCancelled
+		return updateVersioned(tx, &entry, &entry.Versioned)
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &entry, nil
+}
+
+// waiting restricts a query of waitlist entries to the customers waiting for a film at a store, first come first
+func waiting(filmID, storeID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("film_id = ? AND store_id = ? AND status = ?", filmID, storeID, entity.WaitlistWaiting).
+			Order("created_at, waitlist_id")
+	}
+}
+
+// holdForNextInLine reserves a copy that came back for the customer who has waited longest for its film at its
+// store, within tx, and fulfils their entry, which stays locked until tx ends. It does nothing when nobody waits or the copy is out of circulation.
+func holdForNextInLine(tx *gorm.DB, inventoryID uint, now time.Time) error {
+	var item entity.Inventory
+	err := tx.Where("inventory_id = ?", inventoryID).Take(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Entries locked by a concurrent return of another copy are skipped so that it holds its copy for the next
+	// customer in line instead of the same one
+	var entry entity.WaitlistEntry
+	err = forUpdate(tx, "waitlist", true).Scopes(waiting(item.FilmID, item.StoreID)).Take(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	reservation := entity.Reservation{
+		InventoryID: inventoryID,
+		CustomerID:  entry.CustomerID,
+		Status:      entity.ReservationActive,
+		ExpiresAt:   now.Add(WaitlistHold),
+	}
+	if err := tx.Create(&reservation).Error; err != nil {
+		return err
+	}
+	entry.Status = entity.WaitlistFulfilled
+	entry.ReservationID = &reservation.ReservationID
+	return updateVersioned(tx, &entry, &entry.Versioned)
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"CortexMCP/db/entity"
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupWaitlistTest(t *testing.T) (*sql.DB, sqlmock.Sqlmock, WaitlistRepository, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+
+	dialector := mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	})
+
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open gorm database: %v", err)
+	}
+
+	repo := NewWaitlistRepository(gormDB)
+
+	return db, mock, repo, func() {
+		db.Close()
+	}
+}
+
+var waitlistColumns = []string{"version", "waitlist_id", "film_id", "store_id", "customer_id", "status", "reservation_id"}
+
+func TestWaitlistRepository_Join(t *testing.T) {
+	_, mock, repo, cleanup := setupWaitlistTest(t)
+	defer cleanup()
+
+	entry := &entity.WaitlistEntry{FilmID: 3, StoreID: 1, CustomerID: 9}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `inventory` LEFT JOIN rental ON rental.inventory_id = inventory.inventory_id AND rental.status IN (?,?,?) AND rental.deleted_at IS NULL WHERE (inventory.film_id = ? AND inventory.store_id = ?) AND rental.rental_id IS NULL")).
+		WithArgs(entity.RentalReserved, entity.RentalOut, entity.RentalLost, 3, 1, entity.ReservationActive, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `waitlist`")).
+		WithArgs(
+			1, // Version
+			3, // FilmID
+			1, // StoreID
+			9, // CustomerID
+			entity.WaitlistWaiting,
+			nil,              // ReservationID
+			sqlmock.AnyArg(), // CreatedAt
+			sqlmock.AnyArg(), // UpdatedAt
+			sqlmock.AnyArg(), // DeletedAt
+		).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Join(context.Background(), entry); err != nil {
+		t.Fatalf("Error joining waitlist: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestWaitlistRepository_Join_Available(t *testing.T) {
+	_, mock, repo, cleanup := setupWaitlistTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `inventory`")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectRollback()
+
+	var validationErr *ValidationError
+	err := repo.Join(context.Background(), &entity.WaitlistEntry{FilmID: 3, StoreID: 1, CustomerID: 9})
+	if !errors.As(err, &validationErr) || validationErr.Fields[0].Rule != "unavailable" {
+		t.Errorf("Expected joining with a copy available to be rejected, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestWaitlistRepository_FindQueue(t *testing.T) {
+	_, mock, repo, cleanup := setupWaitlistTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `waitlist` WHERE (film_id = ? AND store_id = ? AND status = ?) AND `waitlist`.`deleted_at` IS NULL ORDER BY created_at, waitlist_id")).
+		WithArgs(3, 1, entity.WaitlistWaiting).
+		WillReturnRows(sqlmock.NewRows(waitlistColumns).
+			AddRow(1, 4, 3, 1, 9, "waiting", nil).
+			AddRow(1, 6, 3, 1, 2, "waiting", nil))
+
+	entries, err := repo.FindQueue(context.Background(), 3, 1)
+	if err != nil {
+		t.Fatalf("Error finding queue: %v", err)
+	}
+	if len(entries) != 2 || entries[0].CustomerID != 9 {
+		t.Errorf("Expected customer 9 first of 2, got %+v", entries)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestWaitlistRepository_Leave(t *testing.T) {
+	_, mock, repo, cleanup := setupWaitlistTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `waitlist` WHERE waitlist_id = ?")).
+		WithArgs(4, 1).
+		WillReturnRows(sqlmock.NewRows(waitlistColumns).AddRow(1, 4, 3, 1, 9, "waiting", nil))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `waitlist` SET ")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	entry, err := repo.Leave(context.Background(), 4)
+	if err != nil {
+		t.Fatalf("Error leaving waitlist: %v", err)
+	}
+	if entry.Status != entity.WaitlistCancelled || entry.Version != 2 {
+		t.Errorf("Expected cancelled entry at version 2, got %s at %d", entry.Status, entry.Version)
+	}
+
+	// A fulfilled entry has left the queue already
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `waitlist` WHERE waitlist_id = ?")).
+		WithArgs(4, 1).
+		WillReturnRows(sqlmock.NewRows(waitlistColumns).AddRow(2, 4, 3, 1, 9, "fulfilled", 12))
+	mock.ExpectRollback()
+
+	var validationErr *ValidationError
+	if _, err := repo.Leave(context.Background(), 4); !errors.As(err, &validationErr) {
+		t.Errorf("Expected leaving a fulfilled entry to be rejected, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
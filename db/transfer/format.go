@@ -0,0 +1,223 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// rowWriter writes exported rows in a file format
+type rowWriter interface {
+	header(columns []string) error
+	write(values []any) error
+	flush() error
+}
+
+// rowReader reads imported rows in a file format. It returns io.EOF after the last row
+// and a *syntaxError for lines that cannot be parsed.
+type rowReader interface {
+	next() (line int, values map[string]string, err error)
+}
+
+// syntaxError is a line of an import that is not valid CSV or JSON
+type syntaxError struct {
+	message string
+}
+
+// Error implements the error interface
+func (e *syntaxError) Error() string {
+	return e.message
+}
+
+func newRowWriter(format Format, w io.Writer) (rowWriter, error) {
+	switch format {
+	case CSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case JSONL:
+		return &jsonlWriter{w: bufio.NewWriter(w)}, nil
+	}
+	return nil, invalidArgument("format", "csv jsonl")
+}
+
+func newRowReader(format Format, r io.Reader) (rowReader, error) {
+	switch format {
+	case CSV:
+		reader := csv.NewReader(r)
+		reader.ReuseRecord = true
+		return &csvReader{r: reader}, nil
+	case JSONL:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+		return &jsonlReader{s: scanner}, nil
+	}
+	return nil, invalidArgument("format", "csv jsonl")
+}
+
+// csvWriter writes a header row followed by one record per row. NULL is written as an empty field.
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (c *csvWriter) header(columns []string) error {
+	c.record = make([]string, len(columns))
+	return c.w.Write(columns)
+}
+
+func (c *csvWriter) write(values []any) error {
+	for i, value := range values {
+		c.record[i] = formatValue(value)
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// formatValue formats a column value for CSV
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// jsonlWriter writes one JSON object per row with keys in column order
+type jsonlWriter struct {
+	w    *bufio.Writer
+	keys [][]byte
+}
+
+func (j *jsonlWriter) header(columns []string) error {
+	j.keys = make([][]byte, len(columns))
+	for i, column := range columns {
+		key, err := json.Marshal(column)
+		if err != nil {
+			return err
+		}
+		j.keys[i] = key
+	}
+	return nil
+}
+
+func (j *jsonlWriter) write(values []any) error {
+	var line bytes.Buffer
+	line.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			line.WriteByte(',')
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		line.Write(j.keys[i])
+		line.WriteByte(':')
+		line.Write(encoded)
+	}
+	line.WriteString("}\n")
+	_, err := j.w.Write(line.Bytes())
+	return err
+}
+
+func (j *jsonlWriter) flush() error {
+	return j.w.Flush()
+}
+
+// csvReader reads a header row naming the columns followed by one record per row
+type csvReader struct {
+	r       *csv.Reader
+	columns []string
+}
+
+func (c *csvReader) next() (int, map[string]string, error) {
+	if c.columns == nil {
+		header, err := c.r.Read()
+		if errors.Is(err, io.EOF) {
+			return 0, nil, err
+		}
+		if err != nil {
+			return 1, nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+		c.columns = append([]string(nil), header...)
+	}
+
+	record, err := c.r.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.StartLine, nil, &syntaxError{message: "malformed CSV: " + parseErr.Err.Error()}
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	line, _ := c.r.FieldPos(0)
+	values := make(map[string]string, len(record))
+	for i, value := range record {
+		values[c.columns[i]] = value
+	}
+	return line, values, nil
+}
+
+// jsonlReader reads one JSON object per line, skipping blank lines. Null values are treated as absent.
+type jsonlReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func (j *jsonlReader) next() (int, map[string]string, error) {
+	for j.s.Scan() {
+		j.line++
+		data := bytes.TrimSpace(j.s.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var object map[string]any
+		if err := decoder.Decode(&object); err != nil {
+			return j.line, nil, &syntaxError{message: "malformed JSON: " + err.Error()}
+		}
+
+		values := make(map[string]string, len(object))
+		for key, value := range object {
+			switch v := value.(type) {
+			case nil:
+			case string:
+				values[key] = v
+			case json.Number:
+				values[key] = v.String()
+			case bool:
+				values[key] = strconv.FormatBool(v)
+			default:
+				return j.line, nil, &syntaxError{message: fmt.Sprintf("malformed JSON: %q must be a string, number or boolean", key)}
+			}
+		}
+		return j.line, values, nil
+	}
+	if err := j.s.Err(); err != nil {
+		return j.line, nil, err
+	}
+	return j.line, nil, io.EOF
+}
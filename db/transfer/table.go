@@ -0,0 +1,264 @@
+package transfer
+
+import (
+	"CortexMCP/db/repository"
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// modelColumns are the gorm.Model and entity.Versioned bookkeeping columns, which are not part of the exchanged data
+var modelColumns = map[string]bool{"id": true, "created_at": true, "updated_at": true, "deleted_at": true, "version": true}
+
+// textUnmarshaler is the type of the values parsed from their text form, such as entity.Money
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// timeLayouts are the accepted formats of date and time values, tried in order
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+// entityTable imports and exports entities of type T
+type entityTable[T any] struct{}
+
+// columns returns the fields of T exchanged in files, in declaration order
+func (entityTable[T]) columns(db *gorm.DB) ([]*schema.Field, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+
+	var fields []*schema.Field
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName != "" && !modelColumns[field.DBName] {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+func (t entityTable[T]) export(ctx context.Context, db *gorm.DB, batchSize int, w rowWriter) (int, error) {
+	fields, err := t.columns(db)
+	if err != nil {
+		return 0, err
+	}
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.DBName
+	}
+	if err := w.header(names); err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	var batch []T
+	err = db.WithContext(ctx).FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			value := reflect.ValueOf(&batch[i]).Elem()
+			values := make([]any, len(fields))
+			for j, field := range fields {
+				values[j], _ = field.ValueOf(ctx, value)
+			}
+			if err := w.write(values); err != nil {
+				return err
+			}
+			rows++
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return rows, err
+	}
+	return rows, w.flush()
+}
+
+func (t entityTable[T]) load(ctx context.Context, db *gorm.DB, batchSize int, r rowReader, opts ImportOptions) (*ImportReport, error) {
+	fields, err := t.columns(db)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*schema.Field, len(fields))
+	for _, field := range fields {
+		byName[field.DBName] = field
+	}
+
+	repo := &repository.BaseRepository[T]{DB: db}
+	report := &ImportReport{}
+	var batch []*T
+	var lines []int
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		var results []repository.RowResult
+		if len(opts.ConflictKey) > 0 {
+			results, err = repo.Upsert(ctx, batch, opts.ConflictKey...)
+		} else {
+			results, err = repo.CreateMany(ctx, batch, batchSize)
+		}
+		var bulkErr *repository.BulkError
+		if err != nil && !errors.As(err, &bulkErr) {
+			return err
+		}
+		for _, result := range results {
+			if result.Err != nil {
+				report.fail(lines[result.Index], result.Err)
+			} else {
+				report.Imported++
+			}
+		}
+		batch, lines = nil, nil
+		return nil
+	}
+
+	for {
+		line, values, err := r.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var syntaxErr *syntaxError
+		if errors.As(err, &syntaxErr) {
+			report.fail(line, err)
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+
+		row, err := decodeRow[T](ctx, byName, values)
+		if err != nil {
+			report.fail(line, err)
+			continue
+		}
+		batch = append(batch, row)
+		lines = append(lines, line)
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	err = flush()
+	// Lines rejected by the database are reported after lines rejected while parsing
+	sort.SliceStable(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Line < report.Errors[j].Line
+	})
+	return report, err
+}
+
+// decodeRow builds an entity from column values. Empty values leave the field at its zero value.
+func decodeRow[T any](ctx context.Context, fields map[string]*schema.Field, values map[string]string) (*T, error) {
+	row := new(T)
+	target := reflect.ValueOf(row).Elem()
+
+	var invalid []repository.FieldError
+	for name, text := range values {
+		field, ok := fields[name]
+		if !ok {
+			invalid = append(invalid, repository.FieldError{Field: name, Rule: "unknown"})
+			continue
+		}
+		if text == "" {
+			continue
+		}
+		value, err := parseValue(field.FieldType, text)
+		if err != nil {
+			invalid = append(invalid, repository.FieldError{Field: name, Rule: "type", Param: typeName(field.FieldType)})
+			continue
+		}
+		field.ReflectValueOf(ctx, target).Set(value)
+	}
+	if len(invalid) > 0 {
+		return nil, &repository.ValidationError{Fields: invalid}
+	}
+	return row, nil
+}
+
+// parseValue parses text into a value of type t, which may be a pointer
+func parseValue(t reflect.Type, text string) (reflect.Value, error) {
+	if t.Kind() == reflect.Pointer {
+		value, err := parseValue(t.Elem(), text)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		pointer := reflect.New(t.Elem())
+		pointer.Elem().Set(value)
+		return pointer, nil
+	}
+
+	value := reflect.New(t).Elem()
+	if t == reflect.TypeOf(time.Time{}) {
+		for _, layout := range timeLayouts {
+			if parsed, err := time.Parse(layout, text); err == nil {
+				value.Set(reflect.ValueOf(parsed))
+				return value, nil
+			}
+		}
+		return reflect.Value{}, fmt.Errorf("invalid time %q", text)
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshaler) {
+		if err := value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+			return reflect.Value{}, err
+		}
+		return value, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		value.SetString(text)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		value.SetFloat(f)
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported type %s", t)
+	}
+	return value, nil
+}
+
+// typeName names the expected type of a column in validation errors
+func typeName(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return "time"
+	case reflect.PointerTo(t).Implements(textUnmarshaler):
+		return strings.ToLower(t.Name())
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return "integer"
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return "number"
+	}
+	return t.Kind().String()
+}
@@ -0,0 +1,188 @@
+package transfer
+
+import (
+	"CortexMCP/db/entity"
+	"CortexMCP/db/repository"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// defaultBatchSize is the number of rows read from or written to the database at a time
+const defaultBatchSize = 500
+
+// Format is a file format for imports and exports
+type Format string
+
+// Supported formats
+const (
+	CSV   Format = "csv"
+	JSONL Format = "jsonl"
+)
+
+// Formats lists the supported formats
+var Formats = []Format{CSV, JSONL}
+
+// MimeType returns the media type of the format
+func (f Format) MimeType() string {
+	if f == JSONL {
+		return "application/jsonl"
+	}
+	return "text/csv"
+}
+
+// ImportOptions configure an import
+type ImportOptions struct {
+	// ConflictKey, when set, upserts rows on these unique columns instead of inserting them
+	ConflictKey []string
+}
+
+// LineError is a line of an import that was not imported
+type LineError struct {
+	// Line is the line number in the input, starting at 1 with the CSV header
+	Line int `json:"line"`
+
+	// Message describes why the line was rejected
+	Message string `json:"message"`
+
+	// Fields lists the columns that failed validation
+	Fields []repository.FieldError `json:"fields,omitempty"`
+}
+
+// ImportReport summarizes an import
+type ImportReport struct {
+	Imported int         `json:"imported"`
+	Failed   int         `json:"failed"`
+	Errors   []LineError `json:"errors,omitempty"`
+}
+
+// Service imports and exports entities as CSV or JSON Lines, with columns named after the gorm columns
+// of the entities
+type Service interface {
+	// Tables returns the names of the tables that can be imported and exported
+	Tables() []string
+
+	// Export streams every row of the table to w and returns the number of rows written
+	Export(ctx context.Context, table string, format Format, w io.Writer) (int, error)
+
+	// Import creates the rows read from r. Lines that cannot be parsed, fail validation or are rejected by
+	// the database are reported and skipped; the error is only set when the import cannot continue.
+	Import(ctx context.Context, table string, format Format, r io.Reader, opts ImportOptions) (*ImportReport, error)
+}
+
+// ServiceImpl is an implementation of Service
+type ServiceImpl struct {
+	DB        *gorm.DB
+	BatchSize int
+}
+
+// NewService creates a new Service
+func NewService(db *gorm.DB) Service {
+	return &ServiceImpl{
+		DB:        db,
+		BatchSize: defaultBatchSize,
+	}
+}
+
+// table imports and exports the rows of one entity
+type table interface {
+	export(ctx context.Context, db *gorm.DB, batchSize int, w rowWriter) (int, error)
+	load(ctx context.Context, db *gorm.DB, batchSize int, r rowReader, opts ImportOptions) (*ImportReport, error)
+}
+
+var tables = map[string]table{
+	"actor":       entityTable[entity.Actor]{},
+	"category":    entityTable[entity.Category]{},
+	"customer":    entityTable[entity.Customer]{},
+	"film":        entityTable[entity.Film]{},
+	"inventory":   entityTable[entity.Inventory]{},
+	"payment":     entityTable[entity.Payment]{},
+	"rental":      entityTable[entity.Rental]{},
+	"reservation": entityTable[entity.Reservation]{},
+	"staff":       entityTable[entity.Staff]{},
+	"store":       entityTable[entity.Store]{},
+	"waitlist":    entityTable[entity.WaitlistEntry]{},
+}
+
+// Tables returns the names of the tables that can be imported and exported
+func (s *ServiceImpl) Tables() []string {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Export streams every row of the table to w and returns the number of rows written
+func (s *ServiceImpl) Export(ctx context.Context, table string, format Format, w io.Writer) (int, error) {
+	t, err := s.table(table)
+	if err != nil {
+		return 0, err
+	}
+	writer, err := newRowWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+	return t.export(ctx, s.DB, s.batchSize(), writer)
+}
+
+// Import creates the rows read from r, reporting the lines that were skipped
+func (s *ServiceImpl) Import(ctx context.Context, table string, format Format, r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	t, err := s.table(table)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := newRowReader(format, r)
+	if err != nil {
+		return nil, err
+	}
+	return t.load(ctx, s.DB, s.batchSize(), reader, opts)
+}
+
+func (s *ServiceImpl) table(name string) (table, error) {
+	t, ok := tables[strings.ToLower(name)]
+	if !ok {
+		return nil, invalidArgument("table", strings.Join(s.Tables(), " "))
+	}
+	return t, nil
+}
+
+func (s *ServiceImpl) batchSize() int {
+	if s.BatchSize <= 0 {
+		return defaultBatchSize
+	}
+	return s.BatchSize
+}
+
+// invalidArgument reports an argument that is not one of the allowed values
+func invalidArgument(name, allowed string) error {
+	return &repository.ValidationError{Fields: []repository.FieldError{{Field: name, Rule: "oneof", Param: allowed}}}
+}
+
+// fail records a rejected line
+func (r *ImportReport) fail(line int, err error) {
+	r.Failed++
+	lineErr := LineError{Line: line, Message: "the row could not be written"}
+
+	var validationErr *repository.ValidationError
+	var syntaxErr *syntaxError
+	switch {
+	case errors.As(err, &validationErr):
+		lineErr.Message = repository.ErrValidation.Error()
+		lineErr.Fields = validationErr.Fields
+	case errors.As(err, &syntaxErr):
+		lineErr.Message = syntaxErr.Error()
+	case errors.Is(err, repository.ErrConflict):
+		lineErr.Message = repository.ErrConflict.Error()
+	case errors.Is(err, repository.ErrReferenced):
+		lineErr.Message = repository.ErrReferenced.Error()
+	case errors.Is(err, repository.ErrValidation):
+		lineErr.Message = repository.ErrValidation.Error()
+	}
+	r.Errors = append(r.Errors, lineErr)
+}
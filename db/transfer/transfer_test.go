@@ -0,0 +1,207 @@
+package transfer
+
+import (
+	"CortexMCP/db/repository"
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupTransferTest(t *testing.T) (sqlmock.Sqlmock, Service) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	dialector := mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	})
+
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open gorm database: %v", err)
+	}
+	return mock, NewService(gormDB)
+}
+
+func TestService_ExportCSV(t *testing.T) {
+	mock, service := setupTransferTest(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `category` WHERE `category`.`deleted_at` IS NULL ORDER BY `category`.`id` LIMIT ?")).
+		WithArgs(defaultBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "name"}).
+			AddRow(1, 1, "Action").
+			AddRow(2, 2, "Sci, Fi"))
+
+	var out bytes.Buffer
+	rows, err := service.Export(context.Background(), "category", CSV, &out)
+	if err != nil {
+		t.Fatalf("Error exporting categories: %v", err)
+	}
+	if rows != 2 {
+		t.Errorf("Expected 2 rows, got %d", rows)
+	}
+
+	expected := "category_id,name\n1,Action\n2,\"Sci, Fi\"\n"
+	if out.String() != expected {
+		t.Errorf("Unexpected export:\n%s", out.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestService_ExportJSONL(t *testing.T) {
+	mock, service := setupTransferTest(t)
+
+	rented := time.Date(2005, 5, 24, 22, 53, 30, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rental_id", "rental_date", "inventory_id", "customer_id", "return_date", "staff_id", "status"}).
+			AddRow(1, 1, rented, 367, 130, nil, 1, "out"))
+
+	var out bytes.Buffer
+	if _, err := service.Export(context.Background(), "rental", JSONL, &out); err != nil {
+		t.Fatalf("Error exporting rentals: %v", err)
+	}
+
+	expected := `{"rental_id":1,"rental_date":"2005-05-24T22:53:30Z","inventory_id":367,"customer_id":130,"return_date":null,"staff_id":1,"status":"out"}` + "\n"
+	if out.String() != expected {
+		t.Errorf("Unexpected export:\n%s", out.String())
+	}
+}
+
+func TestService_ImportCSV(t *testing.T) {
+	mock, service := setupTransferTest(t)
+
+	input := strings.Join([]string{
+		"store_id,first_name,last_name,email,address,district,city,country,postal_code,phone",
+		"1,Mary,Smith,mary.smith@dvdrental.com,1913 Hanoi Way,Nagasaki,Sasebo,Japan,35200,28303384290",
+		"one,Patricia,Johnson,patricia.johnson@dvdrental.com,1121 Loja Avenue,California,San Bernardino,United States,17886,838635286649",
+		"2,Linda,Williams,not-an-email,692 Joliet Street,Attika,Athenai,Greece,83579,448477190408",
+		"2,Barbara,Jones,barbara.jones@dvdrental.com,1566 Inegl Manor,Mandalay,Myingyan,Myanmar,53561,705814003527",
+		"2,too,few",
+	}, "\n")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `customer`")).WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectCommit()
+
+	report, err := service.Import(context.Background(), "customer", CSV, strings.NewReader(input), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Error importing customers: %v", err)
+	}
+	if report.Imported != 2 || report.Failed != 3 {
+		t.Fatalf("Expected 2 imported and 3 failed, got %+v", report)
+	}
+
+	expected := []struct {
+		line  int
+		field string
+	}{{line: 3, field: "store_id"}, {line: 4, field: "email"}, {line: 6}}
+	for i, e := range expected {
+		got := report.Errors[i]
+		if got.Line != e.line {
+			t.Errorf("Expected error %d on line %d, got %+v", i, e.line, got)
+		}
+		if e.field != "" && (len(got.Fields) != 1 || got.Fields[0].Field != e.field) {
+			t.Errorf("Expected error %d on field %s, got %+v", i, e.field, got.Fields)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestService_ImportPaymentAmounts(t *testing.T) {
+	mock, service := setupTransferTest(t)
+
+	input := strings.Join([]string{
+		"customer_id,staff_id,rental_id,kind,amount,payment_date",
+		"1,1,1,charge,4.99,2024-05-24",
+		"1,1,1,late_fee,1.5 USD,2024-05-30",
+		"1,1,2,charge,four,2024-05-24",
+	}, "\n")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `payment`")).
+		WithArgs(
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 1, 1, 1, "charge", "4.99", sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 1, 1, 1, "late_fee", "1.50", sqlmock.AnyArg(),
+		).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectCommit()
+
+	report, err := service.Import(context.Background(), "payment", CSV, strings.NewReader(input), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Error importing payments: %v", err)
+	}
+	if report.Imported != 2 || report.Failed != 1 {
+		t.Fatalf("Expected 2 imported and 1 failed, got %+v", report)
+	}
+	if fields := report.Errors[0].Fields; len(fields) != 1 || fields[0].Field != "amount" || fields[0].Param != "money" {
+		t.Errorf("Expected an amount that is not money, got %+v", fields)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestService_ImportJSONL(t *testing.T) {
+	mock, service := setupTransferTest(t)
+
+	input := `{"film_id":1,"store_id":1}
+
+{"film_id":2,"store_id":1,"shelf":"A3"}
+{"film_id":3,`
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `inventory`")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, 1, 1, 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	report, err := service.Import(context.Background(), "inventory", JSONL, strings.NewReader(input), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Error importing inventory: %v", err)
+	}
+	if report.Imported != 1 || report.Failed != 2 {
+		t.Fatalf("Expected 1 imported and 2 failed, got %+v", report)
+	}
+	if report.Errors[0].Line != 3 || report.Errors[0].Fields[0].Rule != "unknown" {
+		t.Errorf("Expected unknown column on line 3, got %+v", report.Errors[0])
+	}
+	if report.Errors[1].Line != 4 || !strings.HasPrefix(report.Errors[1].Message, "malformed JSON") {
+		t.Errorf("Expected malformed JSON on line 4, got %+v", report.Errors[1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestService_UnknownTableAndFormat(t *testing.T) {
+	_, service := setupTransferTest(t)
+
+	if _, err := service.Export(context.Background(), "film_text", CSV, &bytes.Buffer{}); !errors.Is(err, repository.ErrValidation) {
+		t.Errorf("Expected ErrValidation for unknown table, got %v", err)
+	}
+	if _, err := service.Import(context.Background(), "film", "xml", strings.NewReader(""), ImportOptions{}); !errors.Is(err, repository.ErrValidation) {
+		t.Errorf("Expected ErrValidation for unknown format, got %v", err)
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"CortexMCP/app"
+	migrations "CortexMCP/db"
+	"CortexMCP/db/audit"
+	"CortexMCP/db/changes"
+	"CortexMCP/db/repository"
+	"CortexMCP/db/transfer"
+	"CortexMCP/pkg/auth"
+	"CortexMCP/pkg/db"
+	"CortexMCP/pkg/policy"
+	"CortexMCP/pkg/principal"
+	"CortexMCP/pkg/telemetry"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func main() {
+	configPath := flag.String("config", "", "path to the configuration file (defaults to the embedded configuration)")
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		fmt.Fprintf(out, "Usage: %s [flags] [serve|serve-http|migrate|export|import|token] [command flags] [arguments]\n\n", os.Args[0])
+		fmt.Fprintln(out, "  serve-http                                            serve MCP over authenticated HTTP")
+		fmt.Fprintln(out, "  export [-format csv|jsonl] <table> [file]              export a table to file or stdout")
+		fmt.Fprintln(out, "  import [-format csv|jsonl] [-conflict-key col,...] <table> [file]")
+		fmt.Fprintln(out, "                                                        import rows from file or stdin")
+		fmt.Fprintln(out, "  token [-ttl 24h] <principal>                          issue a bearer token, e.g. for staff:2")
+		fmt.Fprintln(out)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(flag.Args(), *configPath); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string, configPath string) error {
+	cfg, err := app.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	command := ""
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	if command == "token" {
+		// Issuing tokens needs only the configured secret
+		return runToken(cfg, args)
+	}
+
+	pool, err := cfg.Database.Pool()
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := audit.Register(pool); err != nil {
+		return err
+	}
+	if err := policy.Register(pool); err != nil {
+		return err
+	}
+	if err := repository.Register(pool); err != nil {
+		return err
+	}
+	if err := telemetry.RegisterGORM(pool); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := telemetry.SetupTracing(ctx, cfg.Telemetry.Tracing, app.Name, app.Version)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		flush, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flush); err != nil {
+			log.Printf("failed to flush spans: %v", err)
+		}
+	}()
+
+	switch command {
+	case "", "serve", "serve-http":
+		sqlDB, err := pool.DB()
+		if err != nil {
+			return err
+		}
+		metrics := telemetry.NewMetrics()
+		if err := metrics.RegisterDBStats(sqlDB, cfg.Database.DbName); err != nil {
+			return err
+		}
+		if cfg.Telemetry.Metrics.Addr != "" {
+			go serveMetrics(ctx, cfg.Telemetry.Metrics, metrics.Handler())
+		}
+
+		repos := app.NewRepositories(pool)
+		opts := []app.Option{app.WithMetrics(metrics)}
+		if sqlLogger, ok := pool.Logger.(*db.Logger); ok {
+			opts = append(opts, app.WithSlowQueries(sqlLogger.SlowQueries()))
+		}
+		if cfg.Cache.Enabled {
+			cache := app.NewCache(cfg.Cache)
+			repos.UseCache(cache)
+			opts = append(opts, app.WithCacheStats(cache))
+		}
+		if cfg.LostItems.Enabled {
+			lostItems := app.NewLostItems(repos.Rental, cfg.LostItems)
+			opts = append(opts, app.WithLostItems(lostItems))
+			go lostItems.Run(ctx)
+		}
+		server := app.NewServer(cfg, repos, opts...)
+		if command == "serve-http" {
+			return serveHTTP(ctx, cfg, server, metrics.Handler())
+		}
+		if cfg.Changes.Enabled {
+			source, err := changes.NewSource(pool, cfg.Changes)
+			if err != nil {
+				return err
+			}
+			go func() {
+				if err := app.PublishChanges(ctx, server, source); err != nil {
+					log.Printf("stopped publishing changes: %v", err)
+				}
+			}()
+		}
+		return server.ServeStdio(ctx, os.Stdin, os.Stdout)
+	case "migrate":
+		sqlDB, err := pool.DB()
+		if err != nil {
+			return err
+		}
+		return migrations.RunMigrations(sqlDB)
+	case "export":
+		return runExport(ctx, transfer.NewService(pool), args)
+	case "import":
+		return runImport(ctx, transfer.NewService(pool), args)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+// serveHTTP serves the MCP server at the configured address, authenticating every request, and the metrics
+// next to it until ctx is cancelled
+func serveHTTP(ctx context.Context, cfg *app.Config, handler, metrics http.Handler) error {
+	authenticator, err := cfg.Auth.Authenticator()
+	if err != nil {
+		return fmt.Errorf("refusing to serve HTTP without authentication: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(cfg.HTTP.Path, auth.Middleware(authenticator, handler))
+	mux.Handle(cfg.Telemetry.Metrics.Path, metrics)
+
+	log.Printf("serving MCP on %s%s", cfg.HTTP.Addr, cfg.HTTP.Path)
+	return listen(ctx, cfg.HTTP.Addr, mux)
+}
+
+// serveMetrics serves the metrics on their own address until ctx is cancelled
+func serveMetrics(ctx context.Context, cfg telemetry.MetricsConfig, metrics http.Handler) {
+	mux := http.NewServeMux()
+	mux.Handle(cfg.Path, metrics)
+
+	log.Printf("serving metrics on %s%s", cfg.Addr, cfg.Path)
+	if err := listen(ctx, cfg.Addr, mux); err != nil {
+		log.Printf("failed to serve metrics: %v", err)
+	}
+}
+
+// listen serves handler at addr until ctx is cancelled, then shuts down gracefully
+func listen(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// runToken prints a bearer token for the principal given as argument, signed with the configured HMAC secret
+func runToken(cfg *app.Config, args []string) error {
+	flags := flag.NewFlagSet("token", flag.ExitOnError)
+	ttl := flags.Duration("ttl", 24*time.Hour, "how long the token is valid for")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: token [-ttl 24h] <principal>")
+	}
+	if cfg.Auth.HMACSecret == "" {
+		return fmt.Errorf("auth.hmacSecret is not configured")
+	}
+
+	token, err := auth.NewHMACTokens([]byte(cfg.Auth.HMACSecret)).Issue(principal.Parse(flags.Arg(0)), *ttl)
+	if err != nil {
+		return err
+	}
+	fmt.Println(token)
+	return nil
+}
+
+// runExport writes a table to the file named by the second argument, or to stdout
+func runExport(ctx context.Context, service transfer.Service, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "", "file format: csv or jsonl (defaults to the file extension, then csv)")
+	_ = flags.Parse(args)
+	if flags.NArg() < 1 {
+		return fmt.Errorf("usage: export [-format csv|jsonl] <table> [file]")
+	}
+
+	out := io.Writer(os.Stdout)
+	path := flags.Arg(1)
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	rows, err := service.Export(ctx, flags.Arg(0), fileFormat(*format, path), out)
+	if err != nil {
+		return err
+	}
+	log.Printf("exported %d rows from %s", rows, flags.Arg(0))
+	return nil
+}
+
+// runImport reads rows for a table from the file named by the second argument, or from stdin,
+// and prints the import report as JSON
+func runImport(ctx context.Context, service transfer.Service, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "file format: csv or jsonl (defaults to the file extension, then csv)")
+	conflictKey := flags.String("conflict-key", "", "comma-separated unique columns to upsert on, e.g. email")
+	_ = flags.Parse(args)
+	if flags.NArg() < 1 {
+		return fmt.Errorf("usage: import [-format csv|jsonl] [-conflict-key col,...] <table> [file]")
+	}
+
+	in := io.Reader(os.Stdin)
+	path := flags.Arg(1)
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	var opts transfer.ImportOptions
+	if *conflictKey != "" {
+		opts.ConflictKey = strings.Split(*conflictKey, ",")
+	}
+	report, err := service.Import(ctx, flags.Arg(0), fileFormat(*format, path), in, opts)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", report.Failed, report.Failed+report.Imported)
+	}
+	return nil
+}
+
+// fileFormat returns the explicit format, or the format matching the file extension, or CSV
+func fileFormat(explicit, path string) transfer.Format {
+	if explicit != "" {
+		return transfer.Format(strings.ToLower(explicit))
+	}
+	if ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), "."); ext == string(transfer.JSONL) {
+		return transfer.JSONL
+	}
+	return transfer.CSV
+}
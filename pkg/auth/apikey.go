@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"CortexMCP/pkg/principal"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+)
+
+// APIKeyHeader is the header carrying a static API key
+const APIKeyHeader = "X-API-Key"
+
+// APIKeys authenticates requests carrying one of a fixed set of API keys in the X-API-Key header
+type APIKeys struct {
+	keys []apiKey
+}
+
+// apiKey is a key's digest, so that comparisons take the same time whatever the key's length
+type apiKey struct {
+	digest    [sha256.Size]byte
+	principal principal.Principal
+}
+
+// NewAPIKeys creates an authenticator for the given keys and the principals they identify
+func NewAPIKeys(keys map[string]principal.Principal) *APIKeys {
+	a := &APIKeys{}
+	for key, p := range keys {
+		a.keys = append(a.keys, apiKey{digest: sha256.Sum256([]byte(key)), principal: p})
+	}
+	return a
+}
+
+// Authenticate returns the principal of the request's API key
+func (a *APIKeys) Authenticate(r *http.Request) (principal.Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return principal.Principal{}, ErrNoCredentials
+	}
+
+	digest := sha256.Sum256([]byte(key))
+	var (
+		found principal.Principal
+		match int
+	)
+	// Every key is compared so that the time taken does not reveal which key matched
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(digest[:], k.digest[:]) == 1 {
+			found, match = k.principal, 1
+		}
+	}
+	if match == 0 {
+		return principal.Principal{}, ErrInvalidCredentials
+	}
+	return found, nil
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"CortexMCP/pkg/principal"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request carries no credentials it handles,
+	// so that the next authenticator can be tried
+	ErrNoCredentials = errors.New("no credentials")
+
+	// ErrInvalidCredentials is returned when the request carries credentials that are unknown, expired or
+	// incorrectly signed
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator resolves the principal making an HTTP request
+type Authenticator interface {
+	// Authenticate returns the principal identified by the request's credentials
+	Authenticate(r *http.Request) (principal.Principal, error)
+}
+
+// Chain tries each authenticator in turn until one recognizes the request's credentials
+type Chain []Authenticator
+
+// Authenticate returns the principal from the first authenticator that handles the request's credentials
+func (c Chain) Authenticate(r *http.Request) (principal.Principal, error) {
+	for _, authenticator := range c {
+		p, err := authenticator.Authenticate(r)
+		if !errors.Is(err, ErrNoCredentials) {
+			return p, err
+		}
+	}
+	return principal.Principal{}, ErrNoCredentials
+}
+
+// Middleware rejects requests that the authenticator cannot authenticate with 401 Unauthorized, and otherwise
+// passes the request on with the principal in its context
+func Middleware(authenticator Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := authenticator.Authenticate(r)
+		if err != nil {
+			unauthorized(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(principal.NewContext(r.Context(), p)))
+	})
+}
+
+// unauthorized writes a 401 response. The reason is deliberately coarse so that it does not help guess keys.
+func unauthorized(w http.ResponseWriter, err error) {
+	reason := "authentication required"
+	if !errors.Is(err, ErrNoCredentials) {
+		reason = "invalid credentials"
+	}
+	w.Header().Set("WWW-Authenticate", `Bearer realm="cortex-mcp"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": reason})
+}
+
+// bearerToken returns the token of an Authorization: Bearer header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"CortexMCP/pkg/principal"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func request(header, value string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	if header != "" {
+		r.Header.Set(header, value)
+	}
+	return r
+}
+
+func TestAPIKeys(t *testing.T) {
+	keys := NewAPIKeys(map[string]principal.Principal{
+		"clerk-key-0123456789":  principal.Staff(1),
+		"report-key-0123456789": principal.Client("reporting"),
+	})
+
+	p, err := keys.Authenticate(request(APIKeyHeader, "report-key-0123456789"))
+	if err != nil || p != principal.Client("reporting") {
+		t.Errorf("Expected client:reporting, got %v (%v)", p, err)
+	}
+	if _, err := keys.Authenticate(request(APIKeyHeader, "guessed-key")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected invalid credentials, got %v", err)
+	}
+	if _, err := keys.Authenticate(request("", "")); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Expected no credentials, got %v", err)
+	}
+}
+
+func TestHMACTokens(t *testing.T) {
+	tokens := NewHMACTokens([]byte("0123456789abcdef0123456789abcdef"))
+	token, err := tokens.Issue(principal.Staff(2), time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+
+	p, err := tokens.Authenticate(request("Authorization", "Bearer "+token))
+	if err != nil || p != principal.Staff(2) {
+		t.Errorf("Expected staff:2, got %v (%v)", p, err)
+	}
+
+	other := NewHMACTokens([]byte("another secret of at least 32 bytes"))
+	if _, err := other.Authenticate(request("Authorization", "Bearer "+token)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected a token signed with another secret to be rejected, got %v", err)
+	}
+
+	tokens.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := tokens.Authenticate(request("Authorization", "Bearer "+token)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected an expired token to be rejected, got %v", err)
+	}
+
+	// JWTs are left to the JWT authenticator
+	if _, err := tokens.Authenticate(request("Authorization", "Bearer a.b.c")); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Expected no credentials for a JWT, got %v", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	chain := Chain{
+		NewAPIKeys(map[string]principal.Principal{"clerk-key-0123456789": principal.Staff(1)}),
+		NewHMACTokens([]byte("0123456789abcdef0123456789abcdef")),
+	}
+	var got principal.Principal
+	handler := Middleware(chain, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = principal.FromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, request(APIKeyHeader, "clerk-key-0123456789"))
+	if rec.Code != http.StatusOK || got != principal.Staff(1) {
+		t.Errorf("Expected staff:1 to be authenticated, got %d %v", rec.Code, got)
+	}
+
+	for name, r := range map[string]*http.Request{
+		"missing": request("", ""),
+		"invalid": request("Authorization", "Bearer forged.token"),
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: expected 401 with a challenge, got %d", name, rec.Code)
+		}
+	}
+}
+
+func TestConfig_Authenticator(t *testing.T) {
+	if _, err := (&Config{}).Authenticator(); err == nil {
+		t.Error("Expected an error without any authentication method")
+	}
+
+	cfg := &Config{APIKeys: []APIKeyConfig{{Key: "clerk-key-0123456789", Principal: "staff:1"}}}
+	authenticator, err := cfg.Authenticator()
+	if err != nil {
+		t.Fatalf("Failed to create authenticator: %v", err)
+	}
+	if p, err := authenticator.Authenticate(request(APIKeyHeader, "clerk-key-0123456789")); err != nil || p != principal.Staff(1) {
+		t.Errorf("Expected staff:1, got %v (%v)", p, err)
+	}
+}
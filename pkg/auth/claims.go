@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Claims are the registered claims checked on signed tokens
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+}
+
+// Audience is the aud claim, which is either a single string or an array of strings
+type Audience []string
+
+// UnmarshalJSON accepts a string or an array of strings
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("aud must be a string or an array of strings")
+	}
+	*a = many
+	return nil
+}
+
+// validate checks that the token is within its validity period, names a subject, and was issued by issuer
+// for audience when those are set
+func (c *Claims) validate(now time.Time, issuer, audience string, leeway time.Duration) error {
+	switch {
+	case c.Subject == "":
+		return fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	case c.ExpiresAt == 0:
+		return fmt.Errorf("%w: token has no expiry", ErrInvalidCredentials)
+	case now.After(time.Unix(c.ExpiresAt, 0).Add(leeway)):
+		return fmt.Errorf("%w: token expired", ErrInvalidCredentials)
+	case c.NotBefore != 0 && now.Add(leeway).Before(time.Unix(c.NotBefore, 0)):
+		return fmt.Errorf("%w: token not valid yet", ErrInvalidCredentials)
+	case issuer != "" && c.Issuer != issuer:
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidCredentials, c.Issuer)
+	case audience != "" && !slices.Contains(c.Audience, audience):
+		return fmt.Errorf("%w: token is not for audience %q", ErrInvalidCredentials, audience)
+	}
+	return nil
+}
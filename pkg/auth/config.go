@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"CortexMCP/pkg/principal"
+	"fmt"
+	"time"
+)
+
+// Config configures the authenticators of the HTTP transport. Every configured method is accepted.
+type Config struct {
+	// APIKeys are static keys sent in the X-API-Key header
+	APIKeys []APIKeyConfig `yaml:"apiKeys" mapstructure:"apiKeys" validate:"dive"`
+
+	// HMACSecret signs the bearer tokens issued by the token command
+	HMACSecret string `yaml:"hmacSecret" mapstructure:"hmacSecret" validate:"omitempty,min=32"`
+
+	// JWT validates OAuth2 access tokens
+	JWT JWTConfig `yaml:"jwt" mapstructure:"jwt"`
+}
+
+// APIKeyConfig is a static API key and the principal it identifies, e.g. staff:2 or client:reporting
+type APIKeyConfig struct {
+	Key       string `yaml:"key" mapstructure:"key" validate:"required,min=16"`
+	Principal string `yaml:"principal" mapstructure:"principal" validate:"required"`
+}
+
+// JWTConfig configures validation of OAuth2 JWT access tokens
+type JWTConfig struct {
+	// JWKSFile is a JWKS document holding the authorization server's signing keys
+	JWKSFile string        `yaml:"jwksFile" mapstructure:"jwksFile"`
+	Issuer   string        `yaml:"issuer" mapstructure:"issuer"`
+	Audience string        `yaml:"audience" mapstructure:"audience"`
+	Leeway   time.Duration `yaml:"leeway" mapstructure:"leeway"`
+}
+
+// Authenticator creates an authenticator accepting every configured method
+func (c *Config) Authenticator() (Authenticator, error) {
+	var chain Chain
+	if len(c.APIKeys) > 0 {
+		keys := make(map[string]principal.Principal, len(c.APIKeys))
+		for _, key := range c.APIKeys {
+			keys[key.Key] = principal.Parse(key.Principal)
+		}
+		chain = append(chain, NewAPIKeys(keys))
+	}
+	if c.HMACSecret != "" {
+		chain = append(chain, NewHMACTokens([]byte(c.HMACSecret)))
+	}
+	if c.JWT.JWKSFile != "" {
+		keys, err := LoadJWKS(c.JWT.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, NewJWT(keys, c.JWT.Issuer, c.JWT.Audience, c.JWT.Leeway))
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no authentication method is configured")
+	}
+	return chain, nil
+}
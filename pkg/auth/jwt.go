@@ -0,0 +1,241 @@
+package auth
+
+import (
+	"CortexMCP/pkg/principal"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // registers SHA-256 for RS256 and ES256
+	_ "crypto/sha512" // registers SHA-384 and SHA-512
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// algorithms are the JWS algorithms accepted on JWTs. Symmetric algorithms and "none" are rejected, so a token
+// cannot be signed with the public key or left unsigned.
+var algorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// JWKS is a set of public keys indexed by key ID
+type JWKS map[string]crypto.PublicKey
+
+// jwk is a JSON Web Key as found in a JWKS document; only the RSA and EC members are read
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// LoadJWKS reads a JWKS document, such as a copy of an OAuth2 provider's jwks_uri, from path.
+// Keys that are not RSA or EC signing keys are skipped.
+func LoadJWKS(path string) (JWKS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	return ParseJWKS(data)
+}
+
+// ParseJWKS parses a JWKS document
+func ParseJWKS(data []byte) (JWKS, error) {
+	var document struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := JWKS{}
+	for _, key := range document.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		var (
+			public crypto.PublicKey
+			err    error
+		)
+		switch key.Kty {
+		case "RSA":
+			public, err = key.rsa()
+		case "EC":
+			public, err = key.ecdsa()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", key.Kid, err)
+		}
+		keys[key.Kid] = public
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS has no RSA or EC signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) rsa() (*rsa.PublicKey, error) {
+	n, err := decodeInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() < 3 {
+		return nil, fmt.Errorf("invalid RSA exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jwk) ecdsa() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := decodeInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// JWT authenticates OAuth2 access tokens that are JWTs signed by one of a set of keys.
+// The principal is the client named by the token's subject.
+type JWT struct {
+	Keys JWKS
+
+	// Issuer and Audience, when set, must match the iss and aud claims
+	Issuer   string
+	Audience string
+
+	// Leeway allows for clock skew when checking exp and nbf
+	Leeway time.Duration
+
+	now func() time.Time
+}
+
+// NewJWT creates an authenticator for JWTs signed by keys
+func NewJWT(keys JWKS, issuer, audience string, leeway time.Duration) *JWT {
+	return &JWT{Keys: keys, Issuer: issuer, Audience: audience, Leeway: leeway, now: time.Now}
+}
+
+// Authenticate verifies the request's bearer token and returns the client named by its subject
+func (j *JWT) Authenticate(r *http.Request) (principal.Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok || strings.Count(token, ".") != 2 {
+		return principal.Principal{}, ErrNoCredentials
+	}
+
+	parts := strings.Split(token, ".")
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return principal.Principal{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return principal.Principal{}, fmt.Errorf("%w: malformed token signature", ErrInvalidCredentials)
+	}
+	if err := j.verify(header.Alg, header.Kid, parts[0]+"."+parts[1], signature); err != nil {
+		return principal.Principal{}, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return principal.Principal{}, err
+	}
+	if err := claims.validate(j.now(), j.Issuer, j.Audience, j.Leeway); err != nil {
+		return principal.Principal{}, err
+	}
+	return principal.Client(claims.Subject), nil
+}
+
+// verify checks the signature of the signing input with the key named by kid, or the only key when the
+// token names none
+func (j *JWT) verify(alg, kid, input string, signature []byte) error {
+	hash, ok := algorithms[alg]
+	if !ok {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidCredentials, alg)
+	}
+	key, ok := j.Keys[kid]
+	if !ok && kid == "" && len(j.Keys) == 1 {
+		for _, only := range j.Keys {
+			key, ok = only, true
+		}
+	}
+	if !ok {
+		return fmt.Errorf("%w: unknown key %q", ErrInvalidCredentials, kid)
+	}
+
+	h := hash.New()
+	h.Write([]byte(input))
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if alg[:2] == "RS" && rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if alg[:2] == "ES" && len(signature) == 2*size {
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			if ecdsa.Verify(key, digest, r, s) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: bad token signature", ErrInvalidCredentials)
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil || json.Unmarshal(data, v) != nil {
+		return fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"CortexMCP/pkg/principal"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// signJWT creates a JWT signed with key, an *rsa.PrivateKey (RS256) or *ecdsa.PrivateKey (ES256)
+func signJWT(t *testing.T, key crypto.Signer, kid string, claims map[string]any) string {
+	t.Helper()
+	alg := "RS256"
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		alg = "ES256"
+	}
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:]); err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// writeJWKS writes the public halves of an RSA and an EC key as a JWKS file
+func writeJWKS(t *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) string {
+	t.Helper()
+	encode := func(i *big.Int) string { return base64.RawURLEncoding.EncodeToString(i.Bytes()) }
+	document, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": encode(ecKey.X), "y": encode(ecKey.Y)},
+		{"kty": "oct", "kid": "secret", "k": "c2VjcmV0"},
+	}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, document, 0o600); err != nil {
+		t.Fatalf("Failed to write JWKS: %v", err)
+	}
+	return path
+}
+
+func TestJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+	keys, err := LoadJWKS(writeJWKS(t, rsaKey, ecKey))
+	if err != nil {
+		t.Fatalf("Failed to load JWKS: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("Expected the symmetric key to be skipped, got %d keys", len(keys))
+	}
+
+	validator := NewJWT(keys, "https://auth.example.com/", "cortex-mcp", time.Minute)
+	valid := func() map[string]any {
+		return map[string]any{
+			"sub": "dashboard",
+			"iss": "https://auth.example.com/",
+			"aud": []string{"cortex-mcp", "other-api"},
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	for kid, key := range map[string]crypto.Signer{"rsa-1": rsaKey, "ec-1": ecKey} {
+		token := signJWT(t, key, kid, valid())
+		p, err := validator.Authenticate(request("Authorization", "Bearer "+token))
+		if err != nil || p != principal.Client("dashboard") {
+			t.Errorf("%s: expected client:dashboard, got %v (%v)", kid, p, err)
+		}
+	}
+
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	expired, wrongAudience := valid(), valid()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongAudience["aud"] = "other-api"
+
+	rejected := map[string]string{
+		"expired":        signJWT(t, rsaKey, "rsa-1", expired),
+		"wrong audience": signJWT(t, rsaKey, "rsa-1", wrongAudience),
+		"unknown key":    signJWT(t, ecKey, "ec-2", valid()),
+		"forged":         signJWT(t, otherKey, "ec-1", valid()),
+		"key mismatch":   signJWT(t, ecKey, "rsa-1", valid()),
+		"unsigned":       base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + ".e30.",
+	}
+	for name, token := range rejected {
+		if _, err := validator.Authenticate(request("Authorization", "Bearer "+token)); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: expected invalid credentials, got %v", name, err)
+		}
+	}
+}
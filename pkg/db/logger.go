@@ -0,0 +1,265 @@
+package db
+
+import (
+	"CortexMCP/pkg/correlation"
+	"CortexMCP/pkg/principal"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// redacted replaces the values of sensitive parameters in logged statements
+const redacted = "[REDACTED]"
+
+// DefaultRedactedColumns are the columns whose values are never logged unless configured otherwise
+var DefaultRedactedColumns = []string{"password", "email", "phone", "address", "address2", "postal_code"}
+
+// LogConfig configures the logging of SQL statements
+type LogConfig struct {
+	// Level is silent, error (failed statements), warn (failed and slow statements) or info (every statement).
+	// Defaults to warn.
+	Level string `yaml:"level" mapstructure:"level" validate:"omitempty,oneof=silent error warn info"`
+
+	// SlowThreshold is how long a statement may take before it is logged and kept as a slow query; 0 disables
+	// slow-query detection
+	SlowThreshold time.Duration `yaml:"slowThreshold" mapstructure:"slowThreshold" validate:"min=0"`
+
+	// SlowQueries is the number of recent slow queries kept for diagnostics. Defaults to 100.
+	SlowQueries int `yaml:"slowQueries" mapstructure:"slowQueries" validate:"min=0"`
+
+	// RedactColumns are the columns whose values are replaced in logged statements. Defaults to
+	// DefaultRedactedColumns.
+	RedactColumns []string `yaml:"redactColumns" mapstructure:"redactColumns"`
+}
+
+// levels maps configured log levels to GORM log levels
+var levels = map[string]logger.LogLevel{
+	"silent": logger.Silent,
+	"error":  logger.Error,
+	"warn":   logger.Warn,
+	"info":   logger.Info,
+}
+
+// Logger is a GORM logger writing structured records to a slog.Logger. Records carry the correlation ID and
+// principal of the request that ran the statement, and statements are logged with the values of sensitive
+// columns redacted.
+type Logger struct {
+	logger        *slog.Logger
+	level         logger.LogLevel
+	slowThreshold time.Duration
+	redact        map[string]bool
+	slow          *SlowQueries
+}
+
+// NewLogger creates a GORM logger writing to l as configured
+func NewLogger(l *slog.Logger, cfg LogConfig) *Logger {
+	level, ok := levels[cfg.Level]
+	if !ok {
+		level = logger.Warn
+	}
+	columns := cfg.RedactColumns
+	if columns == nil {
+		columns = DefaultRedactedColumns
+	}
+	redact := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		redact[strings.ToLower(column)] = true
+	}
+	size := cfg.SlowQueries
+	if size == 0 {
+		size = 100
+	}
+	return &Logger{logger: l, level: level, slowThreshold: cfg.SlowThreshold, redact: redact, slow: NewSlowQueries(size)}
+}
+
+// SlowQueries returns the buffer of recent slow queries
+func (l *Logger) SlowQueries() *SlowQueries {
+	return l.slow
+}
+
+// LogMode returns a copy of the logger logging at level, sharing its slow queries
+func (l *Logger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+// Info logs a message from GORM at info level
+func (l *Logger) Info(ctx context.Context, msg string, data ...any) {
+	if l.level >= logger.Info {
+		l.logger.InfoContext(ctx, fmt.Sprintf(msg, data...), requestAttributes(ctx)...)
+	}
+}
+
+// Warn logs a message from GORM at warn level
+func (l *Logger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.level >= logger.Warn {
+		l.logger.WarnContext(ctx, fmt.Sprintf(msg, data...), requestAttributes(ctx)...)
+	}
+}
+
+// Error logs a message from GORM at error level
+func (l *Logger) Error(ctx context.Context, msg string, data ...any) {
+	if l.level >= logger.Error {
+		l.logger.ErrorContext(ctx, fmt.Sprintf(msg, data...), requestAttributes(ctx)...)
+	}
+}
+
+// Trace logs a statement after it ran: failed statements at error level, slow ones at warn level and every
+// other one at info level. Slow statements are kept in the slow-query buffer whatever the level.
+func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	slow := l.slowThreshold > 0 && elapsed > l.slowThreshold
+	if !slow && (l.level <= logger.Silent || (failed && l.level < logger.Error) || (!failed && l.level < logger.Info)) {
+		return
+	}
+
+	sql, rows := fc()
+	attributes := append(requestAttributes(ctx),
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Duration("duration", elapsed),
+	)
+	if slow {
+		query := SlowQuery{Time: begin, DurationMs: float64(elapsed.Microseconds()) / 1000, SQL: sql, Rows: rows}
+		query.CorrelationID, _ = correlation.FromContext(ctx)
+		if p, ok := principal.FromContext(ctx); ok {
+			query.Principal = p.String()
+		}
+		if failed {
+			query.Error = err.Error()
+		}
+		l.slow.Add(query)
+	}
+
+	switch {
+	case failed && l.level >= logger.Error:
+		l.logger.ErrorContext(ctx, "query failed", append(attributes, slog.String("error", err.Error()))...)
+	case slow && l.level >= logger.Warn:
+		l.logger.WarnContext(ctx, "slow query", append(attributes, slog.Duration("threshold", l.slowThreshold))...)
+	case !failed && l.level >= logger.Info:
+		l.logger.InfoContext(ctx, "query", attributes...)
+	}
+}
+
+// ParamsFilter replaces the parameters compared with or inserted into sensitive columns before a statement
+// is logged
+func (l *Logger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	if len(l.redact) == 0 || len(params) == 0 {
+		return sql, params
+	}
+	filtered := make([]any, len(params))
+	copy(filtered, params)
+	for i, column := range paramColumns(sql, len(params)) {
+		if l.redact[strings.ToLower(column)] {
+			filtered[i] = redacted
+		}
+	}
+	return sql, filtered
+}
+
+// requestAttributes returns the log attributes identifying the request that runs a statement
+func requestAttributes(ctx context.Context) []any {
+	var attributes []any
+	if id, ok := correlation.FromContext(ctx); ok {
+		attributes = append(attributes, slog.String("correlation_id", id))
+	}
+	if p, ok := principal.FromContext(ctx); ok {
+		attributes = append(attributes, slog.String("principal", p.String()))
+	}
+	return attributes
+}
+
+var (
+	// placeholder matches the parameter placeholders of MySQL (?), Postgres ($1) and SQL Server (@p1)
+	placeholder = regexp.MustCompile(`\?|\$\d+|@p\d+`)
+
+	// comparedColumn matches the column a parameter is compared with or assigned to, at the end of the SQL
+	// preceding the parameter
+	comparedColumn = regexp.MustCompile(`(?i)(\w+)["` + "`" + `\]]?\s*(?:=|<>|!=|<=|>=|<|>|\bNOT\s+LIKE|\bI?LIKE)\s*$`)
+
+	// listedColumn matches the column of an IN list opened just before the parameter
+	listedColumn = regexp.MustCompile(`(?i)(\w+)["` + "`" + `\]]?\s+(?:NOT\s+)?IN\s*\(\s*$`)
+
+	// listSeparator matches the text between two parameters of the same list
+	listSeparator = regexp.MustCompile(`^\s*,\s*$`)
+
+	// insertColumns matches the column list of an INSERT statement
+	insertColumns = regexp.MustCompile(`(?is)^\s*INSERT\s+INTO\s+\S+\s*\(([^)]*)\)\s*(?:OUTPUT\s+[^)]*?\s+)?VALUES\s*`)
+
+	// mergeColumns matches the rows and the column list of the source of a SQL Server MERGE upsert, e.g.
+	// USING (VALUES (@p1,@p2)) AS excluded ("email","active")
+	mergeColumns = regexp.MustCompile(`(?is)^\s*MERGE\s+INTO\s+.*?\bUSING\s*\(\s*VALUES\s*(.*?)\)\s*AS\s+\w+\s*\(([^)]*)\)`)
+)
+
+// paramColumns returns the column each of the n parameters of sql is compared with or inserted into, or an
+// empty string where the column cannot be told. Only the text between consecutive placeholders is looked
+// at, so that bulk inserts with thousands of parameters are handled in linear time.
+func paramColumns(sql string, n int) []string {
+	columns := make([]string, n)
+	var inserted []string
+	valuesAt, valuesEnd := -1, len(sql)
+	if match := insertColumns.FindStringSubmatchIndex(sql); match != nil {
+		inserted, valuesAt = columnList(sql[match[2]:match[3]]), match[1]
+	} else if match := mergeColumns.FindStringSubmatchIndex(sql); match != nil {
+		inserted, valuesAt, valuesEnd = columnList(sql[match[4]:match[5]]), match[2], match[3]
+	}
+
+	quotes, end, ordinal, values := 0, 0, 0, 0
+	list := ""
+	for _, loc := range placeholder.FindAllStringIndex(sql, -1) {
+		gap := sql[end:loc[0]]
+		end = loc[1]
+		if quotes += strings.Count(gap, "'"); quotes%2 == 1 {
+			// Inside a string literal
+			continue
+		}
+
+		index := ordinal
+		ordinal++
+		if token := sql[loc[0]:loc[1]]; token != "?" {
+			number, err := strconv.Atoi(strings.TrimLeft(token, "$@p"))
+			if err != nil {
+				continue
+			}
+			index = number - 1
+		}
+		if index < 0 || index >= n {
+			continue
+		}
+
+		column := ""
+		if match := comparedColumn.FindStringSubmatch(gap); match != nil {
+			column, list = match[1], ""
+		} else if match := listedColumn.FindStringSubmatch(gap); match != nil {
+			column, list = match[1], match[1]
+		} else if list != "" && listSeparator.MatchString(gap) {
+			column = list
+		} else if valuesAt >= 0 && loc[0] >= valuesAt && loc[0] < valuesEnd && len(inserted) > 0 {
+			column, list = inserted[values%len(inserted)], ""
+			values++
+		} else {
+			list = ""
+		}
+		columns[index] = column
+	}
+	return columns
+}
+
+// columnList returns the unquoted names of a comma-separated list of columns
+func columnList(list string) []string {
+	var columns []string
+	for _, column := range strings.Split(list, ",") {
+		columns = append(columns, strings.Trim(strings.TrimSpace(column), "\"`[]"))
+	}
+	return columns
+}
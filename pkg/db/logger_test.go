@@ -0,0 +1,156 @@
+package db
+
+import (
+	"CortexMCP/db/entity"
+	"CortexMCP/pkg/correlation"
+	"CortexMCP/pkg/principal"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupLoggerTest(t *testing.T, cfg LogConfig) (sqlmock.Sqlmock, *gorm.DB, *Logger, *bytes.Buffer) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	var out bytes.Buffer
+	sqlLogger := NewLogger(slog.New(slog.NewJSONHandler(&out, nil)), cfg)
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{Logger: sqlLogger})
+	if err != nil {
+		t.Fatalf("Failed to open gorm database: %v", err)
+	}
+	return mock, gormDB, sqlLogger, &out
+}
+
+// records decodes the JSON log records written to out
+func records(t *testing.T, out *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var result []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Failed to decode log record %q: %v", line, err)
+		}
+		result = append(result, record)
+	}
+	return result
+}
+
+func TestLogger_Levels(t *testing.T) {
+	mock, db, _, out := setupLoggerTest(t, LogConfig{Level: "info"})
+	ctx := correlation.NewContext(principal.NewContext(context.Background(), principal.Staff(2)), "abc123")
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer` WHERE email = ? AND `customer`.`deleted_at` IS NULL")).
+		WithArgs("mary@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"customer_id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `film`")).
+		WillReturnError(errors.New("connection reset"))
+
+	var customers []entity.Customer
+	if err := db.WithContext(ctx).Where("email = ?", "mary@example.com").Find(&customers).Error; err != nil {
+		t.Fatalf("Error finding customers: %v", err)
+	}
+	var films []entity.Film
+	_ = db.WithContext(ctx).Find(&films).Error
+
+	logged := records(t, out)
+	if len(logged) != 2 {
+		t.Fatalf("Expected 2 log records, got %d: %s", len(logged), out)
+	}
+	query, failed := logged[0], logged[1]
+	if query["level"] != "INFO" || query["correlation_id"] != "abc123" || query["principal"] != "staff:2" || query["rows"] != float64(1) {
+		t.Errorf("Unexpected query record: %v", query)
+	}
+	if sql := query["sql"].(string); strings.Contains(sql, "mary") || !strings.Contains(sql, redacted) {
+		t.Errorf("Expected the email to be redacted, got %q", sql)
+	}
+	if failed["level"] != "ERROR" || failed["error"] != "connection reset" {
+		t.Errorf("Unexpected failed query record: %v", failed)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestLogger_SlowQueries(t *testing.T) {
+	mock, db, sqlLogger, out := setupLoggerTest(t, LogConfig{Level: "error", SlowThreshold: 10 * time.Millisecond, SlowQueries: 2})
+	ctx := correlation.NewContext(context.Background(), "abc123")
+
+	for _, id := range []int{1, 2, 3} {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `film` WHERE film_id = ?")).
+			WithArgs(id).
+			WillDelayFor(20 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"film_id"}))
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `film` WHERE film_id = ?")).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"film_id"}))
+
+	var films []entity.Film
+	for _, id := range []int{1, 2, 3, 4} {
+		if err := db.WithContext(ctx).Where("film_id = ?", id).Find(&films).Error; err != nil {
+			t.Fatalf("Error finding films: %v", err)
+		}
+	}
+
+	// Slow queries are kept whatever the level, and only the most recent ones
+	slow := sqlLogger.SlowQueries().List()
+	if len(slow) != 2 || !strings.Contains(slow[0].SQL, "film_id = 3") || !strings.Contains(slow[1].SQL, "film_id = 2") {
+		t.Fatalf("Expected the two most recent slow queries, got %+v", slow)
+	}
+	if slow[0].CorrelationID != "abc123" || slow[0].DurationMs < 10 {
+		t.Errorf("Unexpected slow query: %+v", slow[0])
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing to be logged at error level, got %s", out)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestParamColumns(t *testing.T) {
+	tests := []struct {
+		sql  string
+		n    int
+		want []string
+	}{
+		{"SELECT * FROM `customer` WHERE `customer`.`email` = ? AND store_id IN (?,?) AND last_name LIKE ?", 4,
+			[]string{"email", "store_id", "store_id", "last_name"}},
+		{`UPDATE "staff" SET "password"=$2,"updated_at"=$3 WHERE staff_id = $1`, 3,
+			[]string{"staff_id", "password", "updated_at"}},
+		{"INSERT INTO `customer` (`first_name`,`email`) VALUES (?,?),(?,?)", 4,
+			[]string{"first_name", "email", "first_name", "email"}},
+		{"INSERT INTO [customer] ([email],[active]) OUTPUT INSERTED.[customer_id] VALUES (@p1,@p2)", 2,
+			[]string{"email", "active"}},
+		{`MERGE INTO "customer" WITH (HOLDLOCK) USING (VALUES (@p1,@p2),(@p3,@p4)) AS excluded ("email","active") ON "customer"."email" = "excluded"."email" ` +
+			`WHEN MATCHED THEN UPDATE SET "active"="excluded"."active" WHEN NOT MATCHED THEN INSERT ("email","active") VALUES ("excluded"."email","excluded"."active") OUTPUT INSERTED."customer_id";`, 4,
+			[]string{"email", "active", "email", "active"}},
+		{"SELECT * FROM film WHERE title = 'What?' AND film_id = ?", 1, []string{"film_id"}},
+		{"SELECT count(*) FROM film WHERE length > ? + ?", 2, []string{"length", ""}},
+	}
+	for _, tt := range tests {
+		if got := paramColumns(tt.sql, tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("paramColumns(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
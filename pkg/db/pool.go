@@ -3,13 +3,13 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlserver"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 type DatabaseType string
@@ -30,6 +30,7 @@ type ConnectionConfig struct {
 	Timeout      time.Duration `yaml:"timeout" mapstructure:"timeout" validate:"min=3s"`
 	MaxIdleConns int           `yaml:"maxIdleConns" mapstructure:"maxIdleConns" validate:"min=1"`
 	MaxOpenConns int           `yaml:"maxOpenConns" mapstructure:"maxOpenConns" validate:"min=2"`
+	Log          LogConfig     `yaml:"log" mapstructure:"log"`
 }
 
 func (c *ConnectionConfig) Dsn() string {
@@ -66,7 +67,7 @@ func (c *ConnectionConfig) Pool() (*gorm.DB, error) {
 	}
 
 	db, err := gorm.Open(dialector, &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
+		Logger: NewLogger(slog.Default(), c.Log),
 	})
 	if err != nil {
 		return nil, err
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"sync"
+	"time"
+)
+
+// SlowQuery is a statement that took longer than the slow-query threshold
+type SlowQuery struct {
+	Time          time.Time `json:"time"`
+	DurationMs    float64   `json:"durationMs"`
+	SQL           string    `json:"sql"`
+	Rows          int64     `json:"rows"`
+	CorrelationID string    `json:"correlationId,omitempty"`
+	Principal     string    `json:"principal,omitempty"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// SlowQueries keeps the most recent slow queries in a ring buffer
+type SlowQueries struct {
+	mu      sync.Mutex
+	entries []SlowQuery
+	next    int
+	full    bool
+}
+
+// NewSlowQueries creates a buffer keeping the last size slow queries
+func NewSlowQueries(size int) *SlowQueries {
+	if size < 1 {
+		size = 1
+	}
+	return &SlowQueries{entries: make([]SlowQuery, size)}
+}
+
+// Add records a slow query, replacing the oldest one when the buffer is full
+func (s *SlowQueries) Add(query SlowQuery) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[s.next] = query
+	s.next = (s.next + 1) % len(s.entries)
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+// List returns the recorded slow queries, most recent first
+func (s *SlowQueries) List() []SlowQuery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := s.next
+	if s.full {
+		count = len(s.entries)
+	}
+	queries := make([]SlowQuery, count)
+	for i := range queries {
+		queries[i] = s.entries[(s.next-1-i+len(s.entries))%len(s.entries)]
+	}
+	return queries
+}
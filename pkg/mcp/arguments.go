@@ -0,0 +1,108 @@
+package mcp
+
+import (
+	"math"
+)
+
+// Arguments are the decoded arguments of a tools/call request
+type Arguments map[string]any
+
+// String returns a required string argument
+func (a Arguments) String(name string) (string, error) {
+	value, ok := a[name]
+	if !ok {
+		return "", InvalidParamsError("missing required argument %q", name)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", InvalidParamsError("argument %q must be a string", name)
+	}
+	return s, nil
+}
+
+// OptionalString returns a string argument, or def when it is absent
+func (a Arguments) OptionalString(name, def string) (string, error) {
+	if _, ok := a[name]; !ok {
+		return def, nil
+	}
+	return a.String(name)
+}
+
+// Uint returns a required non-negative integer argument
+func (a Arguments) Uint(name string) (uint, error) {
+	value, ok := a[name]
+	if !ok {
+		return 0, InvalidParamsError("missing required argument %q", name)
+	}
+	n, ok := value.(float64)
+	if !ok || n < 0 || n != math.Trunc(n) {
+		return 0, InvalidParamsError("argument %q must be a non-negative integer", name)
+	}
+	return uint(n), nil
+}
+
+// OptionalInt returns an integer argument, or def when it is absent
+func (a Arguments) OptionalInt(name string, def int) (int, error) {
+	value, ok := a[name]
+	if !ok {
+		return def, nil
+	}
+	n, ok := value.(float64)
+	if !ok || n != math.Trunc(n) {
+		return 0, InvalidParamsError("argument %q must be an integer", name)
+	}
+	return int(n), nil
+}
+
+// Number returns a required numeric argument
+func (a Arguments) Number(name string) (float64, error) {
+	value, ok := a[name]
+	if !ok {
+		return 0, InvalidParamsError("missing required argument %q", name)
+	}
+	n, ok := value.(float64)
+	if !ok {
+		return 0, InvalidParamsError("argument %q must be a number", name)
+	}
+	return n, nil
+}
+
+// OptionalStrings returns a string array argument, or nil when it is absent
+func (a Arguments) OptionalStrings(name string) ([]string, error) {
+	value, ok := a[name]
+	if !ok {
+		return nil, nil
+	}
+	items, ok := value.([]any)
+	if !ok {
+		return nil, InvalidParamsError("argument %q must be an array of strings", name)
+	}
+	values := make([]string, len(items))
+	for i, item := range items {
+		if values[i], ok = item.(string); !ok {
+			return nil, InvalidParamsError("argument %q must be an array of strings", name)
+		}
+	}
+	return values, nil
+}
+
+// Uints returns a required array of non-negative integers
+func (a Arguments) Uints(name string) ([]uint, error) {
+	value, ok := a[name]
+	if !ok {
+		return nil, InvalidParamsError("missing required argument %q", name)
+	}
+	items, ok := value.([]any)
+	if !ok {
+		return nil, InvalidParamsError("argument %q must be an array of non-negative integers", name)
+	}
+	values := make([]uint, len(items))
+	for i, item := range items {
+		n, ok := item.(float64)
+		if !ok || n < 0 || n != math.Trunc(n) {
+			return nil, InvalidParamsError("argument %q must be an array of non-negative integers", name)
+		}
+		values[i] = uint(n)
+	}
+	return values, nil
+}
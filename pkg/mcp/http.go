@@ -0,0 +1,34 @@
+package mcp
+
+import (
+	"io"
+	"net/http"
+)
+
+// maxHTTPMessage limits the size of a JSON-RPC message posted to the HTTP transport
+const maxHTTPMessage = 10 * 1024 * 1024
+
+// ServeHTTP serves the streamable HTTP transport without server-sent events: each POST carries one JSON-RPC
+// message and its response is returned as the JSON response body. Notifications are acknowledged with
+// 202 Accepted. The request context, including anything set by HTTP middleware, is passed to handlers.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	message, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHTTPMessage))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	resp := s.Handle(r.Context(), message)
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resp)
+}
@@ -0,0 +1,358 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+)
+
+// ToolHandler handles a tools/call request. The returned value is serialized as JSON text content.
+// Returning an *Error sends that JSON-RPC error to the client; any other error is reported as an internal
+// error without its message, so that database details do not leak to clients.
+type ToolHandler func(ctx context.Context, args Arguments) (any, error)
+
+// ToolMiddleware wraps the handler of the named tool
+type ToolMiddleware func(name string, next ToolHandler) ToolHandler
+
+// RequestHandler handles a JSON-RPC request or notification and returns its result
+type RequestHandler func(ctx context.Context, req *Request) (any, error)
+
+// RequestMiddleware wraps the handling of every request and notification, e.g. to trace it
+type RequestMiddleware func(next RequestHandler) RequestHandler
+
+// ResourceHandler returns the text of a resource when it is read
+type ResourceHandler func(ctx context.Context) (string, error)
+
+// ResourceMiddleware wraps the handler of a resource being read. Resources matching a template are given
+// with the URI read and the template's name and MIME type.
+type ResourceMiddleware func(resource Resource, next ResourceHandler) ResourceHandler
+
+type resourceEntry struct {
+	resource Resource
+	handler  ResourceHandler
+}
+
+type toolEntry struct {
+	tool    Tool
+	handler ToolHandler
+}
+
+type clientKey struct{}
+
+// ClientFromContext returns the client that sent the request being handled, as reported when it initialized.
+// The name is empty when the client has not initialized.
+func ClientFromContext(ctx context.Context) Implementation {
+	client, _ := ctx.Value(clientKey{}).(Implementation)
+	return client
+}
+
+// Server is an MCP server exposing tools and resources over JSON-RPC
+type Server struct {
+	info Implementation
+
+	mu                 sync.RWMutex
+	client             Implementation
+	tools              map[string]toolEntry
+	resources          map[string]resourceEntry
+	templates          map[string]templateEntry
+	middleware         []ToolMiddleware
+	resourceMiddleware []ResourceMiddleware
+	requests           []RequestMiddleware
+	subscriptions      map[string]bool
+	notify             func(message []byte) error
+}
+
+// NewServer creates a new Server
+func NewServer(name, version string) *Server {
+	return &Server{
+		info:          Implementation{Name: name, Version: version},
+		tools:         make(map[string]toolEntry),
+		resources:     make(map[string]resourceEntry),
+		templates:     make(map[string]templateEntry),
+		subscriptions: make(map[string]bool),
+	}
+}
+
+// AddTool registers a tool, replacing any tool with the same name
+func (s *Server) AddTool(tool Tool, handler ToolHandler) {
+	if tool.InputSchema.Type == "" {
+		tool.InputSchema.Type = "object"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tools[tool.Name] = toolEntry{tool: tool, handler: handler}
+}
+
+// AddResource registers a resource, replacing any resource with the same URI. Resources may be added
+// while the server is running, e.g. for files produced by a tool.
+func (s *Server) AddResource(resource Resource, handler ResourceHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resources[resource.URI] = resourceEntry{resource: resource, handler: handler}
+}
+
+// RemoveResource unregisters the resource with the given URI, if any
+func (s *Server) RemoveResource(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.resources, uri)
+}
+
+// UseRequest appends middleware wrapping the handling of every request. The first middleware added is
+// the outermost.
+func (s *Server) UseRequest(middleware ...RequestMiddleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, middleware...)
+}
+
+// Tool returns the registered tool with the given name
+func (s *Server) Tool(name string) (Tool, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.tools[name]
+	return entry.tool, ok
+}
+
+// Resources returns the registered resources sorted by URI
+func (s *Server) Resources() []Resource {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resources := make([]Resource, 0, len(s.resources))
+	for _, entry := range s.resources {
+		resources = append(resources, entry.resource)
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].URI < resources[j].URI
+	})
+	return resources
+}
+
+// Use appends middleware wrapping every tool handler. The first middleware added is the outermost.
+func (s *Server) Use(middleware ...ToolMiddleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.middleware = append(s.middleware, middleware...)
+}
+
+// UseResource appends middleware wrapping the handler of every resource read, including resources matching
+// a template. The first middleware added is the outermost.
+func (s *Server) UseResource(middleware ...ResourceMiddleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resourceMiddleware = append(s.resourceMiddleware, middleware...)
+}
+
+// Tools returns the registered tools sorted by name
+func (s *Server) Tools() []Tool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tools := make([]Tool, 0, len(s.tools))
+	for _, entry := range s.tools {
+		tools = append(tools, entry.tool)
+	}
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Name < tools[j].Name
+	})
+	return tools
+}
+
+// Handle processes a single JSON-RPC message and returns the encoded response, or nil for notifications
+func (s *Server) Handle(ctx context.Context, message []byte) []byte {
+	var req Request
+	if err := json.Unmarshal(message, &req); err != nil {
+		return encode(Response{JSONRPC: jsonrpcVersion, ID: json.RawMessage("null"), Error: NewError(ParseError, "parse error")})
+	}
+	if req.JSONRPC != jsonrpcVersion || req.Method == "" {
+		return encode(Response{JSONRPC: jsonrpcVersion, ID: nullID(req.ID), Error: NewError(InvalidRequest, "invalid request")})
+	}
+
+	s.mu.RLock()
+	client := s.client
+	middleware := s.requests
+	s.mu.RUnlock()
+	handler := s.dispatch
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	result, err := handler(context.WithValue(ctx, clientKey{}, client), &req)
+	if req.IsNotification() {
+		return nil
+	}
+
+	resp := Response{JSONRPC: jsonrpcVersion, ID: req.ID}
+	if err != nil {
+		resp.Error = toError(err)
+	} else {
+		resp.Result = result
+	}
+	return encode(resp)
+}
+
+// ServeStdio serves newline-delimited JSON-RPC messages from in, writing responses and notifications to out,
+// until in is exhausted or ctx is cancelled. Resource subscriptions last until it returns.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+
+	var writeMu sync.Mutex
+	write := func(message []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_, err := out.Write(append(message, '\n'))
+		return err
+	}
+	s.mu.Lock()
+	s.notify = write
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.notify = nil
+		s.subscriptions = make(map[string]bool)
+		s.mu.Unlock()
+	}()
+
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if resp := s.Handle(ctx, line); resp != nil {
+			if err := write(resp); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *Server) dispatch(ctx context.Context, req *Request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				return nil, NewError(InvalidParams, "invalid initialize params")
+			}
+		}
+		s.mu.Lock()
+		s.client = params.ClientInfo
+		s.mu.Unlock()
+		return initializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities:    map[string]any{"tools": map[string]any{}, "resources": map[string]any{}},
+			ServerInfo:      s.info,
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	case "tools/list":
+		return listToolsResult{Tools: s.Tools()}, nil
+	case "tools/call":
+		return s.callTool(ctx, req.Params)
+	case "resources/list":
+		return listResourcesResult{Resources: s.Resources()}, nil
+	case "resources/read":
+		return s.readResource(ctx, req.Params)
+	case "resources/templates/list":
+		return listResourceTemplatesResult{ResourceTemplates: s.ResourceTemplates()}, nil
+	case "resources/subscribe":
+		return s.subscribe(req.Params, true)
+	case "resources/unsubscribe":
+		return s.subscribe(req.Params, false)
+	default:
+		return nil, NewError(MethodNotFound, "method not found: "+req.Method)
+	}
+}
+
+func (s *Server) callTool(ctx context.Context, raw json.RawMessage) (any, error) {
+	var params callToolParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, NewError(InvalidParams, "invalid tools/call params")
+	}
+
+	s.mu.RLock()
+	entry, ok := s.tools[params.Name]
+	middleware := s.middleware
+	s.mu.RUnlock()
+	if !ok {
+		return nil, InvalidParamsError("unknown tool %q", params.Name)
+	}
+	if params.Arguments == nil {
+		params.Arguments = Arguments{}
+	}
+
+	handler := entry.handler
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](params.Name, handler)
+	}
+
+	value, err := handler(ctx, params.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	text, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return CallToolResult{Content: []Content{{Type: "text", Text: string(text)}}}, nil
+}
+
+func (s *Server) readResource(ctx context.Context, raw json.RawMessage) (any, error) {
+	var params readResourceParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, NewError(InvalidParams, "invalid resources/read params")
+	}
+
+	s.mu.RLock()
+	resource, handler, ok := s.lookupResource(params.URI)
+	middleware := s.resourceMiddleware
+	s.mu.RUnlock()
+	if !ok {
+		return nil, NewError(ResourceNotFound, "resource not found: "+params.URI)
+	}
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](resource, handler)
+	}
+
+	text, err := handler(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return readResourceResult{Contents: []ResourceContents{{URI: params.URI, MimeType: resource.MimeType, Text: text}}}, nil
+}
+
+func toError(err error) *Error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+	return NewError(InternalError, "internal error")
+}
+
+func nullID(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+func encode(resp Response) []byte {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(Response{JSONRPC: jsonrpcVersion, ID: resp.ID, Error: NewError(InternalError, "failed to encode response")})
+	}
+	return data
+}
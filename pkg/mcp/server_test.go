@@ -0,0 +1,325 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestServer() *Server {
+	s := NewServer("test", "1.0.0")
+	s.AddTool(Tool{
+		Name:        "echo",
+		Description: "Echoes the message argument",
+		InputSchema: Schema{
+			Properties: map[string]Property{"message": {Type: "string"}},
+			Required:   []string{"message"},
+		},
+	}, func(ctx context.Context, args Arguments) (any, error) {
+		message, err := args.String("message")
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"message": message}, nil
+	})
+	s.AddTool(Tool{Name: "fail"}, func(ctx context.Context, args Arguments) (any, error) {
+		return nil, errors.New("boom")
+	})
+	return s
+}
+
+func decodeResponse(t *testing.T, data []byte) Response {
+	t.Helper()
+	var resp Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("Failed to decode response %s: %v", data, err)
+	}
+	return resp
+}
+
+func TestServer_Initialize(t *testing.T) {
+	s := newTestServer()
+
+	resp := decodeResponse(t, s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)))
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+
+	result := resp.Result.(map[string]any)
+	if result["protocolVersion"] != ProtocolVersion {
+		t.Errorf("Expected protocol version %s, got %v", ProtocolVersion, result["protocolVersion"])
+	}
+	if info := result["serverInfo"].(map[string]any); info["name"] != "test" {
+		t.Errorf("Expected server name test, got %v", info["name"])
+	}
+}
+
+func TestServer_ListTools(t *testing.T) {
+	s := newTestServer()
+
+	resp := decodeResponse(t, s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)))
+	tools := resp.Result.(map[string]any)["tools"].([]any)
+	if len(tools) != 2 {
+		t.Fatalf("Expected 2 tools, got %d", len(tools))
+	}
+	if name := tools[0].(map[string]any)["name"]; name != "echo" {
+		t.Errorf("Expected tools sorted by name, got %v first", name)
+	}
+}
+
+func TestServer_CallTool(t *testing.T) {
+	s := newTestServer()
+
+	resp := decodeResponse(t, s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":"a","method":"tools/call","params":{"name":"echo","arguments":{"message":"hi"}}}`)))
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if string(resp.ID) != `"a"` {
+		t.Errorf("Expected ID \"a\", got %s", resp.ID)
+	}
+
+	content := resp.Result.(map[string]any)["content"].([]any)[0].(map[string]any)
+	if content["text"] != `{"message":"hi"}` {
+		t.Errorf("Unexpected content: %v", content["text"])
+	}
+}
+
+func TestServer_CallToolErrors(t *testing.T) {
+	s := newTestServer()
+
+	tests := []struct {
+		name    string
+		message string
+		code    int
+	}{
+		{name: "missing argument", message: `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{}}}`, code: InvalidParams},
+		{name: "unknown tool", message: `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"nope"}}`, code: InvalidParams},
+		{name: "handler error", message: `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"fail"}}`, code: InternalError},
+		{name: "unknown method", message: `{"jsonrpc":"2.0","id":1,"method":"nope"}`, code: MethodNotFound},
+		{name: "malformed json", message: `{"jsonrpc":`, code: ParseError},
+		{name: "missing version", message: `{"id":1,"method":"ping"}`, code: InvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := decodeResponse(t, s.Handle(context.Background(), []byte(tt.message)))
+			if resp.Error == nil || resp.Error.Code != tt.code {
+				t.Errorf("Expected error code %d, got %+v", tt.code, resp.Error)
+			}
+		})
+	}
+}
+
+func TestServer_Notification(t *testing.T) {
+	s := newTestServer()
+
+	if resp := s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)); resp != nil {
+		t.Errorf("Expected no response to a notification, got %s", resp)
+	}
+}
+
+func TestServer_ServeStdio(t *testing.T) {
+	s := newTestServer()
+
+	in := strings.NewReader("{\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"ping\"}\n\n{\"jsonrpc\":\"2.0\",\"method\":\"notifications/initialized\"}\n{\"jsonrpc\":\"2.0\",\"id\":2,\"method\":\"ping\"}\n")
+	var out bytes.Buffer
+
+	if err := s.ServeStdio(context.Background(), in, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 responses, got %d: %q", len(lines), out.String())
+	}
+}
+
+func TestArguments(t *testing.T) {
+	var args Arguments
+	if err := json.Unmarshal([]byte(`{"id":3,"neg":-1,"frac":1.5,"name":"x","tags":["a","b"],"mixed":["a",1],"ids":[1,2],"badids":[1,-2]}`), &args); err != nil {
+		t.Fatalf("Failed to decode arguments: %v", err)
+	}
+
+	if id, err := args.Uint("id"); err != nil || id != 3 {
+		t.Errorf("Expected id 3, got %d (%v)", id, err)
+	}
+	if _, err := args.Uint("neg"); err == nil {
+		t.Error("Expected error for negative uint")
+	}
+	if _, err := args.OptionalInt("frac", 0); err == nil {
+		t.Error("Expected error for fractional int")
+	}
+	if n, err := args.OptionalInt("missing", 10); err != nil || n != 10 {
+		t.Errorf("Expected default 10, got %d (%v)", n, err)
+	}
+	if s, err := args.OptionalString("name", ""); err != nil || s != "x" {
+		t.Errorf("Expected name x, got %q (%v)", s, err)
+	}
+	if tags, err := args.OptionalStrings("tags"); err != nil || len(tags) != 2 || tags[1] != "b" {
+		t.Errorf("Expected tags [a b], got %v (%v)", tags, err)
+	}
+	if _, err := args.OptionalStrings("mixed"); err == nil {
+		t.Error("Expected error for non-string array item")
+	}
+	if ids, err := args.Uints("ids"); err != nil || len(ids) != 2 || ids[1] != 2 {
+		t.Errorf("Expected ids [1 2], got %v (%v)", ids, err)
+	}
+	if _, err := args.Uints("badids"); err == nil {
+		t.Error("Expected error for negative array item")
+	}
+}
+
+func TestServer_Use(t *testing.T) {
+	s := newTestServer()
+
+	var calls []string
+	trace := func(label string) ToolMiddleware {
+		return func(name string, next ToolHandler) ToolHandler {
+			return func(ctx context.Context, args Arguments) (any, error) {
+				calls = append(calls, label+":"+name)
+				return next(ctx, args)
+			}
+		}
+	}
+	s.Use(trace("outer"), trace("inner"))
+
+	s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"message":"hi"}}}`))
+
+	if strings.Join(calls, ",") != "outer:echo,inner:echo" {
+		t.Errorf("Unexpected middleware order: %v", calls)
+	}
+}
+
+func TestServer_UseResource(t *testing.T) {
+	s := newSubscriptionServer()
+
+	var calls []string
+	trace := func(label string) ResourceMiddleware {
+		return func(resource Resource, next ResourceHandler) ResourceHandler {
+			return func(ctx context.Context) (string, error) {
+				calls = append(calls, label+":"+resource.URI+":"+resource.MimeType)
+				text, err := next(ctx)
+				return label + "(" + text + ")", err
+			}
+		}
+	}
+	s.UseResource(trace("outer"), trace("inner"))
+
+	resp := decodeResponse(t, s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"dvd://rentals/7"}}`)))
+	if strings.Join(calls, ",") != "outer:dvd://rentals/7:application/json,inner:dvd://rentals/7:application/json" {
+		t.Errorf("Unexpected middleware order: %v", calls)
+	}
+	if contents := resp.Result.(map[string]any)["contents"].([]any)[0].(map[string]any); contents["text"] != `outer(inner({"rental_id":7}))` {
+		t.Errorf("Expected the text returned by the middleware, got %v", contents)
+	}
+}
+
+func TestServer_InternalErrorHidesMessage(t *testing.T) {
+	s := newTestServer()
+
+	resp := decodeResponse(t, s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"fail"}}`)))
+	if resp.Error == nil || strings.Contains(resp.Error.Message, "boom") {
+		t.Errorf("Expected a generic internal error, got %+v", resp.Error)
+	}
+}
+
+func TestServer_Resources(t *testing.T) {
+	s := newTestServer()
+	s.AddResource(Resource{URI: "test://b", Name: "b", MimeType: "text/plain"}, func(ctx context.Context) (string, error) {
+		return "bee", nil
+	})
+	s.AddResource(Resource{URI: "test://a", Name: "a"}, func(ctx context.Context) (string, error) {
+		return "", errors.New("boom")
+	})
+
+	resp := decodeResponse(t, s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/list"}`)))
+	resources := resp.Result.(map[string]any)["resources"].([]any)
+	if len(resources) != 2 || resources[0].(map[string]any)["uri"] != "test://a" {
+		t.Fatalf("Expected resources sorted by URI, got %v", resources)
+	}
+
+	resp = decodeResponse(t, s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":2,"method":"resources/read","params":{"uri":"test://b"}}`)))
+	contents := resp.Result.(map[string]any)["contents"].([]any)[0].(map[string]any)
+	if contents["text"] != "bee" || contents["mimeType"] != "text/plain" {
+		t.Errorf("Unexpected contents: %v", contents)
+	}
+
+	resp = decodeResponse(t, s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"test://missing"}}`)))
+	if resp.Error == nil || resp.Error.Code != ResourceNotFound {
+		t.Errorf("Expected resource not found, got %+v", resp.Error)
+	}
+
+	resp = decodeResponse(t, s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"test://a"}}`)))
+	if resp.Error == nil || resp.Error.Code != InternalError {
+		t.Errorf("Expected internal error, got %+v", resp.Error)
+	}
+
+	s.RemoveResource("test://b")
+	resp = decodeResponse(t, s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":5,"method":"resources/read","params":{"uri":"test://b"}}`)))
+	if resp.Error == nil || resp.Error.Code != ResourceNotFound {
+		t.Errorf("Expected a removed resource not to be found, got %+v", resp.Error)
+	}
+}
+
+func TestServer_ClientFromContext(t *testing.T) {
+	s := newTestServer()
+	var client Implementation
+	s.AddTool(Tool{Name: "whoami"}, func(ctx context.Context, args Arguments) (any, error) {
+		client = ClientFromContext(ctx)
+		return nil, nil
+	})
+
+	s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"clientInfo":{"name":"inspector","version":"0.1"}}}`))
+	s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"whoami"}}`))
+
+	if client.Name != "inspector" {
+		t.Errorf("Expected client inspector, got %+v", client)
+	}
+}
+
+func TestServer_ServeHTTP(t *testing.T) {
+	s := newTestServer()
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"message":"hi"}}}`)))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected a JSON response, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if resp := decodeResponse(t, rec.Body.Bytes()); resp.Error != nil {
+		t.Errorf("Unexpected error: %v", resp.Error)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)))
+	if rec.Code != http.StatusAccepted || rec.Body.Len() != 0 {
+		t.Errorf("Expected notification to be accepted without a body, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/mcp", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected GET to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestServer_UseRequest(t *testing.T) {
+	s := newTestServer()
+	var methods []string
+	s.UseRequest(func(next RequestHandler) RequestHandler {
+		return func(ctx context.Context, req *Request) (any, error) {
+			methods = append(methods, req.Method)
+			return next(ctx, req)
+		}
+	})
+
+	s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+	if strings.Join(methods, ",") != "tools/list,notifications/initialized" {
+		t.Errorf("Expected every request to pass through the middleware, got %v", methods)
+	}
+}
@@ -0,0 +1,165 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ErrNoTransport is returned when sending a notification while no transport that can send one is served
+var ErrNoTransport = errors.New("mcp: no transport can send notifications")
+
+// ResourceTemplateHandler returns the text of a resource matching a template when it is read; params holds
+// the values of the template's variables
+type ResourceTemplateHandler func(ctx context.Context, uri string, params map[string]string) (string, error)
+
+type templateEntry struct {
+	template ResourceTemplate
+	handler  ResourceTemplateHandler
+}
+
+// AddResourceTemplate registers a resource template, replacing any template with the same URI template.
+// Variables in braces match one or more characters other than a slash.
+func (s *Server) AddResourceTemplate(template ResourceTemplate, handler ResourceTemplateHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.templates[template.URITemplate] = templateEntry{template: template, handler: handler}
+}
+
+// ResourceTemplates returns the registered resource templates sorted by URI template
+func (s *Server) ResourceTemplates() []ResourceTemplate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	templates := make([]ResourceTemplate, 0, len(s.templates))
+	for _, entry := range s.templates {
+		templates = append(templates, entry.template)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].URITemplate < templates[j].URITemplate
+	})
+	return templates
+}
+
+// Subscribed reports whether the client subscribed to updates of the resource at uri
+func (s *Server) Subscribed(uri string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.subscriptions[uri]
+}
+
+// HasSubscriptions reports whether the client subscribed to updates of any resource
+func (s *Server) HasSubscriptions() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.subscriptions) > 0
+}
+
+// ResourceUpdated sends notifications/resources/updated for the resource at uri if the client subscribed
+// to it
+func (s *Server) ResourceUpdated(uri string) error {
+	if !s.Subscribed(uri) {
+		return nil
+	}
+	return s.Notify("notifications/resources/updated", subscribeParams{URI: uri})
+}
+
+// Notify sends a notification to the client over the transport being served. It fails with ErrNoTransport
+// unless the server is serving stdio.
+func (s *Server) Notify(method string, params any) error {
+	s.mu.RLock()
+	send := s.notify
+	s.mu.RUnlock()
+	if send == nil {
+		return ErrNoTransport
+	}
+	message, err := json.Marshal(notification{JSONRPC: jsonrpcVersion, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	return send(message)
+}
+
+func (s *Server) subscribe(raw json.RawMessage, subscribed bool) (any, error) {
+	var params subscribeParams
+	if err := json.Unmarshal(raw, &params); err != nil || params.URI == "" {
+		return nil, NewError(InvalidParams, "invalid subscription params")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !subscribed {
+		delete(s.subscriptions, params.URI)
+		return struct{}{}, nil
+	}
+	if s.notify == nil {
+		return nil, NewError(InvalidRequest, "resource subscriptions need a transport that can send notifications, such as stdio")
+	}
+	if _, _, ok := s.lookupResource(params.URI); !ok {
+		return nil, NewError(ResourceNotFound, "resource not found: "+params.URI)
+	}
+	s.subscriptions[params.URI] = true
+	return struct{}{}, nil
+}
+
+// lookupResource returns the resource at uri and its handler, from the registered resources or templates.
+// The caller must hold s.mu.
+func (s *Server) lookupResource(uri string) (Resource, ResourceHandler, bool) {
+	if entry, ok := s.resources[uri]; ok {
+		return entry.resource, entry.handler, true
+	}
+	for _, entry := range s.templates {
+		if params, ok := matchTemplate(entry.template.URITemplate, uri); ok {
+			handler := entry.handler
+			resource := Resource{URI: uri, Name: entry.template.Name, Description: entry.template.Description, MimeType: entry.template.MimeType}
+			return resource, func(ctx context.Context) (string, error) {
+				return handler(ctx, uri, params)
+			}, true
+		}
+	}
+	return Resource{}, nil, false
+}
+
+// matchTemplate matches uri against a URI template, returning the values of the template's variables
+func matchTemplate(template, uri string) (map[string]string, bool) {
+	params := map[string]string{}
+	for template != "" {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			return params, template == uri
+		}
+		if !strings.HasPrefix(uri, template[:start]) {
+			return nil, false
+		}
+		uri = uri[start:]
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			return nil, false
+		}
+		name := template[start+1 : start+end]
+		template = template[start+end+1:]
+
+		// A variable extends to the next slash, or to the literal text that follows it
+		value := uri
+		if slash := strings.IndexByte(uri, '/'); slash >= 0 {
+			value = uri[:slash]
+		}
+		if next := strings.IndexAny(template, "{/"); next != 0 && template != "" {
+			literal := template
+			if next > 0 {
+				literal = template[:next]
+			}
+			if i := strings.Index(value, literal); i >= 0 {
+				value = value[:i]
+			}
+		}
+		if value == "" {
+			return nil, false
+		}
+		params[name] = value
+		uri = uri[len(value):]
+	}
+	return params, uri == ""
+}
@@ -0,0 +1,130 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchTemplate(t *testing.T) {
+	tests := []struct {
+		template, uri string
+		want          map[string]string
+	}{
+		{"dvd://rentals/{rental_id}", "dvd://rentals/42", map[string]string{"rental_id": "42"}},
+		{"dvd://stores/{store_id}/availability", "dvd://stores/1/availability", map[string]string{"store_id": "1"}},
+		{"dvd://files/{name}.json", "dvd://files/report.json", map[string]string{"name": "report"}},
+		{"dvd://stores/{store_id}/availability", "dvd://stores/1/rentals", nil},
+		{"dvd://rentals/{rental_id}", "dvd://rentals/", nil},
+		{"dvd://rentals/{rental_id}", "dvd://rentals/1/2", nil},
+	}
+	for _, tt := range tests {
+		params, ok := matchTemplate(tt.template, tt.uri)
+		if ok != (tt.want != nil) || (ok && params[firstKey(tt.want)] != tt.want[firstKey(tt.want)]) {
+			t.Errorf("matchTemplate(%q, %q) = %v, %v, want %v", tt.template, tt.uri, params, ok, tt.want)
+		}
+	}
+}
+
+func firstKey(m map[string]string) string {
+	for key := range m {
+		return key
+	}
+	return ""
+}
+
+func newSubscriptionServer() *Server {
+	s := NewServer("test", "1.0.0")
+	s.AddResourceTemplate(ResourceTemplate{URITemplate: "dvd://rentals/{rental_id}", Name: "rental", MimeType: "application/json"},
+		func(ctx context.Context, uri string, params map[string]string) (string, error) {
+			return `{"rental_id":` + params["rental_id"] + `}`, nil
+		})
+	return s
+}
+
+func TestServer_ResourceTemplates(t *testing.T) {
+	s := newSubscriptionServer()
+
+	resp := decodeResponse(t, s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"dvd://rentals/7"}}`)))
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	contents := resp.Result.(map[string]any)["contents"].([]any)[0].(map[string]any)
+	if contents["text"] != `{"rental_id":7}` || contents["mimeType"] != "application/json" {
+		t.Errorf("Unexpected contents: %v", contents)
+	}
+
+	resp = decodeResponse(t, s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":2,"method":"resources/templates/list"}`)))
+	if templates := resp.Result.(map[string]any)["resourceTemplates"].([]any); len(templates) != 1 {
+		t.Errorf("Expected 1 template, got %v", templates)
+	}
+}
+
+func TestServer_SubscribeWithoutTransport(t *testing.T) {
+	s := newSubscriptionServer()
+
+	resp := decodeResponse(t, s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"dvd://rentals/7"}}`)))
+	if resp.Error == nil || resp.Error.Code != InvalidRequest {
+		t.Errorf("Expected subscriptions to need a transport, got %+v", resp.Error)
+	}
+	if err := s.Notify("notifications/resources/updated", nil); err != ErrNoTransport {
+		t.Errorf("Expected ErrNoTransport, got %v", err)
+	}
+}
+
+func TestServer_SubscribeOverStdio(t *testing.T) {
+	s := newSubscriptionServer()
+	in, input := io.Pipe()
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() { done <- s.ServeStdio(context.Background(), in, &out) }()
+
+	send := func(message string) {
+		if _, err := input.Write([]byte(message + "\n")); err != nil {
+			t.Fatalf("Failed to send %s: %v", message, err)
+		}
+	}
+	send(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"dvd://rentals/7"}}`)
+	send(`{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"dvd://films/7"}}`)
+	send(`{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	for deadline := time.Now().Add(time.Second); !s.Subscribed("dvd://rentals/7"); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the subscription")
+		}
+	}
+
+	if err := s.ResourceUpdated("dvd://rentals/7"); err != nil {
+		t.Fatalf("Failed to notify: %v", err)
+	}
+	if err := s.ResourceUpdated("dvd://rentals/8"); err != nil {
+		t.Fatalf("Expected unsubscribed resources to be ignored, got %v", err)
+	}
+	send(`{"jsonrpc":"2.0","id":4,"method":"resources/unsubscribe","params":{"uri":"dvd://rentals/7"}}`)
+	input.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	var updates int
+	for _, line := range lines {
+		if strings.Contains(line, `"method":"notifications/resources/updated"`) {
+			updates++
+			if !strings.Contains(line, `"uri":"dvd://rentals/7"`) {
+				t.Errorf("Unexpected notification %s", line)
+			}
+		}
+	}
+	if len(lines) != 5 || updates != 1 {
+		t.Errorf("Expected 4 responses and 1 notification, got %q", lines)
+	}
+	if !strings.Contains(lines[1], `"code":-32002`) {
+		t.Errorf("Expected subscribing to an unknown resource to fail, got %s", lines[1])
+	}
+	if s.HasSubscriptions() {
+		t.Error("Expected subscriptions to end with the transport")
+	}
+}
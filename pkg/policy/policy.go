@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"CortexMCP/pkg/principal"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Built-in roles
+const (
+	// RoleClerk may call read-only tools for their stores
+	RoleClerk = "clerk"
+
+	// RoleManager may also call tools that write without deleting or overwriting data
+	RoleManager = "manager"
+
+	// RoleAdmin may call every tool and sees every store
+	RoleAdmin = "admin"
+)
+
+// ErrForbidden is returned when a principal is not allowed to call a tool or touch a store's data
+var ErrForbidden = errors.New("permission denied")
+
+// Permissions are what a role may do
+type Permissions struct {
+	// Write allows tools that are not read-only
+	Write bool `yaml:"write" mapstructure:"write"`
+
+	// Destructive allows tools that delete or overwrite data
+	Destructive bool `yaml:"destructive" mapstructure:"destructive"`
+
+	// AllStores lifts store scoping
+	AllStores bool `yaml:"allStores" mapstructure:"allStores"`
+}
+
+// DefaultRoles are the permissions of the built-in roles; Config.Roles may override them or add roles
+var DefaultRoles = map[string]Permissions{
+	RoleClerk:   {},
+	RoleManager: {Write: true},
+	RoleAdmin:   {Write: true, Destructive: true, AllStores: true},
+}
+
+// Config is the authorization policy
+type Config struct {
+	// Enabled turns on authorization. When disabled every caller may call every tool for every store.
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// Roles override or add to DefaultRoles
+	Roles map[string]Permissions `yaml:"roles" mapstructure:"roles"`
+
+	// Principals assigns roles and stores to principals. Principals not listed are denied.
+	Principals []Assignment `yaml:"principals" mapstructure:"principals" validate:"dive"`
+}
+
+// Assignment gives a principal, e.g. staff:2 or client:reporting, a role at a set of stores
+type Assignment struct {
+	Principal string `yaml:"principal" mapstructure:"principal" validate:"required"`
+	Role      string `yaml:"role" mapstructure:"role" validate:"required"`
+	Stores    []uint `yaml:"stores" mapstructure:"stores"`
+}
+
+// Grant is what a principal may do, resolved from its assignment
+type Grant struct {
+	Role string
+	Permissions
+	StoreIDs []uint
+}
+
+// AllowsTool reports whether the grant allows calling a tool with the given hints
+func (g Grant) AllowsTool(readOnly, destructive bool) bool {
+	switch {
+	case readOnly:
+		return true
+	case destructive:
+		return g.Write && g.Destructive
+	default:
+		return g.Write
+	}
+}
+
+// HasStore reports whether the grant covers the store
+func (g Grant) HasStore(storeID uint) bool {
+	return g.AllStores || slices.Contains(g.StoreIDs, storeID)
+}
+
+// Policy resolves the grants of principals
+type Policy struct {
+	grants map[string]Grant
+}
+
+// New creates a policy from its configuration
+func New(cfg Config) (*Policy, error) {
+	roles := make(map[string]Permissions, len(DefaultRoles)+len(cfg.Roles))
+	for name, permissions := range DefaultRoles {
+		roles[name] = permissions
+	}
+	for name, permissions := range cfg.Roles {
+		roles[name] = permissions
+	}
+
+	p := &Policy{grants: make(map[string]Grant, len(cfg.Principals))}
+	for _, assignment := range cfg.Principals {
+		permissions, ok := roles[assignment.Role]
+		if !ok {
+			return nil, fmt.Errorf("principal %s has unknown role %q", assignment.Principal, assignment.Role)
+		}
+		if !permissions.AllStores && len(assignment.Stores) == 0 {
+			return nil, fmt.Errorf("principal %s has role %q but no stores", assignment.Principal, assignment.Role)
+		}
+		key := principal.Parse(assignment.Principal).String()
+		if _, ok := p.grants[key]; ok {
+			return nil, fmt.Errorf("principal %s is assigned more than once", assignment.Principal)
+		}
+		p.grants[key] = Grant{Role: assignment.Role, Permissions: permissions, StoreIDs: assignment.Stores}
+	}
+	return p, nil
+}
+
+// Grant returns the grant of a principal, or ErrForbidden when it has none
+func (p *Policy) Grant(pr principal.Principal) (Grant, error) {
+	grant, ok := p.grants[pr.String()]
+	if !ok {
+		return Grant{}, fmt.Errorf("%w: %s has no role", ErrForbidden, pr)
+	}
+	return grant, nil
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the grant, which scopes the queries run with it
+func NewContext(ctx context.Context, g Grant) context.Context {
+	return context.WithValue(ctx, contextKey{}, g)
+}
+
+// FromContext returns the grant carried by ctx
+func FromContext(ctx context.Context) (Grant, bool) {
+	g, ok := ctx.Value(contextKey{}).(Grant)
+	return g, ok
+}
@@ -0,0 +1,66 @@
+package policy
+
+import (
+	"CortexMCP/pkg/principal"
+	"errors"
+	"testing"
+)
+
+func TestPolicy_Grant(t *testing.T) {
+	p, err := New(Config{
+		Enabled: true,
+		Roles:   map[string]Permissions{"auditor": {AllStores: true}},
+		Principals: []Assignment{
+			{Principal: "staff:1", Role: RoleClerk, Stores: []uint{1}},
+			{Principal: "staff:2", Role: RoleManager, Stores: []uint{2}},
+			{Principal: "client:ops", Role: RoleAdmin},
+			{Principal: "reporting", Role: "auditor"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create policy: %v", err)
+	}
+
+	clerk, err := p.Grant(principal.Staff(1))
+	if err != nil || !clerk.HasStore(1) || clerk.HasStore(7) {
+		t.Errorf("Expected clerk at store 1, got %+v (%v)", clerk, err)
+	}
+	if !clerk.AllowsTool(true, false) || clerk.AllowsTool(false, false) {
+		t.Errorf("Expected clerk to call read-only tools only")
+	}
+
+	manager, _ := p.Grant(principal.Staff(2))
+	if !manager.AllowsTool(false, false) || manager.AllowsTool(false, true) {
+		t.Errorf("Expected manager to call non-destructive write tools only")
+	}
+
+	admin, _ := p.Grant(principal.Client("ops"))
+	if !admin.AllowsTool(false, true) || !admin.HasStore(7) {
+		t.Errorf("Expected admin to call every tool for every store")
+	}
+
+	auditor, err := p.Grant(principal.Client("reporting"))
+	if err != nil || auditor.AllowsTool(false, false) || !auditor.HasStore(7) {
+		t.Errorf("Expected the configured auditor role, got %+v (%v)", auditor, err)
+	}
+
+	if _, err := p.Grant(principal.Staff(9)); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected unknown principals to be forbidden, got %v", err)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tests := map[string]Config{
+		"unknown role": {Principals: []Assignment{{Principal: "staff:1", Role: "owner", Stores: []uint{1}}}},
+		"no stores":    {Principals: []Assignment{{Principal: "staff:1", Role: RoleClerk}}},
+		"duplicate": {Principals: []Assignment{
+			{Principal: "staff:1", Role: RoleClerk, Stores: []uint{1}},
+			{Principal: "staff:1", Role: RoleAdmin},
+		}},
+	}
+	for name, cfg := range tests {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// storeScope describes how the rows of a table belong to stores
+type storeScope struct {
+	// column is the column of the table that identifies the store, directly or through via
+	column string
+
+	// via is the table mapping column to a store_id; empty when column is the store_id itself
+	via string
+}
+
+// scopedTables are the tables whose rows belong to a store. Rentals and reservations belong to the store of
+// their copy and payments to the store of the staff member who took them.
+var scopedTables = map[string]storeScope{
+	"customer":    {column: "store_id"},
+	"staff":       {column: "store_id"},
+	"inventory":   {column: "store_id"},
+	"rental":      {column: "inventory_id", via: "inventory"},
+	"reservation": {column: "inventory_id", via: "inventory"},
+	"payment":     {column: "staff_id", via: "staff"},
+	"waitlist":    {column: "store_id"},
+}
+
+// allStoresTables are the tables whose rows cannot be scoped to stores, like the audit log recording the changes
+// of every table. Only grants for all stores may read them.
+var allStoresTables = map[string]bool{
+	"audit_log": true,
+}
+
+// Register adds callbacks to db that restrict statements run with a grant in their context to the grant's
+// stores: queries, updates and deletes only see the stores' rows, and creates are rejected with ErrForbidden
+// when a row belongs to another store. Reading a table of allStoresTables is rejected with ErrForbidden too.
+// Statements without a grant, e.g. from the CLI, are not restricted.
+func Register(db *gorm.DB) error {
+	err := errors.Join(
+		db.Callback().Query().Before("gorm:query").Register("policy:scope_query", scope),
+		db.Callback().Row().Before("gorm:row").Register("policy:scope_row", scope),
+		db.Callback().Update().Before("gorm:update").Register("policy:scope_update", scope),
+		db.Callback().Delete().Before("gorm:delete").Register("policy:scope_delete", scope),
+		db.Callback().Create().Before("gorm:create").Register("policy:check_create", checkCreate),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register policy callbacks: %w", err)
+	}
+	return nil
+}
+
+// storeGrant returns the grant of a statement whose grant does not cover all stores, and the statement's table
+func storeGrant(db *gorm.DB) (Grant, string, bool) {
+	if db.Error != nil || db.Statement.Context == nil {
+		return Grant{}, "", false
+	}
+	grant, ok := FromContext(db.Statement.Context)
+	if !ok || grant.AllStores {
+		return Grant{}, "", false
+	}
+	table := db.Statement.Table
+	if table == "" && db.Statement.Schema != nil {
+		table = db.Statement.Schema.Table
+	}
+	return grant, table, true
+}
+
+// restricted returns the store scope and grant of a statement that must be restricted
+func restricted(db *gorm.DB) (storeScope, Grant, bool) {
+	grant, table, ok := storeGrant(db)
+	if !ok {
+		return storeScope{}, Grant{}, false
+	}
+	s, ok := scopedTables[table]
+	return s, grant, ok
+}
+
+// scope adds the grant's store condition to a statement that has not been built yet, or rejects it when its table
+// cannot be scoped
+func scope(db *gorm.DB) {
+	if _, table, ok := storeGrant(db); ok && allStoresTables[table] {
+		_ = db.AddError(fmt.Errorf("%w: %s needs a grant for all stores", ErrForbidden, table))
+		return
+	}
+	s, grant, ok := restricted(db)
+	if !ok || db.Statement.SQL.Len() > 0 {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{s.condition(db, grant.StoreIDs)}})
+}
+
+// condition matches the rows of the current table that belong to the stores
+func (s storeScope) condition(db *gorm.DB, storeIDs []uint) clause.Expression {
+	column := clause.Column{Table: clause.CurrentTable, Name: s.column}
+	if s.via == "" {
+		return clause.IN{Column: column, Values: ids(storeIDs)}
+	}
+	stores := db.Session(&gorm.Session{NewDB: true, Context: unscoped(db.Statement.Context)}).Table(s.via).Select(s.column).Where(clause.IN{Column: clause.Column{Name: "store_id"}, Values: ids(storeIDs)})
+	return clause.Expr{SQL: "? IN (?)", Vars: []any{column, stores}}
+}
+
+// checkCreate rejects rows that belong to stores outside the grant. Upserts that overwrite existing rows are
+// rejected too, since the row being overwritten may belong to another store.
+func checkCreate(db *gorm.DB) {
+	s, grant, ok := restricted(db)
+	if !ok || db.Statement.Schema == nil {
+		return
+	}
+	if onConflict, ok := db.Statement.Clauses["ON CONFLICT"].Expression.(clause.OnConflict); ok && !onConflict.DoNothing {
+		_ = db.AddError(fmt.Errorf("%w: store-scoped principals cannot overwrite %s rows", ErrForbidden, db.Statement.Table))
+		return
+	}
+	field := db.Statement.Schema.LookUpField(s.column)
+	if field == nil {
+		return
+	}
+
+	values := map[uint]bool{}
+	for _, model := range models(db.Statement.ReflectValue) {
+		if value, zero := field.ValueOf(db.Statement.Context, model); !zero {
+			if id, ok := value.(uint); ok {
+				values[id] = true
+			}
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	if s.via == "" {
+		for id := range values {
+			if !grant.HasStore(id) {
+				_ = db.AddError(fmt.Errorf("%w: store %d", ErrForbidden, id))
+				return
+			}
+		}
+		return
+	}
+
+	keys := make([]any, 0, len(values))
+	for id := range values {
+		keys = append(keys, id)
+	}
+	var count int64
+	err := db.Session(&gorm.Session{NewDB: true, Context: unscoped(db.Statement.Context)}).Table(s.via).
+		Where(clause.IN{Column: clause.Column{Name: s.column}, Values: keys}).
+		Where(clause.IN{Column: clause.Column{Name: "store_id"}, Values: ids(grant.StoreIDs)}).
+		Count(&count).Error
+	if err != nil {
+		_ = db.AddError(err)
+	} else if count != int64(len(keys)) {
+		_ = db.AddError(fmt.Errorf("%w: %s of another store", ErrForbidden, s.via))
+	}
+}
+
+// unscoped returns ctx without its grant, for the queries the callbacks run themselves
+func unscoped(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, nil)
+}
+
+func ids(storeIDs []uint) []any {
+	values := make([]any, len(storeIDs))
+	for i, id := range storeIDs {
+		values[i] = id
+	}
+	return values
+}
+
+// models returns the structs held by a statement's reflect value, which is a struct or a slice of structs
+func models(value reflect.Value) []reflect.Value {
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{value}
+	case reflect.Slice, reflect.Array:
+		structs := make([]reflect.Value, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			if model := reflect.Indirect(value.Index(i)); model.Kind() == reflect.Struct {
+				structs = append(structs, model)
+			}
+		}
+		return structs
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package policy
+
+import (
+	"CortexMCP/db/entity"
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupScopeTest(t *testing.T) (sqlmock.Sqlmock, *gorm.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open gorm database: %v", err)
+	}
+	if err := Register(gormDB); err != nil {
+		t.Fatalf("Failed to register policy callbacks: %v", err)
+	}
+	return mock, gormDB
+}
+
+var clerk = Grant{Role: RoleClerk, StoreIDs: []uint{1, 2}}
+
+func TestScope_Query(t *testing.T) {
+	mock, db := setupScopeTest(t)
+	ctx := NewContext(context.Background(), clerk)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer` WHERE last_name = ? AND `customer`.`store_id` IN (?,?) AND `customer`.`deleted_at` IS NULL")).
+		WithArgs("Smith", 1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"customer_id"}))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rental` WHERE `rental`.`inventory_id` IN (SELECT inventory_id FROM `inventory` WHERE `store_id` IN (?,?)) AND `rental`.`deleted_at` IS NULL")).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"rental_id"}))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `payment` WHERE `payment`.`staff_id` IN (SELECT staff_id FROM `staff` WHERE `store_id` IN (?,?)) AND `payment`.`deleted_at` IS NULL")).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	var customers []entity.Customer
+	if err := db.WithContext(ctx).Where("last_name = ?", "Smith").Find(&customers).Error; err != nil {
+		t.Fatalf("Error finding customers: %v", err)
+	}
+	var rentals []entity.Rental
+	if err := db.WithContext(ctx).Find(&rentals).Error; err != nil {
+		t.Fatalf("Error finding rentals: %v", err)
+	}
+	var count int64
+	if err := db.WithContext(ctx).Model(&entity.Payment{}).Count(&count).Error; err != nil {
+		t.Fatalf("Error counting payments: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestScope_Unrestricted(t *testing.T) {
+	mock, db := setupScopeTest(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer` WHERE `customer`.`deleted_at` IS NULL")).
+		WillReturnRows(sqlmock.NewRows([]string{"customer_id"}))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer` WHERE `customer`.`deleted_at` IS NULL")).
+		WillReturnRows(sqlmock.NewRows([]string{"customer_id"}))
+
+	var customers []entity.Customer
+	admin := Grant{Role: RoleAdmin, Permissions: DefaultRoles[RoleAdmin]}
+	for _, ctx := range []context.Context{context.Background(), NewContext(context.Background(), admin)} {
+		if err := db.WithContext(ctx).Find(&customers).Error; err != nil {
+			t.Fatalf("Error finding customers: %v", err)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestScope_AllStoresTables(t *testing.T) {
+	mock, db := setupScopeTest(t)
+
+	var entries []entity.AuditLog
+	err := db.WithContext(NewContext(context.Background(), clerk)).Where("table_name = ?", "customer").Find(&entries).Error
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden reading the audit log for some stores, got %v", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `audit_log` WHERE table_name = ?")).
+		WithArgs("customer").
+		WillReturnRows(sqlmock.NewRows([]string{"audit_log_id"}))
+	admin := Grant{Role: RoleAdmin, Permissions: DefaultRoles[RoleAdmin]}
+	if err := db.WithContext(NewContext(context.Background(), admin)).Where("table_name = ?", "customer").Find(&entries).Error; err != nil {
+		t.Errorf("Error reading the audit log for all stores: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestScope_UpdateAndDelete(t *testing.T) {
+	mock, db := setupScopeTest(t)
+	ctx := NewContext(context.Background(), clerk)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `inventory` SET `store_id`=?,`updated_at`=? WHERE inventory_id = ? AND `inventory`.`store_id` IN (?,?) AND `inventory`.`deleted_at` IS NULL")).
+		WithArgs(1, sqlmock.AnyArg(), 10, 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `staff` SET `deleted_at`=? WHERE staff_id = ? AND `staff`.`store_id` IN (?,?) AND `staff`.`deleted_at` IS NULL")).
+		WithArgs(sqlmock.AnyArg(), 3, 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	if err := db.WithContext(ctx).Model(&entity.Inventory{}).Where("inventory_id = ?", 10).Update("store_id", 1).Error; err != nil {
+		t.Fatalf("Error updating inventory: %v", err)
+	}
+	if err := db.WithContext(ctx).Where("staff_id = ?", 3).Delete(&entity.Staff{}).Error; err != nil {
+		t.Fatalf("Error deleting staff: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestScope_Create(t *testing.T) {
+	mock, db := setupScopeTest(t)
+	ctx := NewContext(context.Background(), clerk)
+
+	// A customer of another store is rejected before anything is written
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	if err := db.WithContext(ctx).Create(&entity.Customer{StoreID: 7}).Error; !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected a customer of store 7 to be forbidden, got %v", err)
+	}
+
+	// A rental of a copy held by another store is rejected
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `inventory` WHERE `inventory_id` = ? AND `store_id` IN (?,?)")).
+		WithArgs(uint(25), 1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+	if err := db.WithContext(ctx).Create(&entity.Rental{InventoryID: 25, CustomerID: 1, StaffID: 1}).Error; !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected a rental of another store's copy to be forbidden, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
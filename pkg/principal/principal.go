@@ -0,0 +1,70 @@
+package principal
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Kinds of principals
+const (
+	// KindStaff is a staff member, identified by staff ID
+	KindStaff = "staff"
+
+	// KindClient is an MCP client, identified by the name it reports or the credential it authenticated with
+	KindClient = "client"
+
+	// KindJob is a background job of the server, identified by its name
+	KindJob = "job"
+)
+
+// Principal identifies who is acting on the data
+type Principal struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+}
+
+// Staff returns the principal of a staff member
+func Staff(staffID uint) Principal {
+	return Principal{Kind: KindStaff, ID: strconv.FormatUint(uint64(staffID), 10)}
+}
+
+// Client returns the principal of an MCP client
+func Client(id string) Principal {
+	return Principal{Kind: KindClient, ID: id}
+}
+
+// Job returns the principal of a background job
+func Job(name string) Principal {
+	return Principal{Kind: KindJob, ID: name}
+}
+
+// String formats the principal as kind:id, e.g. "staff:2"
+func (p Principal) String() string {
+	if p.Kind == "" {
+		return p.ID
+	}
+	return p.Kind + ":" + p.ID
+}
+
+// Parse parses a principal formatted by String. A value without a kind is a client.
+func Parse(s string) Principal {
+	kind, id, ok := strings.Cut(s, ":")
+	if !ok {
+		return Client(s)
+	}
+	return Principal{Kind: kind, ID: id}
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the principal
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal carried by ctx
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
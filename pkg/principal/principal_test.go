@@ -0,0 +1,34 @@
+package principal
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("Expected no principal in an empty context")
+	}
+
+	p, ok := FromContext(NewContext(context.Background(), Staff(2)))
+	if !ok || p.String() != "staff:2" {
+		t.Errorf("Expected staff:2, got %q (%v)", p, ok)
+	}
+	if s := Client("claude-desktop").String(); s != "client:claude-desktop" {
+		t.Errorf("Expected client:claude-desktop, got %q", s)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := map[string]Principal{
+		"staff:2":              Staff(2),
+		"client:claude-ci":     Client("claude-ci"),
+		"reporting-dashboard":  Client("reporting-dashboard"),
+		"client:urn:example:1": Client("urn:example:1"),
+	}
+	for s, expected := range tests {
+		if p := Parse(s); p != expected {
+			t.Errorf("Parse(%q) = %+v, expected %+v", s, p, expected)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrLimited is matched by every *Error
+var ErrLimited = errors.New("rate limited")
+
+// busyRetryAfter is the hint given when every concurrency slot is busy, since there is no way to know when
+// one frees up
+const busyRetryAfter = time.Second
+
+// maxBuckets bounds the number of buckets kept; beyond it, buckets that have refilled are forgotten
+const maxBuckets = 10000
+
+// Error reports a call that was refused, and when it is worth retrying
+type Error struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s, retry after %s", e.Reason, e.RetryAfter)
+}
+
+// Is makes every *Error match ErrLimited
+func (e *Error) Is(target error) bool {
+	return target == ErrLimited
+}
+
+// Rate is a token bucket: calls are allowed at PerSecond on average, in bursts of up to Burst calls
+type Rate struct {
+	// PerSecond is the sustained rate; zero or less means unlimited
+	PerSecond float64 `yaml:"perSecond" mapstructure:"perSecond" validate:"min=0"`
+
+	// Burst is the number of calls allowed at once; at least one
+	Burst int `yaml:"burst" mapstructure:"burst" validate:"min=0"`
+}
+
+func (r Rate) unlimited() bool {
+	return r.PerSecond <= 0
+}
+
+func (r Rate) burst() float64 {
+	return math.Max(float64(r.Burst), 1)
+}
+
+// Config configures rate limits and the concurrency cap
+type Config struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// PerClient limits all the calls of a client
+	PerClient Rate `yaml:"perClient" mapstructure:"perClient"`
+
+	// PerTool limits the calls a client makes to a single tool, unless Tools sets a rate for it
+	PerTool Rate `yaml:"perTool" mapstructure:"perTool"`
+
+	// Tools set the per-client rate of individual tools, e.g. expensive reports
+	Tools map[string]Rate `yaml:"tools" mapstructure:"tools" validate:"dive"`
+
+	// MaxConcurrent caps the tool calls running at once across all clients. Zero uses the size of the
+	// database connection pool, so that tool calls cannot starve it.
+	MaxConcurrent int `yaml:"maxConcurrent" mapstructure:"maxConcurrent" validate:"min=0"`
+
+	// QueueTimeout is how long a call waits for a free slot. Zero rejects calls immediately when every
+	// slot is busy.
+	QueueTimeout time.Duration `yaml:"queueTimeout" mapstructure:"queueTimeout" validate:"min=0"`
+}
+
+// bucket is the state of a token bucket
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens earned since the last call
+func (b *bucket) refill(rate Rate, now time.Time) {
+	b.tokens = math.Min(rate.burst(), b.tokens+now.Sub(b.last).Seconds()*rate.PerSecond)
+	b.last = now
+}
+
+// wait returns how long until the bucket has a token
+func (b *bucket) wait(rate Rate) time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - b.tokens) / rate.PerSecond * float64(time.Second)))
+}
+
+// Limiter enforces rate limits per client and tool and caps concurrent calls. It is safe for concurrent use.
+type Limiter struct {
+	cfg   Config
+	slots chan struct{}
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// New creates a limiter. poolSize is the size of the database connection pool, used as the concurrency cap
+// when the configuration sets none.
+func New(cfg Config, poolSize int) *Limiter {
+	l := &Limiter{cfg: cfg, now: time.Now, buckets: make(map[string]*bucket)}
+	size := cfg.MaxConcurrent
+	if size == 0 {
+		size = poolSize
+	}
+	if size > 0 {
+		l.slots = make(chan struct{}, size)
+	}
+	return l
+}
+
+// Allow takes a token from the client's bucket and the client's bucket for the tool, or returns an *Error
+// with the time until both have one. No token is taken when the call is refused.
+func (l *Limiter) Allow(client, tool string) error {
+	toolRate, ok := l.cfg.Tools[tool]
+	if !ok {
+		toolRate = l.cfg.PerTool
+	}
+	limits := []struct {
+		key, reason string
+		rate        Rate
+	}{
+		{"client\x00" + client, "rate limit exceeded for client " + client, l.cfg.PerClient},
+		{"tool\x00" + client + "\x00" + tool, "rate limit exceeded for client " + client + " on tool " + tool, toolRate},
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if len(l.buckets) > maxBuckets {
+		l.sweep(now)
+	}
+
+	var (
+		taken  []*bucket
+		wait   time.Duration
+		reason string
+	)
+	for _, limit := range limits {
+		if limit.rate.unlimited() {
+			continue
+		}
+		b, ok := l.buckets[limit.key]
+		if !ok {
+			b = &bucket{tokens: limit.rate.burst(), last: now}
+			l.buckets[limit.key] = b
+		}
+		b.refill(limit.rate, now)
+		if w := b.wait(limit.rate); w > wait {
+			wait, reason = w, limit.reason
+		}
+		taken = append(taken, b)
+	}
+	if wait > 0 {
+		return &Error{Reason: reason, RetryAfter: wait}
+	}
+	for _, b := range taken {
+		b.tokens--
+	}
+	return nil
+}
+
+// sweep forgets buckets that have been idle long enough to be full again, so they behave as new ones
+func (l *Limiter) sweep(now time.Time) {
+	longest := 0.0
+	for _, rate := range append([]Rate{l.cfg.PerClient, l.cfg.PerTool}, mapValues(l.cfg.Tools)...) {
+		if !rate.unlimited() {
+			longest = math.Max(longest, rate.burst()/rate.PerSecond)
+		}
+	}
+	idle := time.Duration(longest * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= idle {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func mapValues(m map[string]Rate) []Rate {
+	values := make([]Rate, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
+
+// Acquire takes a concurrency slot, waiting up to the queue timeout for one to free up. The returned function
+// releases the slot.
+func (l *Limiter) Acquire(ctx context.Context) (func(), error) {
+	if l.slots == nil {
+		return func() {}, nil
+	}
+	release := func() { <-l.slots }
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+	if l.cfg.QueueTimeout <= 0 {
+		return nil, &Error{Reason: "too many concurrent tool calls", RetryAfter: busyRetryAfter}
+	}
+
+	timer := time.NewTimer(l.cfg.QueueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, &Error{Reason: "timed out waiting for a free slot", RetryAfter: busyRetryAfter}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
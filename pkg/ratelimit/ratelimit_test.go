@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	l := New(Config{
+		PerClient: Rate{PerSecond: 10, Burst: 10},
+		PerTool:   Rate{PerSecond: 5, Burst: 5},
+		Tools:     map[string]Rate{"rental_find_overdue": {PerSecond: 0.5, Burst: 2}},
+	}, 0)
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := l.Allow("agent", "rental_find_overdue"); err != nil {
+			t.Fatalf("Call %d: unexpected error %v", i, err)
+		}
+	}
+	err := l.Allow("agent", "rental_find_overdue")
+	var limited *Error
+	if !errors.As(err, &limited) || !errors.Is(err, ErrLimited) {
+		t.Fatalf("Expected the third call to be limited, got %v", err)
+	}
+	if limited.RetryAfter != 2*time.Second {
+		t.Errorf("Expected to retry after 2s, got %s", limited.RetryAfter)
+	}
+
+	// Other tools and other clients have their own buckets
+	if err := l.Allow("agent", "film_search"); err != nil {
+		t.Errorf("Expected another tool to be allowed, got %v", err)
+	}
+	if err := l.Allow("other-agent", "rental_find_overdue"); err != nil {
+		t.Errorf("Expected another client to be allowed, got %v", err)
+	}
+
+	now = now.Add(2 * time.Second)
+	if err := l.Allow("agent", "rental_find_overdue"); err != nil {
+		t.Errorf("Expected a call after the retry hint to be allowed, got %v", err)
+	}
+}
+
+func TestLimiter_AllowPerClient(t *testing.T) {
+	l := New(Config{PerClient: Rate{PerSecond: 1, Burst: 3}}, 0)
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+
+	for _, tool := range []string{"film_search", "actor_search", "customer_search"} {
+		if err := l.Allow("agent", tool); err != nil {
+			t.Fatalf("Unexpected error for %s: %v", tool, err)
+		}
+	}
+	if err := l.Allow("agent", "film_find_by_id"); !errors.Is(err, ErrLimited) {
+		t.Errorf("Expected the client limit to apply across tools, got %v", err)
+	}
+}
+
+func TestLimiter_Acquire(t *testing.T) {
+	reject := New(Config{}, 1)
+	release, err := reject.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := reject.Acquire(context.Background()); !errors.Is(err, ErrLimited) {
+		t.Errorf("Expected a call beyond the pool size to be rejected, got %v", err)
+	}
+	release()
+	if release, err := reject.Acquire(context.Background()); err != nil {
+		t.Errorf("Expected a released slot to be reused, got %v", err)
+	} else {
+		release()
+	}
+
+	queue := New(Config{MaxConcurrent: 1, QueueTimeout: time.Second}, 10)
+	release, _ = queue.Acquire(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		release()
+	}()
+	if release, err := queue.Acquire(context.Background()); err != nil {
+		t.Errorf("Expected a queued call to get the released slot, got %v", err)
+	} else {
+		release()
+	}
+
+	short := New(Config{QueueTimeout: 10 * time.Millisecond}, 1)
+	_, _ = short.Acquire(context.Background())
+	if _, err := short.Acquire(context.Background()); !errors.Is(err, ErrLimited) {
+		t.Errorf("Expected a queued call to time out, got %v", err)
+	}
+}
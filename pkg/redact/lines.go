@@ -0,0 +1,80 @@
+package redact
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// CSV redacts the columns of CSV data with a header row; dropped columns are removed
+func (r Rules) CSV(data string) (string, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	var (
+		out    strings.Builder
+		header []string
+		keep   []int
+	)
+	writer := csv.NewWriter(&out)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if header == nil {
+			header = record
+			var columns []string
+			for i, name := range header {
+				if _, kept := r.Field(name, ""); kept {
+					keep = append(keep, i)
+					columns = append(columns, name)
+				}
+			}
+			if err := writer.Write(columns); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		row := make([]string, 0, len(keep))
+		for _, i := range keep {
+			value := ""
+			if i < len(record) {
+				value, _ = r.Field(header[i], record[i])
+			}
+			row = append(row, value)
+		}
+		if err := writer.Write(row); err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
+	return out.String(), writer.Error()
+}
+
+// JSONLines redacts every JSON document of newline-delimited JSON data
+func (r Rules) JSONLines(data string) (string, error) {
+	var out strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		redacted, err := r.JSON([]byte(line))
+		if err != nil {
+			return "", err
+		}
+		out.Write(redacted)
+		out.WriteByte('\n')
+	}
+	return out.String(), scanner.Err()
+}
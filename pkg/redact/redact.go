@@ -0,0 +1,206 @@
+package redact
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Action is what happens to the value of a field
+type Action string
+
+const (
+	// Keep leaves the value as is
+	Keep Action = "keep"
+
+	// Drop removes the field
+	Drop Action = "drop"
+
+	// Mask keeps just enough of the value to recognize it, e.g. p***@dvdrental.com
+	Mask Action = "mask"
+
+	// Hash replaces the value with a keyed hash, so equal values can still be matched
+	Hash Action = "hash"
+)
+
+// DefaultFields are the rules applied unless configured otherwise. They cover the contact details of
+// customers and staff, so no raw PII leaves the server by default.
+var DefaultFields = map[string]Action{
+	"email":      Mask,
+	"phone":      Mask,
+	"address":    Drop,
+	"address2":   Drop,
+	"postalcode": Drop,
+	"password":   Drop,
+	"picture":    Drop,
+}
+
+// Config configures redaction
+type Config struct {
+	// Fields override or add to DefaultFields for every role
+	Fields map[string]Action `yaml:"fields" mapstructure:"fields" validate:"dive,oneof=keep drop mask hash"`
+
+	// Roles override the fields for principals with a given policy role, e.g. keep emails for managers
+	Roles map[string]map[string]Action `yaml:"roles" mapstructure:"roles" validate:"dive,dive,oneof=keep drop mask hash"`
+
+	// HashKey keys the hashes, so that short values such as phone numbers cannot be recovered by hashing every
+	// candidate. A random key is used when empty, making hashes stable only until the server restarts.
+	HashKey string `yaml:"hashKey" mapstructure:"hashKey"`
+}
+
+// Redactor applies the configured rules for a role
+type Redactor struct {
+	fields  map[string]Action
+	roles   map[string]map[string]Action
+	hashKey []byte
+}
+
+// New creates a redactor from its configuration
+func New(cfg Config) *Redactor {
+	r := &Redactor{
+		fields:  normalizeFields(DefaultFields, cfg.Fields),
+		roles:   make(map[string]map[string]Action, len(cfg.Roles)),
+		hashKey: []byte(cfg.HashKey),
+	}
+	for role, fields := range cfg.Roles {
+		r.roles[role] = normalizeFields(r.fields, fields)
+	}
+	if len(r.hashKey) == 0 {
+		r.hashKey = make([]byte, 32)
+		_, _ = rand.Read(r.hashKey)
+	}
+	return r
+}
+
+func normalizeFields(base, overrides map[string]Action) map[string]Action {
+	fields := make(map[string]Action, len(base)+len(overrides))
+	for name, action := range base {
+		fields[normalize(name)] = action
+	}
+	for name, action := range overrides {
+		fields[normalize(name)] = action
+	}
+	return fields
+}
+
+// normalize lets a rule match a field however it is spelled: Go field names, JSON keys and column names
+// such as PostalCode, postalCode and postal_code are the same field
+func normalize(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// Rules are the redaction rules of a role
+type Rules struct {
+	fields  map[string]Action
+	hashKey []byte
+}
+
+// Rules returns the rules of a role; roles without their own rules, including the empty role, get the
+// rules configured for everyone
+func (r *Redactor) Rules(role string) Rules {
+	fields, ok := r.roles[role]
+	if !ok {
+		fields = r.fields
+	}
+	return Rules{fields: fields, hashKey: r.hashKey}
+}
+
+// action returns the action for a field
+func (r Rules) action(name string) Action {
+	if action, ok := r.fields[normalize(name)]; ok {
+		return action
+	}
+	return Keep
+}
+
+// Field applies the rule of a field to its value. It returns false when the field is dropped.
+func (r Rules) Field(name, value string) (string, bool) {
+	switch r.action(name) {
+	case Drop:
+		return "", false
+	case Mask:
+		return mask(value), true
+	case Hash:
+		return r.hash(value), true
+	}
+	return value, true
+}
+
+// JSON redacts every object field of a JSON document, at any depth
+func (r Rules) JSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode document to redact: %w", err)
+	}
+	return json.Marshal(r.value(value))
+}
+
+// Value serializes v as JSON and redacts it
+func (r Rules) Value(v any) (json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return r.JSON(data)
+}
+
+func (r Rules) value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for name, field := range v {
+			switch action := r.action(name); {
+			case action == Drop:
+				delete(v, name)
+			case action == Keep:
+				v[name] = r.value(field)
+			default:
+				if s, ok := field.(string); ok {
+					v[name], _ = r.Field(name, s)
+				} else if field != nil {
+					// Structured values cannot be masked meaningfully
+					delete(v, name)
+				}
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = r.value(item)
+		}
+	}
+	return v
+}
+
+// mask keeps the first character of a value, and the domain of an email address or the last four digits of
+// a phone number
+func mask(value string) string {
+	if value == "" {
+		return ""
+	}
+	if local, domain, ok := strings.Cut(value, "@"); ok && local != "" {
+		return string([]rune(local)[:1]) + "***@" + domain
+	}
+	if digits := strings.Map(keepDigit, value); len(digits) >= 7 && len(digits) >= len(value)/2 {
+		return "***" + digits[len(digits)-4:]
+	}
+	return string([]rune(value)[:1]) + "***"
+}
+
+func keepDigit(r rune) rune {
+	if r >= '0' && r <= '9' {
+		return r
+	}
+	return -1
+}
+
+func (r Rules) hash(value string) string {
+	mac := hmac.New(sha256.New, r.hashKey)
+	mac.Write([]byte(value))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
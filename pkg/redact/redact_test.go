@@ -0,0 +1,67 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRules_JSON(t *testing.T) {
+	rules := New(Config{}).Rules("")
+
+	out, err := rules.JSON([]byte(`[{"Email":"patricia.johnson@dvdrental.com","Phone":"838635286649","Address":"1121 Loja Avenue",` +
+		`"City":"San Bernardino","Store":{"StoreID":1},"Customer":{"email":"mary@dvdrental.com","postal_code":"17886"}}]`))
+	if err != nil {
+		t.Fatalf("Failed to redact: %v", err)
+	}
+
+	expected := `[{"City":"San Bernardino","Customer":{"email":"m***@dvdrental.com"},"Email":"p***@dvdrental.com","Phone":"***6649","Store":{"StoreID":1}}]`
+	if string(out) != expected {
+		t.Errorf("Expected %s, got %s", expected, out)
+	}
+}
+
+func TestRules_Roles(t *testing.T) {
+	r := New(Config{
+		Fields:  map[string]Action{"LastName": Hash},
+		Roles:   map[string]map[string]Action{"manager": {"email": Keep}},
+		HashKey: "test-key",
+	})
+
+	value, _ := r.Rules("manager").Field("Email", "mary@dvdrental.com")
+	if value != "mary@dvdrental.com" {
+		t.Errorf("Expected managers to see emails, got %q", value)
+	}
+	if value, _ := r.Rules("clerk").Field("Email", "mary@dvdrental.com"); value != "m***@dvdrental.com" {
+		t.Errorf("Expected other roles to get the default rules, got %q", value)
+	}
+
+	smith, _ := r.Rules("manager").Field("last_name", "Smith")
+	again, _ := r.Rules("").Field("LastName", "Smith")
+	if !strings.HasPrefix(smith, "hmac:") || smith != again {
+		t.Errorf("Expected a stable keyed hash, got %q and %q", smith, again)
+	}
+	other, _ := New(Config{Fields: map[string]Action{"LastName": Hash}, HashKey: "other-key"}).Rules("").Field("LastName", "Smith")
+	if other == smith {
+		t.Error("Expected hashes to depend on the key")
+	}
+}
+
+func TestRules_CSVAndJSONLines(t *testing.T) {
+	rules := New(Config{}).Rules("")
+
+	csv, err := rules.CSV("customer_id,email,address,phone\n1,mary@dvdrental.com,1913 Hanoi Way,28303384290\n")
+	if err != nil {
+		t.Fatalf("Failed to redact CSV: %v", err)
+	}
+	if expected := "customer_id,email,phone\n1,m***@dvdrental.com,***4290\n"; csv != expected {
+		t.Errorf("Expected %q, got %q", expected, csv)
+	}
+
+	lines, err := rules.JSONLines(`{"customer_id":1,"address":"1913 Hanoi Way"}` + "\n" + `{"customer_id":2,"email":"barbara@dvdrental.com"}` + "\n")
+	if err != nil {
+		t.Fatalf("Failed to redact JSON Lines: %v", err)
+	}
+	if expected := `{"customer_id":1}` + "\n" + `{"customer_id":2,"email":"b***@dvdrental.com"}` + "\n"; lines != expected {
+		t.Errorf("Expected %q, got %q", expected, lines)
+	}
+}
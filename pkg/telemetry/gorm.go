@@ -0,0 +1,104 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// spanKey stores the span of a statement on the statement
+const spanKey = "telemetry:span"
+
+// statementSpan is the span of a running statement and the context it was started from
+type statementSpan struct {
+	span   trace.Span
+	parent context.Context
+}
+
+// RegisterGORM adds callbacks to db that trace every statement it runs with a client span carrying the
+// table, operation and number of rows affected or returned. Statements run within a traced context become
+// children of its span; the SQL is recorded with placeholders, never with values.
+func RegisterGORM(db *gorm.DB) error {
+	callbacks := db.Callback()
+	err := errors.Join(
+		callbacks.Create().Before("gorm:create").Register("telemetry:before_create", startSpan),
+		callbacks.Create().After("gorm:create").Register("telemetry:after_create", endSpan("INSERT")),
+		callbacks.Query().Before("gorm:query").Register("telemetry:before_query", startSpan),
+		callbacks.Query().After("gorm:query").Register("telemetry:after_query", endSpan("SELECT")),
+		callbacks.Update().Before("gorm:update").Register("telemetry:before_update", startSpan),
+		callbacks.Update().After("gorm:update").Register("telemetry:after_update", endSpan("UPDATE")),
+		callbacks.Delete().Before("gorm:delete").Register("telemetry:before_delete", startSpan),
+		callbacks.Delete().After("gorm:delete").Register("telemetry:after_delete", endSpan("DELETE")),
+		callbacks.Row().Before("gorm:row").Register("telemetry:before_row", startSpan),
+		callbacks.Row().After("gorm:row").Register("telemetry:after_row", endSpan("")),
+		callbacks.Raw().Before("gorm:raw").Register("telemetry:before_raw", startSpan),
+		callbacks.Raw().After("gorm:raw").Register("telemetry:after_raw", endSpan("")),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register telemetry callbacks: %w", err)
+	}
+	return nil
+}
+
+// startSpan starts the span of a statement and runs the statement within it
+func startSpan(db *gorm.DB) {
+	if db.DryRun || db.Statement.Context == nil {
+		return
+	}
+	ctx, span := otel.Tracer(tracerName).Start(db.Statement.Context, "db", trace.WithSpanKind(trace.SpanKindClient))
+	db.InstanceSet(spanKey, &statementSpan{span: span, parent: db.Statement.Context})
+	db.Statement.Context = ctx
+}
+
+// endSpan ends the span of a statement. Raw statements and row queries take their operation from the SQL.
+func endSpan(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(spanKey)
+		if !ok {
+			return
+		}
+		s, ok := value.(*statementSpan)
+		if !ok {
+			return
+		}
+		db.Statement.Context = s.parent
+
+		sql := db.Statement.SQL.String()
+		if operation == "" {
+			operation = sqlOperation(sql)
+		}
+		name := operation
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+		s.span.SetName(name)
+		s.span.SetAttributes(
+			attribute.String("db.system.name", db.Dialector.Name()),
+			attribute.String("db.operation.name", operation),
+			attribute.String("db.collection.name", db.Statement.Table),
+			attribute.String("db.query.text", sql),
+			attribute.Int64("db.rows_affected", db.RowsAffected),
+		)
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			s.span.RecordError(db.Error)
+			s.span.SetStatus(codes.Error, db.Error.Error())
+		}
+		s.span.End()
+	}
+}
+
+// sqlOperation returns the first keyword of a statement in upper case
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "RAW"
+	}
+	return strings.ToUpper(fields[0])
+}
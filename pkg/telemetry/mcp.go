@@ -0,0 +1,55 @@
+package telemetry
+
+import (
+	"CortexMCP/pkg/mcp"
+	"context"
+	"encoding/json"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceRequests is request middleware starting a server span for every MCP request and notification,
+// named after the method and carrying the tool called or resource read. The statements a request runs
+// become children of its span.
+func TraceRequests(next mcp.RequestHandler) mcp.RequestHandler {
+	return func(ctx context.Context, req *mcp.Request) (any, error) {
+		attributes := []attribute.KeyValue{
+			attribute.String("rpc.system", "jsonrpc"),
+			attribute.String("rpc.method", req.Method),
+		}
+		if client := mcp.ClientFromContext(ctx); client.Name != "" {
+			attributes = append(attributes, attribute.String("mcp.client.name", client.Name))
+		}
+		var params struct {
+			Name string `json:"name"`
+			URI  string `json:"uri"`
+		}
+		if len(req.Params) > 0 && json.Unmarshal(req.Params, &params) == nil {
+			switch req.Method {
+			case "tools/call":
+				attributes = append(attributes, attribute.String("mcp.tool.name", params.Name))
+			case "resources/read":
+				attributes = append(attributes, attribute.String("mcp.resource.uri", params.URI))
+			}
+		}
+
+		ctx, span := otel.Tracer(tracerName).Start(ctx, "mcp "+req.Method,
+			trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attributes...))
+		defer span.End()
+
+		result, err := next(ctx, req)
+		if err != nil {
+			var rpcErr *mcp.Error
+			if errors.As(err, &rpcErr) {
+				span.SetAttributes(attribute.Int("rpc.jsonrpc.error_code", rpcErr.Code))
+			}
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return result, err
+	}
+}
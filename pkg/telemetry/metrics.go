@@ -0,0 +1,62 @@
+package telemetry
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics are the Prometheus metrics of the server, kept in their own registry
+type Metrics struct {
+	registry *prometheus.Registry
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// NewMetrics creates the tool call metrics along with the Go runtime and process metrics
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mcp_tool_call_duration_seconds",
+			Help:    "Duration of MCP tool calls by tool and outcome (ok or error).",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"tool", "outcome"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mcp_tool_call_errors_total",
+			Help: "Failed MCP tool calls by tool and error kind.",
+		}, []string{"tool", "error"}),
+	}
+	m.registry.MustRegister(m.duration, m.errors, collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return m
+}
+
+// ObserveToolCall records a tool call that took d; kind is empty for successful calls and names the error
+// otherwise
+func (m *Metrics) ObserveToolCall(tool string, d time.Duration, kind string) {
+	outcome := "ok"
+	if kind != "" {
+		outcome = "error"
+		m.errors.WithLabelValues(tool, kind).Inc()
+	}
+	m.duration.WithLabelValues(tool, outcome).Observe(d.Seconds())
+}
+
+// RegisterDBStats exposes the connection pool statistics of db, labelled with the database name
+func (m *Metrics) RegisterDBStats(db *sql.DB, name string) error {
+	if err := m.registry.Register(collectors.NewDBStatsCollector(db, name)); err != nil {
+		return fmt.Errorf("failed to register database metrics: %w", err)
+	}
+	return nil
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
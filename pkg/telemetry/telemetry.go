@@ -0,0 +1,102 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// tracerName is the instrumentation scope of the spans started by this package
+const tracerName = "CortexMCP/pkg/telemetry"
+
+// Span exporters
+const (
+	ExporterNone    = "none"
+	ExporterConsole = "console"
+	ExporterOTLP    = "otlp"
+)
+
+// Config configures tracing and metrics
+type Config struct {
+	Tracing TracingConfig `yaml:"tracing" mapstructure:"tracing"`
+	Metrics MetricsConfig `yaml:"metrics" mapstructure:"metrics"`
+}
+
+// TracingConfig configures where spans are exported to
+type TracingConfig struct {
+	// Exporter is none, console (JSON on stderr, as stdout carries the stdio transport) or otlp
+	Exporter string `yaml:"exporter" mapstructure:"exporter" validate:"omitempty,oneof=none console otlp"`
+
+	// Endpoint is the host:port of the OTLP/HTTP collector. Defaults to OTEL_EXPORTER_OTLP_ENDPOINT, then
+	// localhost:4318.
+	Endpoint string `yaml:"endpoint" mapstructure:"endpoint"`
+
+	// Insecure sends spans to the collector over plain HTTP
+	Insecure bool `yaml:"insecure" mapstructure:"insecure"`
+
+	// SampleRatio is the fraction of traces recorded, unless the caller's trace is already sampled
+	SampleRatio float64 `yaml:"sampleRatio" mapstructure:"sampleRatio" validate:"min=0,max=1"`
+}
+
+// MetricsConfig configures the Prometheus metrics endpoint
+type MetricsConfig struct {
+	// Addr is a separate address to serve metrics on, e.g. ":9090", so they are available over the stdio
+	// transport too. Empty disables the separate listener.
+	Addr string `yaml:"addr" mapstructure:"addr"`
+
+	// Path is the path metrics are served at, on Addr and next to the HTTP transport
+	Path string `yaml:"path" mapstructure:"path" validate:"required,startswith=/"`
+}
+
+// SetupTracing installs a global tracer provider exporting spans as configured and returns the function
+// flushing and stopping it. Nothing is installed when the exporter is none.
+func SetupTracing(ctx context.Context, cfg TracingConfig, service, version string) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterConsole:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported span exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create span exporter: %w", err)
+	}
+
+	provider := NewTracerProvider(sdktrace.NewBatchSpanProcessor(exporter), cfg.SampleRatio, service, version)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// NewTracerProvider creates a tracer provider sending sampled spans to processor. Tests pass a
+// synchronous processor over an in-memory exporter.
+func NewTracerProvider(processor sdktrace.SpanProcessor, sampleRatio float64, service, version string) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", service),
+			attribute.String("service.version", version),
+		)),
+	)
+}
@@ -0,0 +1,162 @@
+package telemetry
+
+import (
+	"CortexMCP/db/entity"
+	"CortexMCP/pkg/mcp"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// recordSpans installs a global tracer provider recording every span in memory for the duration of the test
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewTracerProvider(sdktrace.NewSimpleSpanProcessor(exporter), 1, "test", "dev")
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return exporter
+}
+
+func attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	values := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, kv := range span.Attributes {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func setupGORMTest(t *testing.T) (sqlmock.Sqlmock, *gorm.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open gorm database: %v", err)
+	}
+	if err := RegisterGORM(gormDB); err != nil {
+		t.Fatalf("Failed to register telemetry callbacks: %v", err)
+	}
+	return mock, gormDB
+}
+
+func TestRegisterGORM(t *testing.T) {
+	spans := recordSpans(t)
+	mock, db := setupGORMTest(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `customer` WHERE store_id = ? AND `customer`.`deleted_at` IS NULL")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"customer_id"}).AddRow(1).AddRow(2))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `inventory` SET `store_id`=?,`updated_at`=? WHERE inventory_id = ? AND `inventory`.`deleted_at` IS NULL")).
+		WithArgs(2, sqlmock.AnyArg(), 10).
+		WillReturnError(errors.New("deadlock"))
+	mock.ExpectRollback()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	var customers []entity.Customer
+	if err := db.WithContext(ctx).Where("store_id = ?", 1).Find(&customers).Error; err != nil {
+		t.Fatalf("Error finding customers: %v", err)
+	}
+	if err := db.WithContext(ctx).Model(&entity.Inventory{}).Where("inventory_id = ?", 10).Update("store_id", 2).Error; err == nil {
+		t.Fatal("Expected the update to fail")
+	}
+	parent.End()
+
+	recorded := spans.GetSpans()
+	if len(recorded) != 3 {
+		t.Fatalf("Expected 2 statement spans and the parent span, got %d", len(recorded))
+	}
+	query, update := recorded[0], recorded[1]
+	if query.Name != "SELECT customer" || update.Name != "UPDATE inventory" {
+		t.Errorf("Unexpected span names %q and %q", query.Name, update.Name)
+	}
+	if query.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Error("Expected the statement span to be a child of the request span")
+	}
+	values := attributes(query)
+	if values["db.collection.name"].AsString() != "customer" || values["db.operation.name"].AsString() != "SELECT" ||
+		values["db.rows_affected"].AsInt64() != 2 || values["db.system.name"].AsString() != "mysql" {
+		t.Errorf("Unexpected query attributes: %v", query.Attributes)
+	}
+	if strings.Contains(values["db.query.text"].AsString(), "1") {
+		t.Errorf("Expected the SQL without values, got %q", values["db.query.text"].AsString())
+	}
+	if update.Status.Code != codes.Error || len(update.Events) == 0 {
+		t.Errorf("Expected the failed update to be recorded as an error, got %+v", update.Status)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestTraceRequests(t *testing.T) {
+	spans := recordSpans(t)
+	s := mcp.NewServer("test", "dev")
+	s.UseRequest(TraceRequests)
+	s.AddTool(mcp.Tool{Name: "fail"}, func(ctx context.Context, args mcp.Arguments) (any, error) {
+		return nil, mcp.NewError(-32004, "record not found")
+	})
+
+	s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"fail"}}`))
+	s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":2,"method":"ping"}`))
+
+	recorded := spans.GetSpans()
+	if len(recorded) != 2 || recorded[0].Name != "mcp tools/call" || recorded[1].Name != "mcp ping" {
+		t.Fatalf("Expected a span per request, got %+v", recorded)
+	}
+	values := attributes(recorded[0])
+	if values["mcp.tool.name"].AsString() != "fail" || values["rpc.jsonrpc.error_code"].AsInt64() != -32004 {
+		t.Errorf("Unexpected tool call attributes: %v", recorded[0].Attributes)
+	}
+	if recorded[0].Status.Code != codes.Error || recorded[1].Status.Code == codes.Error {
+		t.Errorf("Expected only the failed call to have an error status")
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	m := NewMetrics()
+	if err := m.RegisterDBStats(db, "dvdrental"); err != nil {
+		t.Fatalf("Failed to register database metrics: %v", err)
+	}
+	m.ObserveToolCall("film_find", 20*time.Millisecond, "")
+	m.ObserveToolCall("film_find", 5*time.Millisecond, "not_found")
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`mcp_tool_call_duration_seconds_count{outcome="ok",tool="film_find"} 1`,
+		`mcp_tool_call_duration_seconds_count{outcome="error",tool="film_find"} 1`,
+		`mcp_tool_call_errors_total{error="not_found",tool="film_find"} 1`,
+		`go_sql_open_connections{db_name="dvdrental"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %s", want)
+		}
+	}
+}